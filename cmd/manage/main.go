@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"ddd-example/internal/app"
+	"ddd-example/internal/app/handler"
+	"ddd-example/internal/infra"
+	"ddd-example/internal/option"
+	"ddd-example/pkg/keyring"
+	"ddd-example/pkg/logger"
+
+	"github.com/joyparty/gokit"
+	"github.com/samber/do/v2"
+)
+
+var (
+	// 系统配置
+	opt = &option.Options{}
+
+	// 子命令
+	commands = map[string]command{
+		"bootstrap-admin": {
+			usage: "grant super admin role to the first admin, args: <email>",
+			run:   bootstrapAdmin,
+		},
+		"rotate-keys": {
+			usage: "rotate session signing keys",
+			run:   rotateKeys,
+		},
+	}
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+func init() {
+	flag.StringVar(&opt.ConfigFile, "config", "", "config file")
+	flag.StringVar(&opt.LogLevel, "logLevel", "", "log level")
+	flag.StringVar(&opt.DBDir, "dbDir", "", "database dir")
+	flag.Usage = usage
+	flag.Parse()
+
+	slog.SetDefault(gokit.MustReturn(
+		logger.New(logger.Option{
+			Level: opt.LogLevel,
+		}),
+	))
+
+	if opt.ConfigFile == "" {
+		logAndExist("need config file")
+	} else if err := opt.LoadFile(opt.ConfigFile); err != nil {
+		logAndExist("load config file", "error", err)
+	}
+}
+
+func main() {
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(args[1:]); err != nil {
+		logAndExist(args[0], "error", err)
+	}
+}
+
+// rotateKeys 轮换会话签名密钥
+//
+// 旧密钥在宽限期内仍然可以验证已下发的凭证，运行中的服务会自动载入新密钥
+func rotateKeys(_ []string) error {
+	file := opt.SessionKeyRingFile()
+
+	keys, err := keyring.Load(file, opt.SessionKeyGrace())
+	if err != nil {
+		return fmt.Errorf("load keyring, %w", err)
+	}
+
+	key, err := keys.Rotate()
+	if err != nil {
+		return fmt.Errorf("rotate, %w", err)
+	} else if err := keys.Save(); err != nil {
+		return fmt.Errorf("save keyring, %w", err)
+	}
+
+	logger.Info(context.Background(), "rotate session keys", "file", file, "active", key.ID)
+	return nil
+}
+
+// bootstrapAdmin 把已注册的账号设置为第一个超级管理员
+//
+// 已经存在超级管理员时不做任何修改，之后的角色分配通过/admin接口完成
+func bootstrapAdmin(args []string) error {
+	if len(args) != 1 {
+		return errors.New("need email")
+	} else if err := opt.Prepare(); err != nil {
+		return fmt.Errorf("prepare resources, %w", err)
+	}
+
+	injector := do.New(
+		opt.Providers(),
+		infra.Providers,
+		app.Providers,
+	)
+	defer injector.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	account, err := do.MustInvoke[*handler.BootstrapAdminHandler](injector).Handle(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	logger.Info(ctx, "bootstrap super admin", "account", account.ID, "email", account.Email)
+	return nil
+}
+
+func usage() {
+	output := flag.CommandLine.Output()
+
+	fmt.Fprintf(output, "Usage: %s [flags] <command> [args]\n\nCommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(output, "  %-16s%s\n", name, commands[name].usage)
+	}
+
+	fmt.Fprintln(output, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+func logAndExist(msg string, args ...any) {
+	logger.Error(context.TODO(), msg, args...)
+	os.Exit(1) // revive:disable-line
+}
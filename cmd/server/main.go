@@ -7,12 +7,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"ddd-example/internal/app"
 	"ddd-example/internal/infra"
 	"ddd-example/internal/option"
 	"ddd-example/internal/presentation/httpapi"
 	"ddd-example/internal/presentation/observer"
+	"ddd-example/internal/presentation/worker"
 	"ddd-example/pkg/logger"
 
 	"github.com/joyparty/gokit"
@@ -60,7 +62,8 @@ func main() {
 	defer cancel()
 
 	server := do.MustInvoke[*httpapi.Server](injector)
-	observer.Start(ctx, opt)
+	observer.Start(ctx, injector)
+	worker.Start(ctx, injector)
 
 	<-ctx.Done()
 	if err := server.Close(); err != nil {
@@ -68,8 +71,17 @@ func main() {
 	} else {
 		logger.Info(ctx, "shutdown server")
 	}
+	// 先停止outbox投递，再关闭事件流
+	worker.Stop()
 	observer.Stop()
 
+	// 等待邮件等后台发送完成
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if report := injector.ShutdownWithContext(shutdownCtx); !report.Succeed {
+		logger.Error(ctx, "shutdown services", "error", report)
+	}
+
 	os.Exit(0)
 }
 
@@ -0,0 +1,28 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountEvent 账号相关的领域事件记录
+type AccountEvent struct {
+	ID        uuid.UUID
+	AccountID uuid.UUID
+	Type      string
+	// 事件数据，去掉了凭证等敏感字段
+	Data       []byte
+	OccurredAt time.Time
+}
+
+// AccountEventRepository 账号事件记录，用于登录历史和个人数据导出
+type AccountEventRepository interface {
+	// Add 保存事件记录，已经存在的事件忽略，事件投递语义为至少一次
+	Add(ctx context.Context, event *AccountEvent) error
+	// ListByAccount 账号的事件记录，types为空时不限类型，最新的在前
+	ListByAccount(ctx context.Context, accountID uuid.UUID, types []string, limit int) ([]*AccountEvent, error)
+	// DeleteByAccount 删除账号的所有事件记录
+	DeleteByAccount(ctx context.Context, accountID uuid.UUID) error
+}
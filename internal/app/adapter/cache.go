@@ -10,4 +10,6 @@ type Cacher interface {
 	Put(ctx context.Context, key string, value []byte, ttl time.Duration) error
 	Get(ctx context.Context, key string) (value []byte, err error)
 	Delete(ctx context.Context, key string) error
+	// Take 读取并删除，并发调用时只有一个能拿到值，用于一次性凭证
+	Take(ctx context.Context, key string) (value []byte, err error)
 }
@@ -0,0 +1,17 @@
+package adapter
+
+import "context"
+
+// Mail 待发送的邮件
+type Mail struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer 邮件发送
+type Mailer interface {
+	// Send 发送邮件，实现可以是异步的，返回nil不代表已经送达
+	Send(ctx context.Context, mail *Mail) error
+}
@@ -0,0 +1,40 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// outbox消息状态
+const (
+	OutboxPending    = "pending"
+	OutboxDispatched = "dispatched"
+	OutboxFailed     = "failed"
+)
+
+// OutboxMessage 等待投递的领域事件
+type OutboxMessage struct {
+	ID            uuid.UUID
+	Type          string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	// 已经处理成功的消费者，重试时跳过
+	Handled  []string
+	CreateAt time.Time
+}
+
+// OutboxRepository 领域事件outbox，和业务数据在同一个事务内写入
+type OutboxRepository interface {
+	Add(ctx context.Context, messages ...*OutboxMessage) error
+	// Claim 领取到期的待投递消息，lease时长之内不会被再次领取，多个服务实例可以同时领取
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error)
+	// Update 保存投递结果
+	Update(ctx context.Context, message *OutboxMessage) error
+	// Purge 删除指定状态并且在before之前更新的消息
+	Purge(ctx context.Context, status string, before time.Time) (int64, error)
+}
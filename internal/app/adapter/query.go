@@ -0,0 +1,29 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"ddd-example/internal/domain"
+)
+
+// AccountFilter 账号查询条件，零值的条件不生效
+type AccountFilter struct {
+	// Email 包含匹配
+	Email  string
+	Status string
+	// 注册时间范围，包含CreatedFrom，不包含CreatedTo
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+
+	Offset int
+	Limit  int
+}
+
+// AccountQuery 账号查询，管理后台和后台任务使用的读模型
+type AccountQuery interface {
+	// Search 按条件查询账号，最新注册的在前，同时返回符合条件的总数
+	Search(ctx context.Context, filter AccountFilter) (accounts []*domain.Account, total int, err error)
+	// ListStatusChanged 查找处于指定状态，并且最近一次状态变更早于before的账号，最早变更的在前
+	ListStatusChanged(ctx context.Context, status string, before time.Time, limit int) ([]*domain.Account, error)
+}
@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"context"
+	"time"
 
 	"ddd-example/internal/domain"
 
@@ -14,10 +15,81 @@ type AccountRepository interface {
 	FindByEmail(ctx context.Context, email string) (*domain.Account, error)
 	Create(ctx context.Context, account *domain.Account) error
 	Update(ctx context.Context, account *domain.Account) error
+	// Delete 删除账号以及角色关联，只用于清除已注销的账号
+	Delete(ctx context.Context, accountID uuid.UUID) error
+}
+
+// DataExportRepository 个人数据导出记录存储
+type DataExportRepository interface {
+	Find(ctx context.Context, exportID uuid.UUID) (*domain.DataExport, error)
+	// ListByAccount 账号的导出记录，最新的在前
+	ListByAccount(ctx context.Context, accountID uuid.UUID, limit int) ([]*domain.DataExport, error)
+	Create(ctx context.Context, export *domain.DataExport) error
+	Update(ctx context.Context, export *domain.DataExport) error
+	Delete(ctx context.Context, exportID uuid.UUID) error
+	// Claim 领取到期的待生成记录，lease时长之内不会被再次领取
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*domain.DataExport, error)
+	// ListExpired 已生成或者已失败，并且在before之前过期的记录
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.DataExport, error)
 }
 
 // OauthRepository 三方账号关联
 type OauthRepository interface {
 	Bind(ctx context.Context, accountID uuid.UUID, vendor, vendorUID string) error
 	Find(ctx context.Context, vendor, vendorUID string) (uuid.UUID, error)
+	ListByAccount(ctx context.Context, accountID uuid.UUID) ([]*domain.OauthIdentity, error)
+	Unbind(ctx context.Context, accountID uuid.UUID, vendor string) error
+	// UnbindAll 解除账号的所有三方账号绑定
+	UnbindAll(ctx context.Context, accountID uuid.UUID) error
+}
+
+// RoleRepository 角色存储，内置角色由数据库迁移脚本创建
+type RoleRepository interface {
+	Find(ctx context.Context, name string) (*domain.Role, error)
+	List(ctx context.Context) ([]*domain.Role, error)
+	// ListByNames 查询指定的角色，忽略不存在的角色
+	ListByNames(ctx context.Context, names []string) ([]*domain.Role, error)
+	// CountAccounts 拥有角色的账号数量
+	CountAccounts(ctx context.Context, name string) (int, error)
+}
+
+// SessionRepository 登录会话存储
+type SessionRepository interface {
+	Find(ctx context.Context, sessionID uuid.UUID) (*domain.Session, error)
+	ListByAccount(ctx context.Context, accountID uuid.UUID) ([]*domain.Session, error)
+	Create(ctx context.Context, session *domain.Session) error
+	Update(ctx context.Context, session *domain.Session) error
+	Delete(ctx context.Context, sessionID uuid.UUID) error
+	// DeleteByAccount 删除账号的所有会话，except指定的会话除外
+	DeleteByAccount(ctx context.Context, accountID uuid.UUID, except ...uuid.UUID) error
+	// DeleteExpired 删除在before之前过期的会话，返回删除的数量
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookRepository webhook配置存储
+type WebhookRepository interface {
+	Find(ctx context.Context, webhookID uuid.UUID) (*domain.Webhook, error)
+	List(ctx context.Context) ([]*domain.Webhook, error)
+	Create(ctx context.Context, hook *domain.Webhook) error
+	Update(ctx context.Context, hook *domain.Webhook) error
+	// Delete 删除webhook以及投递记录
+	Delete(ctx context.Context, webhookID uuid.UUID) error
+}
+
+// WebhookDeliveryRepository webhook投递记录存储
+type WebhookDeliveryRepository interface {
+	Find(ctx context.Context, deliveryID uuid.UUID) (*domain.WebhookDelivery, error)
+	// Create 保存投递记录，同一事件对同一webhook已经有记录时忽略，避免重复投递
+	Create(ctx context.Context, delivery *domain.WebhookDelivery) error
+	Update(ctx context.Context, delivery *domain.WebhookDelivery) error
+	// Claim 领取到期的待投递记录，lease时长之内不会被再次领取
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error)
+	// ListByWebhook 查询webhook的投递记录，status为空时不限状态，最新的在前
+	ListByWebhook(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]*domain.WebhookDelivery, error)
+}
+
+// WebhookSender 发送webhook请求
+type WebhookSender interface {
+	// Send 发送请求，返回对方的http状态码
+	Send(ctx context.Context, hook *domain.Webhook, delivery *domain.WebhookDelivery) (statusCode int, err error)
 }
@@ -0,0 +1,16 @@
+package adapter
+
+import (
+	"context"
+	"io"
+)
+
+// FileStorage 文件存储，文件名可以包含子目录
+type FileStorage interface {
+	// Put 保存文件，返回写入的字节数
+	Put(ctx context.Context, name string, r io.Reader) (int64, error)
+	// Open 打开文件，文件不存在时返回fs.ErrNotExist
+	Open(ctx context.Context, name string) (io.ReadSeekCloser, error)
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, name string) error
+}
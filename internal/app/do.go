@@ -10,14 +10,77 @@ import (
 // Providers 依赖注入配置
 var Providers = do.Package(
 	do.Lazy(do.InvokeStruct[*service.AccountService]),
+	do.Lazy(do.InvokeStruct[*service.DataExportService]),
+	do.Lazy(do.InvokeStruct[*service.EmailChangeService]),
+	do.Lazy(do.InvokeStruct[*service.EmailVerificationService]),
+	do.Lazy(do.InvokeStruct[*service.MagicLinkService]),
+	do.Lazy(do.InvokeStruct[*service.MailService]),
+	do.Lazy(do.InvokeStruct[*service.LoginGuardService]),
+	do.Lazy(do.InvokeStruct[*service.MFAChallengeService]),
+	do.Lazy(do.InvokeStruct[*service.OauthStateService]),
 	do.Lazy(do.InvokeStruct[*service.OauthTokenService]),
+	do.Lazy(do.InvokeStruct[*service.OutboxService]),
+	do.Lazy(do.InvokeStruct[*service.PasswordResetService]),
+	do.Lazy(do.InvokeStruct[*service.RoleService]),
 	do.Lazy(do.InvokeStruct[*service.SessionTokenService]),
+	do.Lazy(do.InvokeStruct[*service.SignedTokenService]),
+	do.Lazy(do.InvokeStruct[*service.WebhookService]),
 
+	do.Lazy(do.InvokeStruct[*handler.AssignRoleHandler]),
 	do.Lazy(do.InvokeStruct[*handler.AuthorizeHandler]),
+	do.Lazy(do.InvokeStruct[*handler.BootstrapAdminHandler]),
+	do.Lazy(do.InvokeStruct[*handler.BuildDataExportsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ChangeEmailHandler]),
 	do.Lazy(do.InvokeStruct[*handler.ChangePasswordHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ConfirmEmailChangeHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ConfirmTOTPHandler]),
+	do.Lazy(do.InvokeStruct[*handler.CreateWebhookHandler]),
+	do.Lazy(do.InvokeStruct[*handler.DeleteAccountHandler]),
+	do.Lazy(do.InvokeStruct[*handler.DeleteWebhookHandler]),
+	do.Lazy(do.InvokeStruct[*handler.DeliverWebhooksHandler]),
+	do.Lazy(do.InvokeStruct[*handler.DisableAccountHandler]),
+	do.Lazy(do.InvokeStruct[*handler.DisableTOTPHandler]),
+	do.Lazy(do.InvokeStruct[*handler.DispatchOutboxHandler]),
+	do.Lazy(do.InvokeStruct[*handler.DownloadDataExportHandler]),
+	do.Lazy(do.InvokeStruct[*handler.EnableAccountHandler]),
+	do.Lazy(do.InvokeStruct[*handler.EnqueueWebhooksHandler]),
+	do.Lazy(do.InvokeStruct[*handler.EnrollTOTPHandler]),
+	do.Lazy(do.InvokeStruct[*handler.GetAccountHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ListOauthHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ListRolesHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ListSessionsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ListWebhookDeliveriesHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ListWebhooksHandler]),
+	do.Lazy(do.InvokeStruct[*handler.LockAccountHandler]),
 	do.Lazy(do.InvokeStruct[*handler.LoginWithEmailHandler]),
+	do.Lazy(do.InvokeStruct[*handler.LoginWithOauthHandler]),
 	do.Lazy(do.InvokeStruct[*handler.LogoutHandler]),
+	do.Lazy(do.InvokeStruct[*handler.PurgeAccountsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.PurgeDataExportsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.PurgeOutboxHandler]),
+	do.Lazy(do.InvokeStruct[*handler.PurgeSessionsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.RecordAccountEventHandler]),
 	do.Lazy(do.InvokeStruct[*handler.RegisterHandler]),
 	do.Lazy(do.InvokeStruct[*handler.RegisterWithOauthHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ReplayWebhookDeliveryHandler]),
+	do.Lazy(do.InvokeStruct[*handler.RequestDataExportHandler]),
+	do.Lazy(do.InvokeStruct[*handler.RequestMagicLinkHandler]),
+	do.Lazy(do.InvokeStruct[*handler.RequestPasswordResetHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ResendVerificationHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ResetPasswordHandler]),
+	do.Lazy(do.InvokeStruct[*handler.ResolvePermissionsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.RevertEmailChangeHandler]),
+	do.Lazy(do.InvokeStruct[*handler.RevokeOtherSessionsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.RevokeRoleHandler]),
+	do.Lazy(do.InvokeStruct[*handler.RevokeSessionHandler]),
+	do.Lazy(do.InvokeStruct[*handler.SearchAccountsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.SendAccountEmailHandler]),
+	do.Lazy(do.InvokeStruct[*handler.SendPasswordResetHandler]),
+	do.Lazy(do.InvokeStruct[*handler.SuspendAccountSessionsHandler]),
+	do.Lazy(do.InvokeStruct[*handler.UnbindOauthHandler]),
+	do.Lazy(do.InvokeStruct[*handler.UnlockAccountHandler]),
+	do.Lazy(do.InvokeStruct[*handler.VerifyEmailHandler]),
+	do.Lazy(do.InvokeStruct[*handler.VerifyMagicLinkHandler]),
+	do.Lazy(do.InvokeStruct[*handler.VerifyMFAHandler]),
 	do.Lazy(do.InvokeStruct[*handler.VerifyOauthHandler]),
 )
@@ -0,0 +1,137 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Meta 事件元数据，每个领域事件都需要嵌入
+//
+// 事件投递语义为至少一次，消费者可以使用ID去重
+type Meta struct {
+	ID         uuid.UUID `json:"-"`
+	OccurredAt time.Time `json:"-"`
+	// 事件数据结构版本，不兼容的修改需要增加版本
+	Version int `json:"-"`
+}
+
+func (m *Meta) meta() *Meta {
+	return m
+}
+
+// Envelope 事件的JSON序列化格式，用于日志、webhook和outbox
+type Envelope struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type eventType struct {
+	name    string
+	version int
+	typ     reflect.Type
+	// 只在系统内部使用，不投递到webhook
+	private bool
+}
+
+// 可以序列化的事件类型
+var (
+	typesByName = map[string]eventType{}
+	typesByType = map[reflect.Type]eventType{}
+)
+
+func register(name string, version int, event any) {
+	t := eventType{
+		name:    name,
+		version: version,
+		typ:     reflect.TypeOf(event),
+	}
+	typesByName[name] = t
+	typesByType[t.typ] = t
+}
+
+// registerPrivate 注册内部事件，例如触发凭证邮件的申请，外部系统不能订阅
+func registerPrivate(name string, version int, event any) {
+	register(name, version, event)
+
+	t := typesByName[name]
+	t.private = true
+	typesByName[name] = t
+	typesByType[t.typ] = t
+}
+
+// TypeOf 事件类型名称，未注册的事件返回空字符串
+func TypeOf(event any) string {
+	return typesByType[reflect.TypeOf(event)].name
+}
+
+// IsPublic 是否是可以被外部系统订阅的事件类型
+func IsPublic(name string) bool {
+	t, ok := typesByName[name]
+	return ok && !t.private
+}
+
+// NewEnvelope 封装领域事件，没有ID的事件会生成ID和发生时间
+func NewEnvelope(event any) (*Envelope, error) {
+	t, ok := typesByType[reflect.TypeOf(event)]
+	if !ok {
+		return nil, fmt.Errorf("unregistered event type %T", event)
+	}
+
+	// 事件是值类型，复制一份之后才能修改元数据
+	v := reflect.New(t.typ)
+	v.Elem().Set(reflect.ValueOf(event))
+
+	m := v.Interface().(interface{ meta() *Meta }).meta()
+	if m.ID == uuid.Nil {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, fmt.Errorf("create event id, %w", err)
+		}
+		m.ID = id
+	}
+	if m.OccurredAt.IsZero() {
+		m.OccurredAt = time.Now()
+	}
+	if m.Version == 0 {
+		m.Version = t.version
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("encode event, %w", err)
+	}
+
+	return &Envelope{
+		ID:         m.ID,
+		Type:       t.name,
+		Version:    m.Version,
+		OccurredAt: m.OccurredAt,
+		Data:       data,
+	}, nil
+}
+
+// Event 还原领域事件，返回的是事件值
+func (e *Envelope) Event() (any, error) {
+	t, ok := typesByName[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", e.Type)
+	}
+
+	v := reflect.New(t.typ)
+	if err := json.Unmarshal(e.Data, v.Interface()); err != nil {
+		return nil, fmt.Errorf("decode event, %w", err)
+	}
+
+	m := v.Interface().(interface{ meta() *Meta }).meta()
+	m.ID = e.ID
+	m.OccurredAt = e.OccurredAt
+	m.Version = e.Version
+	return v.Elem().Interface(), nil
+}
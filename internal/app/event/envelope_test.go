@@ -0,0 +1,95 @@
+package event
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEnvelope(t *testing.T) {
+	ev := PasswordChanged{
+		AccountID: uuid.New(),
+		Email:     "test@example.com",
+		Reason:    PasswordChangedByUser,
+	}
+
+	envelope, err := NewEnvelope(ev)
+	if err != nil {
+		t.Fatal(err)
+	} else if envelope.ID == uuid.Nil || envelope.OccurredAt.IsZero() {
+		t.Fatal("event meta should be generated")
+	} else if envelope.Type != "account.password_changed" || envelope.Version != 1 {
+		t.Fatalf("unexpected event type %q, version %d", envelope.Type, envelope.Version)
+	}
+
+	// 元数据只在envelope内出现
+	var data map[string]any
+	if err := json.Unmarshal(envelope.Data, &data); err != nil {
+		t.Fatal(err)
+	} else if len(data) != 3 {
+		t.Fatalf("unexpected event data %s", envelope.Data)
+	}
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Envelope{}
+	if err := json.Unmarshal(payload, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := decoded.Event()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := v.(PasswordChanged)
+	if !ok {
+		t.Fatalf("unexpected decoded type %T", v)
+	} else if got.ID != envelope.ID || got.Version != 1 {
+		t.Fatal("event meta should be kept")
+	} else if got.AccountID != ev.AccountID || got.Email != ev.Email || got.Reason != ev.Reason {
+		t.Fatal("event data should be kept")
+	}
+
+	// 已经有ID的事件重新封装时ID不变
+	if again, err := NewEnvelope(got); err != nil {
+		t.Fatal(err)
+	} else if again.ID != envelope.ID {
+		t.Fatal("event id should not be regenerated")
+	}
+
+	if _, err := NewEnvelope(struct{ Meta }{}); err == nil {
+		t.Fatal("unregistered event should not be enveloped")
+	}
+}
+
+// 事件会保存在outbox中并发送给webhook，不能包含凭证，凭证在发送邮件时生成
+func TestEventWithoutToken(t *testing.T) {
+	for typ, et := range typesByType {
+		for i := range typ.NumField() {
+			f := typ.Field(i)
+			if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); f.Name == "Token" || name == "token" {
+				t.Errorf("event %s should not contain token", et.name)
+			}
+		}
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	if !IsPublic("account.password_changed") {
+		t.Fatal("password changed should be public")
+	} else if !IsPublic("account.email_changed") {
+		t.Fatal("email changed should be public")
+	} else if IsPublic("account.password_reset_requested") {
+		t.Fatal("password reset request should be private")
+	} else if IsPublic("account.email_revert_requested") {
+		t.Fatal("email revert request should be private")
+	} else if IsPublic("account.not_exist") {
+		t.Fatal("unknown event should not be public")
+	}
+}
@@ -2,41 +2,149 @@ package event
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
 	"ddd-example/pkg/events"
 	"ddd-example/pkg/logger"
-	"reflect"
 
+	"github.com/google/uuid"
 	"github.com/reactivex/rxgo/v2"
 )
 
+// observeTimeout 等待观察者处理一个事件的最长时间，超时的事件由outbox稍后重新投递
+const observeTimeout = 10 * time.Second
+
 var (
 	// stream 事件流
 	stream = events.NewStream()
-	// Stream 事件观察对象
+	// Stream 事件观察对象，事件流里的元素是*Delivery，观察者通过Observe处理
 	Stream = stream.Observable(rxgo.WithErrorStrategy(rxgo.ContinueOnError))
+
+	observersMu sync.RWMutex
+	// observers 事件流上的观察者名称
+	observers []string
 )
 
-// publish 发布领域事件
-func publish(event any) {
-	logger.Debug(context.Background(), "deliver domain event",
-		"type", reflect.TypeOf(event).Name(),
-		"data", event,
+// Delivery 投递到事件流的领域事件，观察者处理完之后报告处理结果
+type Delivery struct {
+	Event any
+
+	// handled 之前已经处理成功的观察者
+	handled []string
+	results chan observeResult
+}
+
+type observeResult struct {
+	observer string
+	err      error
+}
+
+// Observe 登记事件流观察者，返回用于rxgo.Observable.ForEach的处理函数
+//
+// name会随outbox消息保存，用于重新投递时跳过已经处理成功的观察者，登记之后不要修改。
+// handle返回错误或者panic时，outbox会把事件重新投递给这个观察者
+func Observe(name string, handle func(event any) error) rxgo.NextFunc {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+
+	if slices.Contains(observers, name) {
+		panic(fmt.Sprintf("event: duplicate observer %q", name))
+	}
+	observers = append(observers, name)
+
+	return func(item any) {
+		if d, ok := item.(*Delivery); ok {
+			d.observe(name, handle)
+		}
+	}
+}
+
+func (d *Delivery) observe(name string, handle func(event any) error) {
+	if slices.Contains(d.handled, name) {
+		d.results <- observeResult{observer: name}
+		return
+	}
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic, %v", r)
+			}
+		}()
+		err = handle(d.Event)
+	}()
+	d.results <- observeResult{observer: name, err: err}
+}
+
+// Dispatch 把领域事件投递到事件流，等待handled之外的观察者处理完成，
+// 返回包括handled在内所有处理成功的观察者
+//
+// 业务代码不直接调用，领域事件先写入outbox，再由outbox投递到事件流。
+// 所有观察者都处理成功之后才返回nil，否则outbox稍后重新投递
+func Dispatch(ctx context.Context, event any, handled []string) ([]string, error) {
+	// 事件数据可能包含email等个人信息，日志只记录类型和ID
+	logger.Debug(ctx, "deliver domain event",
+		"type", TypeOf(event),
+		"id", idOf(event),
 	)
 
-	if err := stream.Publish(event); err != nil {
-		logger.Error(context.Background(), "deliver domain event",
-			"type", reflect.TypeOf(event).Name(),
-			"data", event,
-			"error", err,
-		)
+	return deliver(ctx, stream.Publish, event, handled)
+}
+
+func deliver(ctx context.Context, publish func(any) error, event any, handled []string) ([]string, error) {
+	observersMu.RLock()
+	n := len(observers)
+	observersMu.RUnlock()
+
+	d := &Delivery{
+		Event:   event,
+		handled: handled,
+		// 观察者报告结果时不能被阻塞，等待超时之后的结果直接丢弃
+		results: make(chan observeResult, n),
 	}
+	if err := publish(d); err != nil {
+		return handled, fmt.Errorf("publish to stream, %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, observeTimeout)
+	defer cancel()
+
+	var (
+		done = slices.Clone(handled)
+		errs []error
+	)
+	for range n {
+		select {
+		case <-ctx.Done():
+			return done, errors.Join(append(errs, fmt.Errorf("wait observers, %w", ctx.Err()))...)
+		case r := <-d.results:
+			if r.err != nil {
+				errs = append(errs, fmt.Errorf("%s, %w", r.observer, r.err))
+			} else if !slices.Contains(done, r.observer) {
+				done = append(done, r.observer)
+			}
+		}
+	}
+	return done, errors.Join(errs...)
 }
 
-// Publish 批量发布领域事件
-func Publish(events ...any) {
-	for _, event := range events {
-		publish(event)
+// idOf 事件ID，没有嵌入Meta的值返回uuid.Nil
+func idOf(event any) uuid.UUID {
+	v := reflect.ValueOf(event)
+	if v.Kind() != reflect.Struct {
+		return uuid.Nil
+	}
+
+	if m, ok := v.FieldByName("Meta").Interface().(Meta); ok {
+		return m.ID
 	}
+	return uuid.Nil
 }
 
 // CloseStream 关闭事件流
@@ -0,0 +1,73 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/reactivex/rxgo/v2"
+)
+
+func TestDeliver(t *testing.T) {
+	ctx := context.Background()
+
+	var received []string
+	handlers := []rxgo.NextFunc{
+		Observe("a", func(any) error {
+			received = append(received, "a")
+			return nil
+		}),
+		Observe("b", func(any) error {
+			received = append(received, "b")
+			return errors.New("failed")
+		}),
+		Observe("c", func(any) error {
+			panic("boom")
+		}),
+	}
+	publish := func(item any) error {
+		for _, handle := range handlers {
+			handle(item)
+		}
+		return nil
+	}
+
+	// 一个观察者失败不影响其它观察者
+	handled, err := deliver(ctx, publish, "foo", nil)
+	if err == nil {
+		t.Fatal("expected error")
+	} else if !slices.Equal(handled, []string{"a"}) {
+		t.Fatalf("unexpected handled %v", handled)
+	} else if !slices.Equal(received, []string{"a", "b"}) {
+		t.Fatalf("unexpected received %v", received)
+	}
+
+	// 重新投递时跳过已经处理成功的观察者
+	received = nil
+	if _, err := deliver(ctx, publish, "foo", []string{"a", "c"}); err == nil {
+		t.Fatal("expected error")
+	} else if !slices.Equal(received, []string{"b"}) {
+		t.Fatalf("unexpected received %v", received)
+	}
+
+	if handled, err := deliver(ctx, publish, "foo", []string{"b", "c"}); err != nil {
+		t.Fatal(err)
+	} else if !slices.Equal(handled, []string{"b", "c", "a"}) {
+		t.Fatalf("unexpected handled %v", handled)
+	}
+
+	// 观察者没有处理时等待超时，由outbox重新投递
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := deliver(ctx, func(any) error { return nil }, "foo", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate observer should panic")
+		}
+	}()
+	Observe("a", func(any) error { return nil })
+}
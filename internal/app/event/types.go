@@ -1,13 +1,289 @@
 package event
 
-import "ddd-example/internal/domain"
+import (
+	"time"
 
-// Login 账号登录
-type Login struct {
-	Account *domain.Account
+	"github.com/google/uuid"
+)
+
+func init() {
+	register("account.registered", 1, Register{})
+	register("account.login", 1, Login{})
+	register("account.logout", 1, Logout{})
+	registerPrivate("account.magic_link_requested", 2, MagicLinkRequested{})
+	register("account.login_locked", 1, LoginLocked{})
+	registerPrivate("account.verification_requested", 2, VerificationRequested{})
+	register("account.email_verified", 1, EmailVerified{})
+	registerPrivate("account.email_change_requested", 2, EmailChangeRequested{})
+	register("account.email_changed", 2, EmailChanged{})
+	registerPrivate("account.email_revert_requested", 1, EmailRevertRequested{})
+	register("account.email_change_reverted", 1, EmailChangeReverted{})
+	registerPrivate("account.password_reset_requested", 2, PasswordResetRequested{})
+	register("account.password_changed", 1, PasswordChanged{})
+	register("account.session_revoked", 1, SessionRevoked{})
+	register("account.sessions_suspended", 1, SessionsSuspended{})
+	register("account.oauth_bound", 1, OauthBound{})
+	register("account.oauth_unbound", 1, OauthUnbound{})
+	register("account.mfa_enabled", 1, MFAEnabled{})
+	register("account.mfa_disabled", 1, MFADisabled{})
+	register("account.disabled", 1, AccountDisabled{})
+	register("account.enabled", 1, AccountEnabled{})
+	register("account.locked", 1, AccountLocked{})
+	register("account.unlocked", 1, AccountUnlocked{})
+	register("account.deletion_requested", 1, AccountDeletionRequested{})
+	register("account.deletion_cancelled", 1, AccountDeletionCancelled{})
+	register("account.deleted", 1, AccountDeleted{})
+	register("account.data_export_requested", 1, DataExportRequested{})
+	registerPrivate("account.data_export_ready", 2, DataExportReady{})
+	register("account.role_assigned", 1, RoleAssigned{})
+	register("account.role_revoked", 1, RoleRevoked{})
 }
 
+// 登录方式
+const (
+	LoginByPassword = "password"
+	LoginByOauth    = "oauth"
+	LoginByMFA      = "mfa"
+	// 邮件中的免密码登录链接
+	LoginByMagicLink = "magic_link"
+)
+
+// 登录锁定的范围
+const (
+	LoginLockedByAccount = "account"
+	LoginLockedByIP      = "ip"
+)
+
+// 修改密码的原因
+const (
+	PasswordChangedByUser  = "change"
+	PasswordChangedByReset = "reset"
+)
+
 // Register 账号注册
 type Register struct {
-	Account *domain.Account
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+	// 使用三方账号注册时的三方站点
+	Vendor string `json:"vendor,omitempty"`
+}
+
+// Login 账号登录
+type Login struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Method    string    `json:"method"`
+	Vendor    string    `json:"vendor,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Device    string    `json:"device,omitempty"`
+}
+
+// Logout 退出登录，没有会话记录的旧凭证退出时SessionID为空
+type Logout struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// MagicLinkRequested 申请免密码登录，发送邮件时生成登录凭证
+type MagicLinkRequested struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+}
+
+// LoginLocked 登录失败次数过多被暂时锁定
+//
+// 按IP锁定时，AccountID和Email是触发锁定的那次尝试使用的账号，账号不存在时AccountID为空
+type LoginLocked struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+	IP        string    `json:"ip,omitempty"`
+	Scope     string    `json:"scope"`
+	Until     time.Time `json:"until"`
+}
+
+// VerificationRequested 需要验证email，发送邮件时生成验证凭证
+type VerificationRequested struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+}
+
+// EmailVerified email验证通过
+type EmailVerified struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+}
+
+// EmailChangeRequested 申请修改email，确认凭证发送到新email，同时通知旧email
+type EmailChangeRequested struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+	NewEmail  string    `json:"new_email"`
+}
+
+// EmailChanged 修改了email
+type EmailChanged struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	OldEmail  string    `json:"old_email"`
+	NewEmail  string    `json:"new_email"`
+}
+
+// EmailRevertRequested 修改email之后通知旧email，发送邮件时生成撤销凭证
+type EmailRevertRequested struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	OldEmail  string    `json:"old_email"`
+	NewEmail  string    `json:"new_email"`
+}
+
+// EmailChangeReverted 旧email的所有者撤销了email修改，Email为恢复的email
+type EmailChangeReverted struct {
+	Meta
+	AccountID     uuid.UUID `json:"account_id"`
+	Email         string    `json:"email"`
+	RevertedEmail string    `json:"reverted_email"`
+}
+
+// PasswordResetRequested 申请重置密码，发送邮件时生成重置凭证
+type PasswordResetRequested struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+}
+
+// PasswordChanged 密码已修改
+type PasswordChanged struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason"`
+}
+
+// SessionRevoked 撤销了指定会话
+type SessionRevoked struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// SessionsSuspended 账号的会话全部失效，Except为保留的会话
+type SessionsSuspended struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Except    uuid.UUID `json:"except"`
+}
+
+// OauthBound 绑定了三方账号
+type OauthBound struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Vendor    string    `json:"vendor"`
+	VendorUID string    `json:"vendor_uid"`
+}
+
+// OauthUnbound 解绑了三方站点的所有账号
+type OauthUnbound struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Vendor    string    `json:"vendor"`
+}
+
+// MFAEnabled 开启了两步验证
+type MFAEnabled struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+}
+
+// MFADisabled 关闭了两步验证
+type MFADisabled struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+}
+
+// AccountDisabled 账号被停用，OperatorID为操作的管理员
+type AccountDisabled struct {
+	Meta
+	AccountID  uuid.UUID `json:"account_id"`
+	Reason     string    `json:"reason,omitempty"`
+	OperatorID uuid.UUID `json:"operator_id"`
+}
+
+// AccountEnabled 停用的账号被恢复
+type AccountEnabled struct {
+	Meta
+	AccountID  uuid.UUID `json:"account_id"`
+	OperatorID uuid.UUID `json:"operator_id"`
+}
+
+// AccountLocked 账号因安全原因被锁定
+type AccountLocked struct {
+	Meta
+	AccountID  uuid.UUID `json:"account_id"`
+	Reason     string    `json:"reason,omitempty"`
+	OperatorID uuid.UUID `json:"operator_id"`
+}
+
+// AccountUnlocked 锁定的账号被解锁
+type AccountUnlocked struct {
+	Meta
+	AccountID  uuid.UUID `json:"account_id"`
+	OperatorID uuid.UUID `json:"operator_id"`
+}
+
+// AccountDeletionRequested 账号申请注销，PurgeAt之前重新登录可以撤销
+type AccountDeletionRequested struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// AccountDeletionCancelled 宽限期内重新登录，撤销了注销申请
+type AccountDeletionCancelled struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+}
+
+// AccountDeleted 注销宽限期结束，账号数据已清除，下游系统需要删除这个账号的相关数据
+type AccountDeleted struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+}
+
+// DataExportRequested 申请导出账号数据
+type DataExportRequested struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	ExportID  uuid.UUID `json:"export_id"`
+}
+
+// DataExportReady 账号数据导出完成，发送邮件时生成下载凭证，ExpireAt之后失效
+type DataExportReady struct {
+	Meta
+	AccountID uuid.UUID `json:"account_id"`
+	Email     string    `json:"email"`
+	ExportID  uuid.UUID `json:"export_id"`
+	ExpireAt  time.Time `json:"expire_at"`
+}
+
+// RoleAssigned 账号被分配了角色，OperatorID为操作的管理员，初始化超级管理员时为空
+type RoleAssigned struct {
+	Meta
+	AccountID  uuid.UUID `json:"account_id"`
+	Role       string    `json:"role"`
+	OperatorID uuid.UUID `json:"operator_id"`
+}
+
+// RoleRevoked 账号的角色被撤销
+type RoleRevoked struct {
+	Meta
+	AccountID  uuid.UUID `json:"account_id"`
+	Role       string    `json:"role"`
+	OperatorID uuid.UUID `json:"operator_id"`
 }
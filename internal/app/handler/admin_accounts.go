@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// SearchAccounts 查询账号，参数
+type SearchAccounts struct {
+	// Email 包含匹配
+	Email  string `json:"email"`
+	Status string `json:"status" validate:"omitempty,oneof=active disabled locked pending_deletion deleted"`
+	// 注册时间范围，RFC3339格式，包含created_from，不包含created_to
+	CreatedFrom time.Time `json:"created_from"`
+	CreatedTo   time.Time `json:"created_to"`
+	Page        int       `json:"page" validate:"omitempty,min=1"`
+	PageSize    int       `json:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// SearchAccountsResult 查询账号，结果
+type SearchAccountsResult struct {
+	Items    []*domain.Account `json:"items"`
+	Total    int               `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// SearchAccountsHandler 按条件分页查询账号
+type SearchAccountsHandler struct {
+	Accounts adapter.AccountQuery `do:""`
+}
+
+// Handle 执行，最新注册的在前
+func (h *SearchAccountsHandler) Handle(ctx context.Context, args SearchAccounts) (SearchAccountsResult, error) {
+	result := SearchAccountsResult{
+		Items:    []*domain.Account{},
+		Page:     max(args.Page, 1),
+		PageSize: args.PageSize,
+	}
+	if result.PageSize == 0 {
+		result.PageSize = 20
+	}
+
+	accounts, total, err := h.Accounts.Search(ctx, adapter.AccountFilter{
+		Email:       args.Email,
+		Status:      args.Status,
+		CreatedFrom: args.CreatedFrom,
+		CreatedTo:   args.CreatedTo,
+		Offset:      (result.Page - 1) * result.PageSize,
+		Limit:       result.PageSize,
+	})
+	if err != nil {
+		return result, fmt.Errorf("search accounts, %w", err)
+	}
+
+	result.Total = total
+	if len(accounts) > 0 {
+		result.Items = accounts
+	}
+	return result, nil
+}
+
+// AccountDetail 账号详情
+type AccountDetail struct {
+	Account *domain.Account         `json:"account"`
+	Oauth   []*domain.OauthIdentity `json:"oauth"`
+}
+
+// GetAccountHandler 查看账号详情，包括绑定的三方账号
+type GetAccountHandler struct {
+	Accounts adapter.AccountRepository `do:""`
+	Oauth    adapter.OauthRepository   `do:""`
+}
+
+// Handle 执行
+func (h *GetAccountHandler) Handle(ctx context.Context, accountID uuid.UUID) (*AccountDetail, error) {
+	account, err := h.Accounts.Find(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := h.Oauth.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list oauth identities, %w", err)
+	}
+
+	return &AccountDetail{
+		Account: account,
+		Oauth:   identities,
+	}, nil
+}
+
+// ManageAccount 管理员操作账号，参数
+type ManageAccount struct {
+	// 执行操作的管理员
+	Operator  *domain.Account `json:"-"`
+	AccountID uuid.UUID       `json:"-"`
+	// 停用或锁定的原因
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// find 查找被操作的账号
+//
+// 不能操作自己的账号，只有超级管理员可以操作其它超级管理员
+func (args ManageAccount) find(ctx context.Context, accounts adapter.AccountRepository) (*domain.Account, error) {
+	if args.AccountID == args.Operator.ID {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	account, err := accounts.Find(ctx, args.AccountID)
+	if err != nil {
+		return nil, err
+	} else if account.HasRole(domain.RoleSuperAdmin) && !args.Operator.HasRole(domain.RoleSuperAdmin) {
+		return nil, domain.ErrPermissionDenied
+	}
+	return account, nil
+}
+
+// DisableAccountHandler 停用账号
+type DisableAccountHandler struct {
+	DB       *sqlx.DB                     `do:""`
+	Accounts adapter.AccountRepository    `do:""`
+	Session  *service.SessionTokenService `do:""`
+}
+
+// Handle 执行，停用之后账号的所有会话都会失效
+func (h *DisableAccountHandler) Handle(ctx context.Context, args ManageAccount) (*domain.Account, error) {
+	account, err := args.find(ctx, h.Accounts)
+	if err != nil {
+		return nil, err
+	} else if err := account.Disable(args.Reason, args.Operator.ID); err != nil {
+		return nil, err
+	}
+
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		// Suspend会同时保存账号状态
+		if err := h.Session.WithDB(db).Suspend(ctx, account); err != nil {
+			return fmt.Errorf("suspend sessions, %w", err)
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.AccountDisabled{
+			AccountID:  account.ID,
+			Reason:     args.Reason,
+			OperatorID: args.Operator.ID,
+		}); err != nil {
+			return fmt.Errorf("publish account disabled event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// EnableAccountHandler 恢复停用的账号
+type EnableAccountHandler struct {
+	DB       *sqlx.DB                  `do:""`
+	Accounts adapter.AccountRepository `do:""`
+}
+
+// Handle 执行
+func (h *EnableAccountHandler) Handle(ctx context.Context, args ManageAccount) (*domain.Account, error) {
+	account, err := args.find(ctx, h.Accounts)
+	if err != nil {
+		return nil, err
+	} else if err := account.Enable(args.Operator.ID); err != nil {
+		return nil, err
+	}
+
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := infra.NewAccountRepository(db).Update(ctx, account); err != nil {
+			return fmt.Errorf("save account, %w", err)
+		} else if err := service.NewOutboxService(db).Publish(ctx, event.AccountEnabled{
+			AccountID:  account.ID,
+			OperatorID: args.Operator.ID,
+		}); err != nil {
+			return fmt.Errorf("publish account enabled event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// LockAccountHandler 因安全原因锁定账号
+type LockAccountHandler struct {
+	DB       *sqlx.DB                     `do:""`
+	Accounts adapter.AccountRepository    `do:""`
+	Session  *service.SessionTokenService `do:""`
+}
+
+// Handle 执行，锁定之后账号的所有会话都会失效
+func (h *LockAccountHandler) Handle(ctx context.Context, args ManageAccount) (*domain.Account, error) {
+	account, err := args.find(ctx, h.Accounts)
+	if err != nil {
+		return nil, err
+	} else if err := account.Lock(args.Reason, args.Operator.ID); err != nil {
+		return nil, err
+	}
+
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		// Suspend会同时保存账号状态
+		if err := h.Session.WithDB(db).Suspend(ctx, account); err != nil {
+			return fmt.Errorf("suspend sessions, %w", err)
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.AccountLocked{
+			AccountID:  account.ID,
+			Reason:     args.Reason,
+			OperatorID: args.Operator.ID,
+		}); err != nil {
+			return fmt.Errorf("publish account locked event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// UnlockAccountHandler 解锁账号
+type UnlockAccountHandler struct {
+	DB       *sqlx.DB                  `do:""`
+	Accounts adapter.AccountRepository `do:""`
+}
+
+// Handle 执行
+func (h *UnlockAccountHandler) Handle(ctx context.Context, args ManageAccount) (*domain.Account, error) {
+	account, err := args.find(ctx, h.Accounts)
+	if err != nil {
+		return nil, err
+	} else if err := account.Unlock(args.Operator.ID); err != nil {
+		return nil, err
+	}
+
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := infra.NewAccountRepository(db).Update(ctx, account); err != nil {
+			return fmt.Errorf("save account, %w", err)
+		} else if err := service.NewOutboxService(db).Publish(ctx, event.AccountUnlocked{
+			AccountID:  account.ID,
+			OperatorID: args.Operator.ID,
+		}); err != nil {
+			return fmt.Errorf("publish account unlocked event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// SuspendAccountSessionsHandler 强制账号退出所有会话
+type SuspendAccountSessionsHandler struct {
+	DB       *sqlx.DB                     `do:""`
+	Accounts adapter.AccountRepository    `do:""`
+	Session  *service.SessionTokenService `do:""`
+}
+
+// Handle 执行
+func (h *SuspendAccountSessionsHandler) Handle(ctx context.Context, args ManageAccount) error {
+	account, err := args.find(ctx, h.Accounts)
+	if err != nil {
+		return err
+	}
+
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := h.Session.WithDB(db).Suspend(ctx, account); err != nil {
+			return fmt.Errorf("suspend sessions, %w", err)
+		} else if err := service.NewOutboxService(db).Publish(ctx, event.SessionsSuspended{
+			AccountID: account.ID,
+		}); err != nil {
+			return fmt.Errorf("publish sessions suspended event, %w", err)
+		}
+		return nil
+	})
+}
+
+// SendPasswordResetHandler 管理员给账号发送重置密码邮件
+type SendPasswordResetHandler struct {
+	Accounts adapter.AccountRepository `do:""`
+	Events   *service.OutboxService    `do:""`
+}
+
+// Handle 执行，不修改现有密码，账号通过邮件自行重置
+func (h *SendPasswordResetHandler) Handle(ctx context.Context, args ManageAccount) error {
+	account, err := args.find(ctx, h.Accounts)
+	if err != nil {
+		return err
+	}
+	return requestPasswordReset(ctx, h.Events, account)
+}
@@ -6,6 +6,8 @@ import (
 
 	"ddd-example/internal/app/internal/service"
 	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
 )
 
 // AuthorizeHandler 验证访问者信息
@@ -14,18 +16,36 @@ type AuthorizeHandler struct {
 }
 
 // Handle 执行
-func (h *AuthorizeHandler) Handle(ctx context.Context, payload string) (account *domain.Account, newPayload string, err error) {
-	account, token, err := h.Session.Retrieve(ctx, payload)
+//
+// sessionID为当前会话ID，没有会话记录的旧凭证会被换成包含会话记录的新凭证
+//
+// 不是正常状态的账号返回对应的错误，见Account.CheckActive
+func (h *AuthorizeHandler) Handle(ctx context.Context, payload string, client domain.ClientInfo) (
+	account *domain.Account,
+	sessionID uuid.UUID,
+	newPayload string,
+	err error,
+) {
+	account, token, err := h.Session.Retrieve(ctx, payload, client)
 	if err != nil {
 		err = fmt.Errorf("retrieve session token, %w", err)
 		return
 	} else if token.IsExpired() {
 		err = domain.ErrSessionTokenExpired
 		return
+	} else if err = account.CheckActive(); err != nil {
+		return
 	}
 
-	if token.NeedRenew() {
-		newPayload, err = h.Session.Renew(account)
+	sessionID = token.SessionID
+	if sessionID == uuid.Nil {
+		newPayload, err = h.Session.Upgrade(ctx, account, client)
+		if err != nil {
+			err = fmt.Errorf("upgrade session token, %w", err)
+			return
+		}
+	} else if token.NeedRenew() {
+		newPayload, err = h.Session.Renew(account, sessionID)
 		if err != nil {
 			err = fmt.Errorf("renew session token, %w", err)
 			return
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// ChangeEmail 申请修改email，参数
+type ChangeEmail struct {
+	Account  *domain.Account `json:"-"`
+	NewEmail string          `json:"new_email" validate:"email"`
+	Password string          `json:"password" validate:"required"`
+
+	ClientInfo domain.ClientInfo `json:"-"`
+}
+
+// ChangeEmailHandler 申请修改email
+type ChangeEmailHandler struct {
+	EmailChange *service.EmailChangeService `do:""`
+	Events      *service.OutboxService      `do:""`
+	Guard       *service.LoginGuardService  `do:""`
+}
+
+// Handle 执行，新email通过确认凭证确认之后才会生效
+func (h *ChangeEmailHandler) Handle(ctx context.Context, args ChangeEmail) error {
+	account := args.Account
+	if err := verifyPassword(ctx, h.Guard, account, args.Password, args.ClientInfo.IP); err != nil {
+		return err
+	}
+
+	// 确认凭证在发送邮件时生成
+	newEmail := domain.NormalizeEmail(args.NewEmail)
+	if err := h.EmailChange.CheckAvailable(ctx, account, newEmail); err != nil {
+		return err
+	}
+
+	if err := h.Events.Publish(ctx, event.EmailChangeRequested{
+		AccountID: account.ID,
+		Email:     account.Email,
+		NewEmail:  newEmail,
+	}); err != nil {
+		return fmt.Errorf("publish email change requested event, %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange 确认修改email，参数
+type ConfirmEmailChange struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ConfirmEmailChangeHandler 确认修改email
+type ConfirmEmailChangeHandler struct {
+	DB          *sqlx.DB                    `do:""`
+	EmailChange *service.EmailChangeService `do:""`
+}
+
+// Handle 执行，账号的所有会话失效，需要使用新email重新登录
+//
+// 新email和领域事件在同一个事务内保存
+func (h *ConfirmEmailChangeHandler) Handle(ctx context.Context, args ConfirmEmailChange) (account *domain.Account, err error) {
+	err = entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		var oldEmail string
+		account, oldEmail, err = h.EmailChange.WithDB(db).Confirm(ctx, args.Token)
+		if err != nil {
+			return fmt.Errorf("confirm email change, %w", err)
+		}
+
+		// 撤销凭证在发送邮件时生成
+		if err := service.NewOutboxService(db).Publish(ctx,
+			event.EmailChanged{
+				AccountID: account.ID,
+				OldEmail:  oldEmail,
+				NewEmail:  account.Email,
+			},
+			event.EmailRevertRequested{
+				AccountID: account.ID,
+				OldEmail:  oldEmail,
+				NewEmail:  account.Email,
+			},
+		); err != nil {
+			return fmt.Errorf("publish email changed event, %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// RevertEmailChange 撤销修改email，参数
+type RevertEmailChange struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RevertEmailChangeHandler 旧email的所有者撤销修改email
+type RevertEmailChangeHandler struct {
+	DB          *sqlx.DB                    `do:""`
+	EmailChange *service.EmailChangeService `do:""`
+}
+
+// Handle 执行，改回旧email，账号的所有会话失效
+func (h *RevertEmailChangeHandler) Handle(ctx context.Context, args RevertEmailChange) (account *domain.Account, err error) {
+	err = entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		var reverted string
+		account, reverted, err = h.EmailChange.WithDB(db).Revert(ctx, args.Token)
+		if err != nil {
+			return fmt.Errorf("revert email change, %w", err)
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.EmailChangeReverted{
+			AccountID:     account.ID,
+			Email:         account.Email,
+			RevertedEmail: reverted,
+		}); err != nil {
+			return fmt.Errorf("publish email change reverted event, %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"fmt"
 
-	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
 	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
 )
 
 // ChangePassword 替换密码，参数
@@ -17,18 +22,27 @@ type ChangePassword struct {
 
 // ChangePasswordHandler 替换密码
 type ChangePasswordHandler struct {
-	Accounts adapter.AccountRepository `do:""`
+	DB *sqlx.DB `do:""`
 }
 
-// Handle 执行替换密码
+// Handle 执行替换密码，新密码和领域事件在同一个事务内保存
 func (h *ChangePasswordHandler) Handle(ctx context.Context, args ChangePassword) error {
 	account := args.Account
 	if !account.ComparePassword(args.OldPassword) {
 		return fmt.Errorf("compare old password, %w", domain.ErrWrongPassword)
 	} else if err := account.SetPassword(args.NewPassword); err != nil {
 		return fmt.Errorf("set new password, %w", err)
-	} else if err := h.Accounts.Update(ctx, account); err != nil {
-		return fmt.Errorf("save account, %w", err)
 	}
-	return nil
+
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := infra.NewAccountRepository(db).Update(ctx, account); err != nil {
+			return fmt.Errorf("save account, %w", err)
+		}
+
+		return service.NewOutboxService(db).Publish(ctx, event.PasswordChanged{
+			AccountID: account.ID,
+			Email:     account.Email,
+			Reason:    event.PasswordChangedByUser,
+		})
+	})
 }
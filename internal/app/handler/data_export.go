@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// RequestDataExportHandler 申请导出个人数据
+type RequestDataExportHandler struct {
+	DB      *sqlx.DB                     `do:""`
+	Exports adapter.DataExportRepository `do:""`
+}
+
+// Handle 执行，导出文件由后台任务生成，完成后通过邮件发送下载链接
+func (h *RequestDataExportHandler) Handle(ctx context.Context, account *domain.Account) (*domain.DataExport, error) {
+	// 同时只能有一个正在生成的导出
+	if latest, err := h.Exports.ListByAccount(ctx, account.ID, 1); err != nil {
+		return nil, fmt.Errorf("list data exports, %w", err)
+	} else if len(latest) > 0 && latest[0].Status == domain.DataExportPending {
+		return nil, domain.ErrDataExportInProgress
+	}
+
+	export, err := domain.NewDataExport(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := infra.NewDataExportRepository(db).Create(ctx, export); err != nil {
+			return fmt.Errorf("save data export, %w", err)
+		} else if err := service.NewOutboxService(db).Publish(ctx, event.DataExportRequested{
+			AccountID: account.ID,
+			ExportID:  export.ID,
+		}); err != nil {
+			return fmt.Errorf("publish data export requested event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// BuildDataExportsHandler 生成到期的导出文件，由后台任务定时执行
+type BuildDataExportsHandler struct {
+	Exports *service.DataExportService `do:""`
+}
+
+// Handle 执行，返回处理的数量
+func (h *BuildDataExportsHandler) Handle(ctx context.Context) (int, error) {
+	return h.Exports.Build(ctx)
+}
+
+// DownloadDataExportHandler 使用下载链接中的凭证下载导出文件
+type DownloadDataExportHandler struct {
+	Exports *service.DataExportService `do:""`
+}
+
+// Handle 执行，调用方负责关闭返回的文件
+func (h *DownloadDataExportHandler) Handle(ctx context.Context, token string) (*domain.DataExport, io.ReadSeekCloser, error) {
+	return h.Exports.Open(ctx, token)
+}
+
+// 每次清除的导出数量
+const purgeDataExportsBatch = 100
+
+// PurgeDataExportsHandler 删除过期的导出文件，由后台任务定时执行
+type PurgeDataExportsHandler struct {
+	Exports *service.DataExportService `do:""`
+}
+
+// Handle 执行一批，返回删除的数量
+func (h *PurgeDataExportsHandler) Handle(ctx context.Context) (int, error) {
+	return h.Exports.Purge(ctx, time.Now(), purgeDataExportsBatch)
+}
+
+// RecordAccountEventHandler 保存账号相关的领域事件，用于登录历史和个人数据导出
+type RecordAccountEventHandler struct {
+	Events adapter.AccountEventRepository `do:""`
+}
+
+// Handle 执行，没有账号ID的事件直接忽略
+func (h *RecordAccountEventHandler) Handle(ctx context.Context, ev any) error {
+	envelope, err := event.NewEnvelope(ev)
+	if err != nil {
+		return err
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(envelope.Data, &data); err != nil {
+		return fmt.Errorf("decode event data, %w", err)
+	}
+
+	var accountID uuid.UUID
+	if v, ok := data["account_id"]; !ok {
+		return nil
+	} else if err := json.Unmarshal(v, &accountID); err != nil {
+		return fmt.Errorf("decode account id, %w", err)
+	} else if accountID == uuid.Nil {
+		return nil
+	}
+
+	// 凭证可以直接使用，不能保存在记录里
+	delete(data, "token")
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode event data, %w", err)
+	}
+
+	return h.Events.Add(ctx, &adapter.AccountEvent{
+		ID:         envelope.ID,
+		AccountID:  accountID,
+		Type:       envelope.Type,
+		Data:       payload,
+		OccurredAt: envelope.OccurredAt,
+	})
+}
@@ -0,0 +1,50 @@
+//go:build dbtest
+
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"ddd-example/internal/migrate"
+	"ddd-example/pkg/database"
+
+	"github.com/jmoiron/sqlx"
+
+	// database driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestDB 每个测试使用独立的sqlite数据库
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("%s?_fk=1", filepath.Join(t.TempDir(), "main.db"))
+	if err := migrate.Up("sqlite3", dsn); err != nil {
+		t.Fatalf("migrate test db, %v", err)
+	}
+
+	db, err := database.NewDB(database.Option{
+		Driver: "sqlite3",
+		DSN:    dsn,
+		// sqlite只允许一个写事务
+		MaxOpenConns: 1,
+	})
+	if err != nil {
+		t.Fatalf("connect test db, %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// countOutbox 统计outbox中指定类型的消息数量
+func countOutbox(t *testing.T, db *sqlx.DB, typ string) int {
+	t.Helper()
+
+	var n int
+	if err := db.Get(&n, "SELECT COUNT(*) FROM outbox WHERE type = ?", typ); err != nil {
+		t.Fatalf("count outbox, %v", err)
+	}
+	return n
+}
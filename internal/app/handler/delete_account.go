@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// DeleteAccount 申请注销账号，参数
+type DeleteAccount struct {
+	Account   *domain.Account `json:"-"`
+	SessionID uuid.UUID       `json:"-"`
+	// 提供密码时验证密码，否则当前会话需要是刚刚登录的，例如三方账号重新登录
+	Password string `json:"password"`
+	Reason   string `json:"reason" validate:"max=255"`
+
+	ClientInfo domain.ClientInfo `json:"-"`
+}
+
+// DeleteAccountHandler 申请注销账号
+type DeleteAccountHandler struct {
+	DB       *sqlx.DB                     `do:""`
+	Guard    *service.LoginGuardService   `do:""`
+	Roles    adapter.RoleRepository       `do:""`
+	Session  *service.SessionTokenService `do:""`
+	Sessions adapter.SessionRepository    `do:""`
+}
+
+// Handle 执行，返回账号数据被清除的时间
+//
+// 账号的所有会话立即失效，宽限期内重新登录可以撤销注销申请
+func (h *DeleteAccountHandler) Handle(ctx context.Context, args DeleteAccount) (time.Time, error) {
+	account := args.Account
+	if err := h.reauthenticate(ctx, args); err != nil {
+		return time.Time{}, err
+	}
+
+	// 和撤销角色一样，不能注销最后一个超级管理员
+	if account.HasRole(domain.RoleSuperAdmin) {
+		if n, err := h.Roles.CountAccounts(ctx, domain.RoleSuperAdmin); err != nil {
+			return time.Time{}, fmt.Errorf("count super admins, %w", err)
+		} else if n <= 1 {
+			return time.Time{}, domain.ErrLastSuperAdmin
+		}
+	}
+
+	if err := account.RequestDeletion(args.Reason, account.ID); err != nil {
+		return time.Time{}, err
+	}
+
+	purgeAt := account.StatusChange.At.Add(domain.AccountDeletionGrace)
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		// Suspend会同时保存账号状态
+		if err := h.Session.WithDB(db).Suspend(ctx, account); err != nil {
+			return fmt.Errorf("suspend sessions, %w", err)
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.AccountDeletionRequested{
+			AccountID: account.ID,
+			PurgeAt:   purgeAt,
+		}); err != nil {
+			return fmt.Errorf("publish account deletion requested event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return time.Time{}, err
+	}
+	return purgeAt, nil
+}
+
+func (h *DeleteAccountHandler) reauthenticate(ctx context.Context, args DeleteAccount) error {
+	if args.Password != "" {
+		return verifyPassword(ctx, h.Guard, args.Account, args.Password, args.ClientInfo.IP)
+	}
+
+	// 没有会话记录的旧凭证无法判断登录时间
+	if args.SessionID == uuid.Nil {
+		return domain.ErrReauthRequired
+	}
+
+	session, err := h.Sessions.Find(ctx, args.SessionID)
+	if err != nil {
+		return fmt.Errorf("find session, %w", err)
+	} else if session.AccountID != args.Account.ID || !session.Reauthenticated(time.Now()) {
+		return domain.ErrReauthRequired
+	}
+	return nil
+}
+
+// 每次清除的账号数量
+const purgeAccountsBatch = 100
+
+// PurgeAccountsHandler 清除注销的账号，由后台任务定时执行
+//
+// 清除分两个阶段：注销宽限期结束后，把账号标记为已注销，删除个人数据、三方账号绑定、会话、事件记录和数据导出文件，
+// 只保留email防止立即被重新注册；email保留期结束后，删除账号记录
+type PurgeAccountsHandler struct {
+	DB            *sqlx.DB                       `do:""`
+	Accounts      adapter.AccountRepository      `do:""`
+	AccountEvents adapter.AccountEventRepository `do:""`
+	Query         adapter.AccountQuery           `do:""`
+	Storage       adapter.FileStorage            `do:""`
+}
+
+// Handle 执行一批，返回处理的账号数量
+func (h *PurgeAccountsHandler) Handle(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	due, err := h.Query.ListStatusChanged(ctx, domain.AccountPendingDeletion, now.Add(-domain.AccountDeletionGrace), purgeAccountsBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list accounts pending deletion, %w", err)
+	}
+	for _, account := range due {
+		if err := h.erase(ctx, account.ID, now); err != nil {
+			return 0, fmt.Errorf("erase account %s, %w", account.ID, err)
+		}
+	}
+
+	released, err := h.Query.ListStatusChanged(ctx, domain.AccountDeleted, now.Add(-domain.DeletedEmailRetention), purgeAccountsBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list deleted accounts, %w", err)
+	}
+	for _, account := range released {
+		// 标记注销之后发生的事件也会被记录下来
+		if err := h.AccountEvents.DeleteByAccount(ctx, account.ID); err != nil {
+			return 0, fmt.Errorf("delete account events %s, %w", account.ID, err)
+		} else if err := h.Accounts.Delete(ctx, account.ID); err != nil {
+			return 0, fmt.Errorf("delete account %s, %w", account.ID, err)
+		}
+	}
+
+	return len(due) + len(released), nil
+}
+
+// erase 注销账号并删除相关数据，账号状态、关联数据和领域事件在同一个事务内保存
+func (h *PurgeAccountsHandler) erase(ctx context.Context, accountID uuid.UUID, now time.Time) error {
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		accounts := infra.NewAccountRepository(db)
+
+		// 查询之后可能已经重新登录撤销了注销申请
+		account, err := accounts.Find(ctx, accountID)
+		if err != nil {
+			return err
+		} else if !account.DeletionDue(now) {
+			return nil
+		}
+
+		if err := account.MarkDeleted("deletion grace period expired", uuid.Nil); err != nil {
+			return err
+		} else if err := account.RefreshSessionSalt(); err != nil {
+			return fmt.Errorf("refresh session salt, %w", err)
+		} else if err := accounts.Update(ctx, account); err != nil {
+			return fmt.Errorf("save account, %w", err)
+		}
+
+		if err := infra.NewOauthRepository(db).UnbindAll(ctx, account.ID); err != nil {
+			return fmt.Errorf("unbind oauth, %w", err)
+		} else if err := infra.NewSessionRepository(db).DeleteByAccount(ctx, account.ID); err != nil {
+			return fmt.Errorf("delete sessions, %w", err)
+		} else if err := infra.NewAccountEventRepository(db).DeleteByAccount(ctx, account.ID); err != nil {
+			return fmt.Errorf("delete account events, %w", err)
+		} else if err := h.deleteExports(ctx, infra.NewDataExportRepository(db), account.ID); err != nil {
+			return fmt.Errorf("delete data exports, %w", err)
+		}
+
+		return service.NewOutboxService(db).Publish(ctx, event.AccountDeleted{
+			AccountID: account.ID,
+		})
+	})
+}
+
+// deleteExports 删除账号的数据导出文件和记录
+//
+// 文件删除不能回滚，先删除文件再在事务内删除记录，事务失败时下次清除重新执行，删除不存在的文件不会出错
+func (h *PurgeAccountsHandler) deleteExports(ctx context.Context, exports adapter.DataExportRepository, accountID uuid.UUID) error {
+	for {
+		list, err := exports.ListByAccount(ctx, accountID, purgeAccountsBatch)
+		if err != nil {
+			return fmt.Errorf("list data exports, %w", err)
+		} else if len(list) == 0 {
+			return nil
+		}
+
+		for _, export := range list {
+			if export.File != "" {
+				if err := h.Storage.Delete(ctx, export.File); err != nil {
+					return fmt.Errorf("delete archive, %s, %w", export.ID, err)
+				}
+			}
+
+			if err := exports.Delete(ctx, export.ID); err != nil {
+				return fmt.Errorf("delete data export, %s, %w", export.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+//go:build dbtest
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+	"ddd-example/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+func TestPurgeAccountsHandler(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	accounts := infra.NewAccountRepository(db)
+	exports := infra.NewDataExportRepository(db)
+
+	files, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := service.NewAccountService(db).Create(ctx, "test@example.com", "password", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if err := account.RequestDeletion("", account.ID); err != nil {
+		t.Fatal(err)
+	}
+	account.StatusChange.At = time.Now().Add(-domain.AccountDeletionGrace - time.Hour)
+	if err := accounts.Update(ctx, account); err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := domain.NewDataExport(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := files.Put(ctx, export.File, strings.NewReader("archive")); err != nil {
+		t.Fatal(err)
+	}
+	export.Complete(7, time.Now())
+	if err := exports.Create(ctx, export); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &PurgeAccountsHandler{
+		DB:            db,
+		Accounts:      accounts,
+		AccountEvents: infra.NewAccountEventRepository(db),
+		Query:         infra.NewAccountQuery(db),
+		Storage:       files,
+	}
+	if n, err := h.Handle(ctx); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("expected 1 account, got %d", n)
+	}
+
+	if saved, err := accounts.Find(ctx, account.ID); err != nil {
+		t.Fatal(err)
+	} else if saved.Status != domain.AccountDeleted {
+		t.Fatalf("unexpected status %s", saved.Status)
+	}
+
+	// 导出文件和记录随账号一起删除
+	if _, err := exports.Find(ctx, export.ID); !errors.Is(err, domain.ErrDataExportNotFound) {
+		t.Fatalf("expected %v, got %v", domain.ErrDataExportNotFound, err)
+	} else if _, err := files.Open(ctx, export.File); err == nil {
+		t.Fatal("archive should be deleted")
+	}
+
+	// 只删除被清除账号的导出
+	other, err := domain.NewDataExport(uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	} else if err := exports.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	} else if _, err := h.Handle(ctx); err != nil {
+		t.Fatal(err)
+	} else if _, err := exports.Find(ctx, other.ID); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,41 +2,111 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"ddd-example/internal/app/event"
 	"ddd-example/internal/app/internal/service"
 	"ddd-example/internal/domain"
+	"ddd-example/pkg/logger"
 )
 
 // LoginWithEmail 使用Email登录，参数
 type LoginWithEmail struct {
 	Email    string `json:"email" validate:"email"`
 	Password string `json:"password" validate:"required"`
+
+	ClientInfo domain.ClientInfo `json:"-"`
+}
+
+// LoginWithEmailResult 使用Email登录，结果
+type LoginWithEmailResult struct {
+	Account *domain.Account
+	// 会话凭证
+	SessionToken string
+	// 账号开启了两步验证时，不下发会话凭证，
+	// 而是下发挑战凭证，使用挑战凭证和验证码换取会话凭证
+	MFAChallenge string
 }
 
 // LoginWithEmailHandler 使用Email登录
 type LoginWithEmailHandler struct {
-	Session  *service.SessionTokenService `do:""`
-	Accounts *service.AccountService      `do:""`
+	Session    *service.SessionTokenService `do:""`
+	Accounts   *service.AccountService      `do:""`
+	Challenges *service.MFAChallengeService `do:""`
+	Events     *service.OutboxService       `do:""`
+	Guard      *service.LoginGuardService   `do:""`
 }
 
 // Handle 执行
-func (h *LoginWithEmailHandler) Handle(ctx context.Context, args LoginWithEmail) (account *domain.Account, token string, err error) {
-	account, err = h.Accounts.Authorize(ctx, args.Email, args.Password)
+func (h *LoginWithEmailHandler) Handle(ctx context.Context, args LoginWithEmail) (result LoginWithEmailResult, err error) {
+	if err = h.Guard.Check(ctx, args.Email, args.ClientInfo.IP); err != nil {
+		return
+	}
+
+	account, err := h.Accounts.Authorize(ctx, args.Email, args.Password)
 	if err != nil {
+		recordLoginFailure(ctx, h.Guard, err, args.Email, args.ClientInfo.IP)
 		err = fmt.Errorf("account authorize, %w", err)
 		return
 	}
+	result.Account = account
 
-	token, err = h.Session.Generate(ctx, account)
+	// 开启了两步验证的账号，失败记录在两步验证通过之后才清除
+	if account.MFAEnabled() {
+		result.MFAChallenge, err = h.Challenges.New(ctx, account)
+		if err != nil {
+			err = fmt.Errorf("new mfa challenge, %w", err)
+		}
+		return
+	}
+
+	result.SessionToken, err = h.Session.Generate(ctx, account, args.ClientInfo)
 	if err != nil {
 		err = fmt.Errorf("generate session token, %w", err)
 		return
 	}
 
-	event.Publish(event.Login{
-		Account: account,
-	})
+	if err := h.Guard.Reset(ctx, account.Email); err != nil {
+		logger.Error(ctx, "reset login attempts", "account", account.ID, "error", err)
+	}
+
+	if err := h.Events.Publish(ctx, event.Login{
+		AccountID: account.ID,
+		Method:    event.LoginByPassword,
+		IP:        args.ClientInfo.IP,
+		Device:    args.ClientInfo.Device,
+	}); err != nil {
+		logger.Error(ctx, "publish login event", "account", account.ID, "error", err)
+	}
 	return
 }
+
+// verifyPassword 已登录的账号再次提交密码确认身份，和登录一样计入失败次数，失败次数过多时锁定
+func verifyPassword(ctx context.Context, guard *service.LoginGuardService, account *domain.Account, password, ip string) error {
+	if err := guard.Check(ctx, account.Email, ip); err != nil {
+		return err
+	} else if !account.ComparePassword(password) {
+		err := fmt.Errorf("compare password, %w", domain.ErrWrongPassword)
+		recordLoginFailure(ctx, guard, err, account.Email, ip)
+		return err
+	}
+
+	if err := guard.Reset(ctx, account.Email); err != nil {
+		logger.Error(ctx, "reset login attempts", "account", account.ID, "error", err)
+	}
+	return nil
+}
+
+// recordLoginFailure 密码或者两步验证码验证失败时计入登录失败次数，记录失败不影响本次请求的结果
+func recordLoginFailure(ctx context.Context, guard *service.LoginGuardService, err error, email, ip string) {
+	if !errors.Is(err, domain.ErrAccountNotFound) &&
+		!errors.Is(err, domain.ErrWrongPassword) &&
+		!errors.Is(err, domain.ErrWrongMFACode) {
+		return
+	}
+
+	if err := guard.Fail(ctx, email, ip); err != nil {
+		logger.Error(ctx, "record login failure", "email", email, "ip", ip, "error", err)
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/pkg/logger"
+	"ddd-example/pkg/oauth"
+)
+
+// LoginWithOauth 跳转到三方登录，参数
+type LoginWithOauth struct {
+	Client      oauth.Client `json:"-"`
+	RedirectURI string       `json:"redirect_uri" validate:"http_url"`
+}
+
+// LoginWithOauthHandler 构造三方登录跳转地址
+type LoginWithOauthHandler struct {
+	States *service.OauthStateService `do:""`
+}
+
+// Handle 执行，返回跳转地址及state
+//
+// state需要由调用方绑定到访问者，回调验证时核对
+func (h *LoginWithOauthHandler) Handle(ctx context.Context, args LoginWithOauth) (nextURL *url.URL, state string, err error) {
+	if err := verifyRedirectURI(ctx, args.Client, args.RedirectURI); err != nil {
+		return nil, "", err
+	}
+
+	params, err := oauth.NewAuthParams()
+	if err != nil {
+		return nil, "", fmt.Errorf("new auth params, %w", err)
+	}
+
+	nextURL, err = args.Client.AuthorizeURL(args.RedirectURI, params)
+	if err != nil {
+		return nil, "", fmt.Errorf("build authorize url, %w", err)
+	} else if err := h.States.Save(ctx, args.Client.Vendor(), params); err != nil {
+		return nil, "", fmt.Errorf("save auth params, %w", err)
+	}
+	return nextURL, params.State, nil
+}
+
+// verifyRedirectURI 检查重定向地址白名单，拒绝时记录原因
+func verifyRedirectURI(ctx context.Context, client oauth.Client, redirectURI string) error {
+	if err := client.VerifyRedirectURI(redirectURI); err != nil {
+		logger.Warn(ctx, "reject oauth redirect uri",
+			"vendor", client.Vendor(),
+			"redirect_uri", redirectURI,
+			"reason", err,
+		)
+		return err
+	}
+	return nil
+}
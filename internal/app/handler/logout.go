@@ -3,16 +3,37 @@ package handler
 import (
 	"context"
 
+	"ddd-example/internal/app/event"
 	"ddd-example/internal/app/internal/service"
 	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
 )
 
 // LogoutHandler 退出登录
 type LogoutHandler struct {
+	DB      *sqlx.DB                     `do:""`
 	Session *service.SessionTokenService `do:""`
 }
 
-// Handle 执行
-func (h *LogoutHandler) Handle(ctx context.Context, account *domain.Account) error {
-	return h.Session.Suspend(ctx, account)
+// Handle 执行，只撤销当前会话，不影响其它设备
+func (h *LogoutHandler) Handle(ctx context.Context, account *domain.Account, sessionID uuid.UUID) error {
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		session := h.Session.WithDB(db)
+		if sessionID == uuid.Nil {
+			// 没有会话记录的旧凭证，只能使所有会话失效
+			if err := session.Suspend(ctx, account); err != nil {
+				return err
+			}
+		} else if err := session.Revoke(ctx, account, sessionID); err != nil {
+			return err
+		}
+
+		return service.NewOutboxService(db).Publish(ctx, event.Logout{
+			AccountID: account.ID,
+			SessionID: sessionID,
+		})
+	})
 }
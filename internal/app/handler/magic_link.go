@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+	"ddd-example/pkg/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// magicLinkResponseTime 申请免密码登录的最短响应时间，
+// 处理时间不足时补齐，email是否存在的响应时间相同
+const magicLinkResponseTime = 500 * time.Millisecond
+
+// RequestMagicLink 申请免密码登录，参数
+type RequestMagicLink struct {
+	Email string `json:"email" validate:"email"`
+}
+
+// RequestMagicLinkHandler 申请免密码登录
+type RequestMagicLinkHandler struct {
+	Accounts adapter.AccountRepository `do:""`
+	Events   *service.OutboxService    `do:""`
+}
+
+// Handle 执行
+//
+// email是否存在都返回同样的结果，并且等待到同样的最短响应时间，
+// 避免通过这个接口的结果或者响应时间探测email是否已注册，邮件由outbox在后台发送
+func (h *RequestMagicLinkHandler) Handle(ctx context.Context, args RequestMagicLink) error {
+	timer := time.NewTimer(magicLinkResponseTime)
+	defer timer.Stop()
+
+	err := h.request(ctx, domain.NormalizeEmail(args.Email))
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	return err
+}
+
+func (h *RequestMagicLinkHandler) request(ctx context.Context, email string) error {
+	account, err := h.Accounts.FindByEmail(ctx, email)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		logger.Debug(ctx, "request magic link, account not found")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("find account by email, %w", err)
+	} else if err := account.CheckLogin(time.Now()); err != nil {
+		logger.Debug(ctx, "request magic link, account can not login", "account", account.ID, "error", err)
+		return nil
+	}
+
+	// 登录凭证在发送邮件时生成
+	if err := h.Events.Publish(ctx, event.MagicLinkRequested{
+		AccountID: account.ID,
+		Email:     account.Email,
+	}); err != nil {
+		return fmt.Errorf("publish magic link requested event, %w", err)
+	}
+	return nil
+}
+
+// VerifyMagicLink 使用邮件中的凭证登录，参数
+type VerifyMagicLink struct {
+	Token string `json:"token" validate:"required"`
+
+	ClientInfo domain.ClientInfo `json:"-"`
+}
+
+// VerifyMagicLinkHandler 使用邮件中的凭证登录
+type VerifyMagicLinkHandler struct {
+	DB         *sqlx.DB                     `do:""`
+	Challenges *service.MFAChallengeService `do:""`
+	Events     *service.OutboxService       `do:""`
+	MagicLink  *service.MagicLinkService    `do:""`
+	Session    *service.SessionTokenService `do:""`
+}
+
+// Handle 执行，凭证只能使用一次
+//
+// 开启了两步验证的账号和密码登录一样，下发挑战凭证而不是会话凭证
+func (h *VerifyMagicLinkHandler) Handle(ctx context.Context, args VerifyMagicLink) (result LoginWithEmailResult, err error) {
+	account, err := h.MagicLink.Consume(ctx, args.Token)
+	if err != nil {
+		err = fmt.Errorf("consume magic link, %w", err)
+		return
+	} else if err = account.CheckLogin(time.Now()); err != nil {
+		return
+	}
+	result.Account = account
+
+	// 能收到邮件说明email属于本人
+	if !account.EmailVerified {
+		if err = h.verifyEmail(ctx, account); err != nil {
+			return
+		}
+	}
+
+	if account.MFAEnabled() {
+		result.MFAChallenge, err = h.Challenges.New(ctx, account)
+		if err != nil {
+			err = fmt.Errorf("new mfa challenge, %w", err)
+		}
+		return
+	}
+
+	result.SessionToken, err = h.Session.Generate(ctx, account, args.ClientInfo)
+	if err != nil {
+		err = fmt.Errorf("generate session token, %w", err)
+		return
+	}
+
+	if err := h.Events.Publish(ctx, event.Login{
+		AccountID: account.ID,
+		Method:    event.LoginByMagicLink,
+		IP:        args.ClientInfo.IP,
+		Device:    args.ClientInfo.Device,
+	}); err != nil {
+		logger.Error(ctx, "publish login event", "account", account.ID, "error", err)
+	}
+	return
+}
+
+func (h *VerifyMagicLinkHandler) verifyEmail(ctx context.Context, account *domain.Account) error {
+	account.VerifyEmail()
+
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := infra.NewAccountRepository(db).Update(ctx, account); err != nil {
+			return fmt.Errorf("save account, %w", err)
+		} else if err := service.NewOutboxService(db).Publish(ctx, event.EmailVerified{
+			AccountID: account.ID,
+			Email:     account.Email,
+		}); err != nil {
+			return fmt.Errorf("publish email verified event, %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+	"ddd-example/pkg/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// EnrollTOTP 生成TOTP密钥，参数
+type EnrollTOTP struct {
+	Account *domain.Account
+	// 验证器应用中显示的服务名称
+	Issuer string
+}
+
+// EnrollTOTPHandler 生成TOTP密钥，需要确认之后才会开启两步验证
+type EnrollTOTPHandler struct {
+	Accounts adapter.AccountRepository `do:""`
+}
+
+// Handle 执行，返回密钥以及provisioning uri
+func (h *EnrollTOTPHandler) Handle(ctx context.Context, args EnrollTOTP) (secret, uri string, err error) {
+	account := args.Account
+
+	secret, uri, err = account.EnrollTOTP(args.Issuer)
+	if err != nil {
+		return "", "", fmt.Errorf("enroll totp, %w", err)
+	} else if err := h.Accounts.Update(ctx, account); err != nil {
+		return "", "", fmt.Errorf("save account, %w", err)
+	}
+	return
+}
+
+// ConfirmTOTP 确认开启两步验证，参数
+type ConfirmTOTP struct {
+	Account *domain.Account `json:"-"`
+	Code    string          `json:"code" validate:"required"`
+}
+
+// ConfirmTOTPHandler 确认开启两步验证
+type ConfirmTOTPHandler struct {
+	DB *sqlx.DB `do:""`
+}
+
+// Handle 执行，返回明文恢复码
+func (h *ConfirmTOTPHandler) Handle(ctx context.Context, args ConfirmTOTP) ([]string, error) {
+	account := args.Account
+
+	recoveryCodes, err := account.ConfirmTOTP(args.Code)
+	if err != nil {
+		return nil, fmt.Errorf("confirm totp, %w", err)
+	}
+
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := infra.NewAccountRepository(db).Update(ctx, account); err != nil {
+			return fmt.Errorf("save account, %w", err)
+		} else if err := service.NewOutboxService(db).Publish(ctx, event.MFAEnabled{
+			AccountID: account.ID,
+		}); err != nil {
+			return fmt.Errorf("publish mfa enabled event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// DisableTOTP 关闭两步验证，参数
+type DisableTOTP struct {
+	Account *domain.Account `json:"-"`
+	// TOTP验证码或者恢复码
+	Code string `json:"code" validate:"required"`
+
+	ClientInfo domain.ClientInfo `json:"-"`
+}
+
+// DisableTOTPHandler 关闭两步验证
+type DisableTOTPHandler struct {
+	DB    *sqlx.DB                   `do:""`
+	Guard *service.LoginGuardService `do:""`
+}
+
+// Handle 执行，需要验证码确认，验证码错误和登录一样计入失败次数
+func (h *DisableTOTPHandler) Handle(ctx context.Context, args DisableTOTP) error {
+	account := args.Account
+
+	if err := h.Guard.Check(ctx, account.Email, args.ClientInfo.IP); err != nil {
+		return err
+	} else if err := account.VerifyMFA(args.Code); err != nil {
+		recordLoginFailure(ctx, h.Guard, err, account.Email, args.ClientInfo.IP)
+		return fmt.Errorf("verify mfa code, %w", err)
+	} else if err := account.DisableTOTP(); err != nil {
+		return fmt.Errorf("disable totp, %w", err)
+	}
+
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := infra.NewAccountRepository(db).Update(ctx, account); err != nil {
+			return fmt.Errorf("save account, %w", err)
+		}
+
+		return service.NewOutboxService(db).Publish(ctx, event.MFADisabled{
+			AccountID: account.ID,
+		})
+	})
+}
+
+// VerifyMFA 使用挑战凭证和验证码完成登录，参数
+type VerifyMFA struct {
+	Challenge string `json:"challenge" validate:"required"`
+	// TOTP验证码或者恢复码
+	Code string `json:"code" validate:"required"`
+
+	ClientInfo domain.ClientInfo `json:"-"`
+}
+
+// VerifyMFAHandler 两步验证登录
+//
+// 验证码和恢复码错误与密码错误一样按账号和IP计入登录失败次数，避免每次重新登录拿到新的挑战凭证之后无限尝试
+type VerifyMFAHandler struct {
+	Accounts   adapter.AccountRepository    `do:""`
+	Challenges *service.MFAChallengeService `do:""`
+	Events     *service.OutboxService       `do:""`
+	Guard      *service.LoginGuardService   `do:""`
+	Session    *service.SessionTokenService `do:""`
+}
+
+// Handle 执行，验证通过后返回会话凭证
+func (h *VerifyMFAHandler) Handle(ctx context.Context, args VerifyMFA) (account *domain.Account, token string, err error) {
+	// 先取出挑战凭证，并发请求不能同时使用同一个凭证
+	challenge, err := h.Challenges.Take(ctx, args.Challenge)
+	if err != nil {
+		return nil, "", fmt.Errorf("take mfa challenge, %w", err)
+	}
+
+	account, err = h.Accounts.Find(ctx, challenge.AccountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return nil, "", domain.ErrInvalidToken
+	} else if err != nil {
+		return nil, "", fmt.Errorf("find account, %w", err)
+	}
+
+	if err := h.Guard.Check(ctx, account.Email, args.ClientInfo.IP); err != nil {
+		// 等待期间的请求不计入尝试次数，挑战凭证放回
+		if err := h.Challenges.Restore(ctx, args.Challenge, challenge); err != nil {
+			logger.Error(ctx, "restore mfa challenge", "account", account.ID, "error", err)
+		}
+		return nil, "", err
+	}
+
+	if err := account.VerifyMFA(args.Code); err != nil {
+		if errors.Is(err, domain.ErrWrongMFACode) {
+			recordLoginFailure(ctx, h.Guard, err, account.Email, args.ClientInfo.IP)
+			if err := h.Challenges.Fail(ctx, args.Challenge, challenge); err != nil {
+				return nil, "", fmt.Errorf("record mfa failure, %w", err)
+			}
+		}
+		return nil, "", fmt.Errorf("verify mfa code, %w", err)
+	}
+
+	// 保存验证码周期或者已使用的恢复码
+	if err := h.Accounts.Update(ctx, account); err != nil {
+		return nil, "", fmt.Errorf("save account, %w", err)
+	}
+
+	token, err = h.Session.Generate(ctx, account, args.ClientInfo)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate session token, %w", err)
+	}
+
+	if err := h.Guard.Reset(ctx, account.Email); err != nil {
+		logger.Error(ctx, "reset login attempts", "account", account.ID, "error", err)
+	}
+
+	if err := h.Events.Publish(ctx, event.Login{
+		AccountID: account.ID,
+		Method:    event.LoginByMFA,
+		IP:        args.ClientInfo.IP,
+		Device:    args.ClientInfo.Device,
+	}); err != nil {
+		logger.Error(ctx, "publish login event", "account", account.ID, "error", err)
+	}
+	return account, token, nil
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// ListOauthHandler 账号绑定的三方账号列表
+type ListOauthHandler struct {
+	Oauth adapter.OauthRepository `do:""`
+}
+
+// Handle 执行
+func (h *ListOauthHandler) Handle(ctx context.Context, account *domain.Account) ([]*domain.OauthIdentity, error) {
+	identities, err := h.Oauth.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list oauth identities, %w", err)
+	}
+	return identities, nil
+}
+
+// UnbindOauth 解绑三方账号，参数
+type UnbindOauth struct {
+	Account *domain.Account
+	Vendor  string
+}
+
+// UnbindOauthHandler 解绑三方账号
+type UnbindOauthHandler struct {
+	DB *sqlx.DB `do:""`
+}
+
+// Handle 执行，解绑指定站点的所有三方账号
+//
+// 没有密码的账号，不能解绑最后一个三方账号，否则账号将无法登录。
+// 在事务内重新读取账号和绑定关系检查，避免并发解绑不同站点时把登录方式全部解除
+func (h *UnbindOauthHandler) Handle(ctx context.Context, args UnbindOauth) error {
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		oauth := infra.NewOauthRepository(db)
+
+		account, err := infra.NewAccountRepository(db).Find(ctx, args.Account.ID)
+		if err != nil {
+			return fmt.Errorf("find account, %w", err)
+		}
+
+		identities, err := oauth.ListByAccount(ctx, account.ID)
+		if err != nil {
+			return fmt.Errorf("list oauth identities, %w", err)
+		}
+
+		var bound, remain int
+		for _, v := range identities {
+			if v.Vendor == args.Vendor {
+				bound++
+			} else {
+				remain++
+			}
+		}
+
+		if bound == 0 {
+			return domain.ErrOauthNotBound
+		} else if remain == 0 && !account.HasPassword() {
+			return domain.ErrLastLoginMethod
+		}
+
+		if err := oauth.Unbind(ctx, account.ID, args.Vendor); err != nil {
+			return fmt.Errorf("unbind oauth, %w", err)
+		}
+
+		return service.NewOutboxService(db).Publish(ctx, event.OauthUnbound{
+			AccountID: account.ID,
+			Vendor:    args.Vendor,
+		})
+	})
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"ddd-example/internal/app/internal/service"
+)
+
+// DispatchOutboxHandler 投递outbox内到期的领域事件
+type DispatchOutboxHandler struct {
+	Events *service.OutboxService `do:""`
+}
+
+// Handle 执行，返回投递成功的数量
+func (h *DispatchOutboxHandler) Handle(ctx context.Context) (int, error) {
+	return h.Events.Dispatch(ctx)
+}
+
+// PurgeOutbox 清理outbox，参数
+type PurgeOutbox struct {
+	// 已投递事件的保留时长
+	DispatchedRetention time.Duration
+	// 投递失败事件的保留时长，保留更久以便排查
+	FailedRetention time.Duration
+}
+
+// PurgeOutboxHandler 清理outbox
+type PurgeOutboxHandler struct {
+	Events *service.OutboxService `do:""`
+}
+
+// Handle 执行，返回清理的数量
+func (h *PurgeOutboxHandler) Handle(ctx context.Context, args PurgeOutbox) (int64, error) {
+	now := time.Now()
+	return h.Events.Purge(ctx, now.Add(-args.DispatchedRetention), now.Add(-args.FailedRetention))
+}
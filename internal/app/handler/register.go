@@ -7,39 +7,64 @@ import (
 	"ddd-example/internal/app/event"
 	"ddd-example/internal/app/internal/service"
 	"ddd-example/internal/domain"
+	"ddd-example/pkg/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
 )
 
 // Register 账号注册，参数
 type Register struct {
 	Email    string `json:"email" validate:"email"`
 	Password string `json:"password" validate:"required"`
+
+	ClientInfo domain.ClientInfo `json:"-"`
 }
 
 // RegisterHandler 账号注册
 type RegisterHandler struct {
-	Session  *service.SessionTokenService `do:""`
-	Accounts *service.AccountService      `do:""`
+	DB      *sqlx.DB                     `do:""`
+	Events  *service.OutboxService       `do:""`
+	Session *service.SessionTokenService `do:""`
 }
 
 // Handle 执行账号注册
 func (h *RegisterHandler) Handle(ctx context.Context, args Register) (account *domain.Account, token string, err error) {
-	account, err = h.Accounts.Create(ctx, args.Email, args.Password)
-	if err != nil {
+	// 账号和注册事件在同一个事务内保存
+	if err = entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		account, err = service.NewAccountService(db).Create(ctx, args.Email, args.Password, args.ClientInfo.Language)
+		if err != nil {
+			return err
+		}
+
+		// 注册后直接登录，但需要通过邮件验证email
+		return service.NewOutboxService(db).Publish(ctx,
+			event.Register{
+				AccountID: account.ID,
+				Email:     account.Email,
+			},
+			event.VerificationRequested{
+				AccountID: account.ID,
+				Email:     account.Email,
+			},
+		)
+	}); err != nil {
 		return
 	}
 
-	event.Publish(event.Register{
-		Account: account,
-	})
-
-	token, err = h.Session.Generate(ctx, account)
+	token, err = h.Session.Generate(ctx, account, args.ClientInfo)
 	if err != nil {
 		err = fmt.Errorf("generate session token, %w", err)
 		return
 	}
 
-	event.Publish(event.Login{
-		Account: account,
-	})
+	if err := h.Events.Publish(ctx, event.Login{
+		AccountID: account.ID,
+		Method:    event.LoginByPassword,
+		IP:        args.ClientInfo.IP,
+		Device:    args.ClientInfo.Device,
+	}); err != nil {
+		logger.Error(ctx, "publish login event", "account", account.ID, "error", err)
+	}
 	return
 }
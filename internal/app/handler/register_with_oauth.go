@@ -9,9 +9,9 @@ import (
 	"ddd-example/internal/app/internal/service"
 	"ddd-example/internal/domain"
 	"ddd-example/internal/infra"
+	"ddd-example/pkg/logger"
 	"ddd-example/pkg/oauth"
 
-	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/joyparty/entity"
 )
@@ -21,44 +21,88 @@ type RegisterWithOauth struct {
 	OauthToken     string `json:"oauth_token" validte:"required"`
 	Email          string `json:"email" validate:"email"`
 	VerifyPassword string `json:"verify_password"` // 绑定账号，需要提供密码
+
+	ClientInfo domain.ClientInfo `json:"-"`
 }
 
 // RegisterWithOauthHandler 三方账号注册
 type RegisterWithOauthHandler struct {
 	DB         *sqlx.DB                     `do:""`
+	Challenges *service.MFAChallengeService `do:""`
+	Events     *service.OutboxService       `do:""`
+	Guard      *service.LoginGuardService   `do:""`
 	Session    *service.SessionTokenService `do:""`
 	OauthToken *service.OauthTokenService   `do:""`
 }
 
 // Handle 三方登录，绑定或注册新账号
-func (h *RegisterWithOauthHandler) Handle(ctx context.Context, args RegisterWithOauth) (account *domain.Account, sessionToken string, err error) {
+//
+// 绑定的已有账号开启了两步验证时，和密码登录一样下发挑战凭证而不是会话凭证
+func (h *RegisterWithOauthHandler) Handle(ctx context.Context, args RegisterWithOauth) (result LoginWithEmailResult, err error) {
 	vendorUser, err := h.OauthToken.Retrieve(ctx, args.OauthToken)
 	if err != nil {
 		err = fmt.Errorf("retrieve vendor account from cache, %w", err)
 		return
 	}
 
-	var events []any
+	// 绑定已有账号需要验证密码，和密码登录使用同样的失败次数限制
+	if args.VerifyPassword != "" {
+		if err = h.Guard.Check(ctx, args.Email, args.ClientInfo.IP); err != nil {
+			return
+		}
+	}
+
+	// 账号、三方账号绑定和领域事件在同一个事务内保存
+	var account *domain.Account
 	if err = entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		var events []any
 		account, events, err = h.handle(
 			ctx, args, vendorUser,
 			service.NewAccountService(db),
 			infra.NewOauthRepository(db),
 		)
-		return err
+		if err != nil {
+			return err
+		}
+		return service.NewOutboxService(db).Publish(ctx, events...)
 	}); err != nil {
+		if args.VerifyPassword != "" {
+			recordLoginFailure(ctx, h.Guard, err, args.Email, args.ClientInfo.IP)
+		}
 		return
 	}
 
-	event.Publish(events...)
+	// 开启了两步验证的账号，失败记录在两步验证通过之后才清除
+	result.Account = account
+	if account.MFAEnabled() {
+		result.MFAChallenge, err = h.Challenges.New(ctx, account)
+		if err != nil {
+			err = fmt.Errorf("new mfa challenge, %w", err)
+		}
+		return
+	}
 
-	sessionToken, err = h.Session.Generate(ctx, account)
+	result.SessionToken, err = h.Session.Generate(ctx, account, args.ClientInfo)
 	if err != nil {
 		err = fmt.Errorf("generate session token, %w", err)
+		return
+	}
+
+	if args.VerifyPassword != "" {
+		if err := h.Guard.Reset(ctx, account.Email); err != nil {
+			logger.Error(ctx, "reset login attempts", "account", account.ID, "error", err)
+		}
+	}
+
+	if err := h.Events.Publish(ctx, event.Login{
+		AccountID: account.ID,
+		Method:    event.LoginByOauth,
+		Vendor:    vendorUser.Vendor,
+		IP:        args.ClientInfo.IP,
+		Device:    args.ClientInfo.Device,
+	}); err != nil {
+		logger.Error(ctx, "publish login event", "account", account.ID, "error", err)
 	}
-	event.Publish(event.Login{
-		Account: account,
-	})
 	return
 }
 
@@ -72,12 +116,21 @@ func (h *RegisterWithOauthHandler) handle(
 	if args.VerifyPassword != "" {
 		account, err = accountService.Authorize(ctx, args.Email, args.VerifyPassword)
 	} else {
-		account, err = accountService.Create(ctx, args.Email, uuid.New().String())
+		account, err = accountService.Create(ctx, args.Email, "", args.ClientInfo.Language)
 
 		if err == nil {
-			events = append(events, event.Register{
-				Account: account,
-			})
+			events = append(events,
+				event.Register{
+					AccountID: account.ID,
+					Email:     account.Email,
+					Vendor:    vendorUser.Vendor,
+				},
+				// 三方账号注册时填写的email同样需要验证
+				event.VerificationRequested{
+					AccountID: account.ID,
+					Email:     account.Email,
+				},
+			)
 		}
 	}
 
@@ -87,6 +140,13 @@ func (h *RegisterWithOauthHandler) handle(
 
 	if err = oauthRepos.Bind(ctx, account.ID, vendorUser.Vendor, vendorUser.ID); err != nil {
 		err = fmt.Errorf("bound vendor user, %w", err)
+		return
 	}
+
+	events = append(events, event.OauthBound{
+		AccountID: account.ID,
+		Vendor:    vendorUser.Vendor,
+		VendorUID: vendorUser.ID,
+	})
 	return
 }
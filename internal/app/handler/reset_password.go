@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// RequestPasswordReset 申请重置密码，参数
+type RequestPasswordReset struct {
+	Email string `json:"email" validate:"email"`
+}
+
+// RequestPasswordResetHandler 申请重置密码
+type RequestPasswordResetHandler struct {
+	Accounts adapter.AccountRepository `do:""`
+	Events   *service.OutboxService    `do:""`
+}
+
+// Handle 执行
+//
+// email不存在时不返回错误，避免通过这个接口探测email是否已注册，已注销的账号同样处理
+func (h *RequestPasswordResetHandler) Handle(ctx context.Context, args RequestPasswordReset) error {
+	account, err := h.Accounts.FindByEmail(ctx, domain.NormalizeEmail(args.Email))
+	if err == nil && errors.Is(account.CheckActive(), domain.ErrAccountDeleted) {
+		err = domain.ErrAccountNotFound
+	}
+
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		logger.Debug(ctx, "request password reset, account not found")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("find account by email, %w", err)
+	}
+
+	return requestPasswordReset(ctx, h.Events, account)
+}
+
+// requestPasswordReset 通过事件发送重置邮件，重置凭证在发送邮件时生成
+func requestPasswordReset(ctx context.Context, events *service.OutboxService, account *domain.Account) error {
+	if err := events.Publish(ctx, event.PasswordResetRequested{
+		AccountID: account.ID,
+		Email:     account.Email,
+	}); err != nil {
+		return fmt.Errorf("publish password reset event, %w", err)
+	}
+	return nil
+}
+
+// ResetPassword 重置密码，参数
+type ResetPassword struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// ResetPasswordHandler 使用邮件凭证重置密码
+type ResetPasswordHandler struct {
+	DB      *sqlx.DB                      `do:""`
+	Reset   *service.PasswordResetService `do:""`
+	Session *service.SessionTokenService  `do:""`
+}
+
+// Handle 执行，重置之后账号的所有会话都会失效
+//
+// 新密码、会话失效和领域事件在同一个事务内保存，凭证在事务最后消费，
+// 保存失败时凭证仍然可以使用，同一个凭证并发使用时只有一个能成功
+func (h *ResetPasswordHandler) Handle(ctx context.Context, args ResetPassword) error {
+	account, err := h.Reset.Retrieve(ctx, args.Token)
+	if err != nil {
+		return fmt.Errorf("retrieve reset token, %w", err)
+	} else if err := account.SetPassword(args.NewPassword); err != nil {
+		return fmt.Errorf("set new password, %w", err)
+	}
+
+	// 能收到邮件说明email属于这个账号
+	account.VerifyEmail()
+
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		// Suspend会同时保存账号
+		if err := h.Session.WithDB(db).Suspend(ctx, account); err != nil {
+			return fmt.Errorf("suspend sessions, %w", err)
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx,
+			event.PasswordChanged{
+				AccountID: account.ID,
+				Email:     account.Email,
+				Reason:    event.PasswordChangedByReset,
+			},
+			event.SessionsSuspended{
+				AccountID: account.ID,
+			},
+		); err != nil {
+			return fmt.Errorf("publish password changed event, %w", err)
+		}
+
+		if err := h.Reset.Consume(ctx, account, args.Token); err != nil {
+			return fmt.Errorf("consume reset token, %w", err)
+		}
+		return nil
+	})
+}
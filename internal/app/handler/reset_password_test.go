@@ -0,0 +1,85 @@
+//go:build dbtest
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+)
+
+// 模拟凭证在读取之后被其它请求抢先使用
+type takenCache struct {
+	adapter.Cacher
+}
+
+func (takenCache) Take(context.Context, string) ([]byte, error) {
+	return nil, domain.ErrMissingCache
+}
+
+func TestResetPasswordHandler(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	accounts := infra.NewAccountRepository(db)
+
+	account, err := service.NewAccountService(db).Create(ctx, "test@example.com", "old password", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reset := &service.PasswordResetService{
+		Cache:    infra.NewMemoryCache(),
+		Accounts: accounts,
+	}
+	h := &ResetPasswordHandler{
+		DB:    db,
+		Reset: reset,
+		Session: &service.SessionTokenService{
+			Accounts: accounts,
+			Sessions: infra.NewSessionRepository(db),
+		},
+	}
+
+	token, err := reset.NewToken(ctx, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 凭证消费失败时，新密码和领域事件都不会保存
+	failed := *h
+	failed.Reset = &service.PasswordResetService{
+		Cache:    takenCache{reset.Cache},
+		Accounts: accounts,
+	}
+	if err := failed.Handle(ctx, ResetPassword{Token: token, NewPassword: "new password"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	} else if saved, err := accounts.Find(ctx, account.ID); err != nil {
+		t.Fatal(err)
+	} else if !saved.ComparePassword("old password") || saved.SessionSalt != account.SessionSalt {
+		t.Fatal("account should not be changed")
+	} else if n := countOutbox(t, db, "account.password_changed"); n != 0 {
+		t.Fatalf("event should not be published, got %d", n)
+	}
+
+	if err := h.Handle(ctx, ResetPassword{Token: token, NewPassword: "new password"}); err != nil {
+		t.Fatal(err)
+	} else if saved, err := accounts.Find(ctx, account.ID); err != nil {
+		t.Fatal(err)
+	} else if !saved.ComparePassword("new password") || !saved.EmailVerified {
+		t.Fatal("password should be reset")
+	} else if saved.SessionSalt == account.SessionSalt {
+		t.Fatal("sessions should be suspended")
+	} else if n := countOutbox(t, db, "account.password_changed"); n != 1 {
+		t.Fatalf("expected 1 event, got %d", n)
+	}
+
+	// 凭证只能使用一次
+	if err := h.Handle(ctx, ResetPassword{Token: token, NewPassword: "another password"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// ResolvePermissionsHandler 查询账号拥有的权限
+type ResolvePermissionsHandler struct {
+	Roles *service.RoleService `do:""`
+}
+
+// Handle 执行
+func (h *ResolvePermissionsHandler) Handle(ctx context.Context, account *domain.Account) (domain.Permissions, error) {
+	return h.Roles.Permissions(ctx, account)
+}
+
+// ListRolesHandler 角色列表
+type ListRolesHandler struct {
+	Roles adapter.RoleRepository `do:""`
+}
+
+// Handle 执行
+func (h *ListRolesHandler) Handle(ctx context.Context) ([]*domain.Role, error) {
+	roles, err := h.Roles.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles, %w", err)
+	}
+	return roles, nil
+}
+
+// ChangeRole 分配或撤销角色，参数
+type ChangeRole struct {
+	// 执行操作的管理员
+	Operator  *domain.Account
+	AccountID uuid.UUID
+	Role      string
+}
+
+// 只有超级管理员可以分配或撤销超级管理员角色
+func (args ChangeRole) check() error {
+	if args.Role == domain.RoleSuperAdmin && !args.Operator.HasRole(domain.RoleSuperAdmin) {
+		return domain.ErrPermissionDenied
+	}
+	return nil
+}
+
+// AssignRoleHandler 给账号分配角色
+type AssignRoleHandler struct {
+	DB       *sqlx.DB                  `do:""`
+	Accounts adapter.AccountRepository `do:""`
+	Roles    *service.RoleService      `do:""`
+}
+
+// Handle 执行，返回分配后的账号
+func (h *AssignRoleHandler) Handle(ctx context.Context, args ChangeRole) (*domain.Account, error) {
+	if err := args.check(); err != nil {
+		return nil, err
+	}
+
+	account, err := h.Accounts.Find(ctx, args.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if ok, err := h.Roles.WithDB(db).Assign(ctx, account, args.Role); err != nil || !ok {
+			return err
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.RoleAssigned{
+			AccountID:  account.ID,
+			Role:       args.Role,
+			OperatorID: args.Operator.ID,
+		}); err != nil {
+			return fmt.Errorf("publish role assigned event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// RevokeRoleHandler 撤销账号的角色
+type RevokeRoleHandler struct {
+	DB       *sqlx.DB                  `do:""`
+	Accounts adapter.AccountRepository `do:""`
+	Roles    *service.RoleService      `do:""`
+}
+
+// Handle 执行，返回撤销后的账号
+func (h *RevokeRoleHandler) Handle(ctx context.Context, args ChangeRole) (*domain.Account, error) {
+	if err := args.check(); err != nil {
+		return nil, err
+	}
+
+	account, err := h.Accounts.Find(ctx, args.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if ok, err := h.Roles.WithDB(db).Revoke(ctx, account, args.Role); err != nil || !ok {
+			return err
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.RoleRevoked{
+			AccountID:  account.ID,
+			Role:       args.Role,
+			OperatorID: args.Operator.ID,
+		}); err != nil {
+			return fmt.Errorf("publish role revoked event, %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// BootstrapAdminHandler 初始化第一个超级管理员
+type BootstrapAdminHandler struct {
+	DB    *sqlx.DB             `do:""`
+	Roles *service.RoleService `do:""`
+}
+
+// Handle 执行，账号需要先注册，已经存在超级管理员时返回domain.ErrSuperAdminExists
+func (h *BootstrapAdminHandler) Handle(ctx context.Context, email string) (account *domain.Account, err error) {
+	err = entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		account, err = h.Roles.WithDB(db).Bootstrap(ctx, email)
+		if err != nil {
+			return err
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.RoleAssigned{
+			AccountID: account.ID,
+			Role:      domain.RoleSuperAdmin,
+		}); err != nil {
+			return fmt.Errorf("publish role assigned event, %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// SendAccountEmailHandler 根据账号事件发送通知邮件
+//
+// 邮件里的凭证在发送时生成，不会保存在事件里
+type SendAccountEmailHandler struct {
+	Accounts     adapter.AccountRepository         `do:""`
+	DataExport   *service.DataExportService        `do:""`
+	EmailChange  *service.EmailChangeService       `do:""`
+	MagicLink    *service.MagicLinkService         `do:""`
+	Mail         *service.MailService              `do:""`
+	Reset        *service.PasswordResetService     `do:""`
+	Verification *service.EmailVerificationService `do:""`
+}
+
+// tokenMailData 带凭证的邮件模板数据
+type tokenMailData struct {
+	Email    string
+	OldEmail string
+	NewEmail string
+	Token    string
+	ExpireAt time.Time
+}
+
+// Handle 执行，不需要发送邮件的事件直接忽略
+func (h *SendAccountEmailHandler) Handle(ctx context.Context, ev any) error {
+	switch ev := ev.(type) {
+	case event.Register:
+		return h.send(ctx, ev.AccountID, ev.Email, "register", ev)
+	case event.VerificationRequested:
+		return h.sendVerification(ctx, ev)
+	case event.EmailChangeRequested:
+		return h.sendEmailChange(ctx, ev)
+	case event.EmailRevertRequested:
+		return h.sendEmailRevert(ctx, ev)
+	case event.MagicLinkRequested:
+		return h.sendMagicLink(ctx, ev)
+	case event.PasswordResetRequested:
+		return h.sendPasswordReset(ctx, ev)
+	case event.PasswordChanged:
+		return h.send(ctx, ev.AccountID, ev.Email, "password_changed", ev)
+	case event.DataExportReady:
+		return h.sendDataExport(ctx, ev)
+	}
+	return nil
+}
+
+func (h *SendAccountEmailHandler) sendVerification(ctx context.Context, ev event.VerificationRequested) error {
+	account, ok, err := h.findAccount(ctx, ev.AccountID)
+	if !ok || err != nil {
+		return err
+	} else if account.EmailVerified || account.Email != ev.Email {
+		// 已经验证过或者email已经修改，凭证不再有用
+		logger.Debug(ctx, "skip verification email", "account", account.ID)
+		return nil
+	}
+
+	token, err := h.Verification.NewToken(account)
+	if err != nil {
+		return fmt.Errorf("new verification token, %w", err)
+	}
+	return h.sendTo(ctx, account, ev.Email, "verify_email", tokenMailData{Email: ev.Email, Token: token})
+}
+
+func (h *SendAccountEmailHandler) sendEmailChange(ctx context.Context, ev event.EmailChangeRequested) error {
+	account, ok, err := h.findAccount(ctx, ev.AccountID)
+	if !ok || err != nil {
+		return err
+	} else if account.Email != ev.Email {
+		logger.Debug(ctx, "skip email change email", "account", account.ID)
+		return nil
+	}
+
+	token, err := h.EmailChange.NewToken(ctx, account, ev.NewEmail)
+	if errors.Is(err, domain.ErrEmailRegistered) {
+		// 申请之后新email被其它账号占用
+		logger.Debug(ctx, "skip email change email", "account", account.ID, "error", err)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("new email change token, %w", err)
+	}
+
+	data := tokenMailData{Email: ev.Email, NewEmail: ev.NewEmail, Token: token}
+	if err := h.sendTo(ctx, account, ev.NewEmail, "change_email", data); err != nil {
+		return err
+	}
+
+	// 通知原email的邮件里不能带凭证
+	data.Token = ""
+	return h.sendTo(ctx, account, ev.Email, "change_email_notice", data)
+}
+
+func (h *SendAccountEmailHandler) sendEmailRevert(ctx context.Context, ev event.EmailRevertRequested) error {
+	account, ok, err := h.findAccount(ctx, ev.AccountID)
+	if !ok || err != nil {
+		return err
+	} else if account.Email != ev.NewEmail {
+		logger.Debug(ctx, "skip email revert email", "account", account.ID)
+		return nil
+	}
+
+	token, expireAt, err := h.EmailChange.NewRevertToken(account, ev.OldEmail)
+	if errors.Is(err, domain.ErrInvalidToken) {
+		// 已经撤销或者再次修改过email
+		logger.Debug(ctx, "skip email revert email", "account", account.ID)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("new email revert token, %w", err)
+	}
+	return h.sendTo(ctx, account, ev.OldEmail, "email_changed", tokenMailData{
+		OldEmail: ev.OldEmail,
+		NewEmail: ev.NewEmail,
+		Token:    token,
+		ExpireAt: expireAt,
+	})
+}
+
+func (h *SendAccountEmailHandler) sendMagicLink(ctx context.Context, ev event.MagicLinkRequested) error {
+	account, ok, err := h.findAccount(ctx, ev.AccountID)
+	if !ok || err != nil {
+		return err
+	} else if account.Email != ev.Email {
+		logger.Debug(ctx, "skip magic link email", "account", account.ID)
+		return nil
+	}
+
+	token, expireAt, err := h.MagicLink.NewToken(ctx, account)
+	if err != nil {
+		return fmt.Errorf("new magic link token, %w", err)
+	}
+	return h.sendTo(ctx, account, ev.Email, "magic_link", tokenMailData{Email: ev.Email, Token: token, ExpireAt: expireAt})
+}
+
+func (h *SendAccountEmailHandler) sendPasswordReset(ctx context.Context, ev event.PasswordResetRequested) error {
+	account, ok, err := h.findAccount(ctx, ev.AccountID)
+	if !ok || err != nil {
+		return err
+	} else if account.Email != ev.Email {
+		logger.Debug(ctx, "skip password reset email", "account", account.ID)
+		return nil
+	}
+
+	token, err := h.Reset.NewToken(ctx, account)
+	if err != nil {
+		return fmt.Errorf("new reset token, %w", err)
+	}
+	return h.sendTo(ctx, account, ev.Email, "password_reset", tokenMailData{Email: ev.Email, Token: token})
+}
+
+func (h *SendAccountEmailHandler) sendDataExport(ctx context.Context, ev event.DataExportReady) error {
+	account, ok, err := h.findAccount(ctx, ev.AccountID)
+	if !ok || err != nil {
+		return err
+	}
+
+	token, err := h.DataExport.NewToken(ctx, ev.ExportID)
+	if errors.Is(err, domain.ErrDataExportNotFound) {
+		// 导出文件已经过期或者被删除
+		logger.Debug(ctx, "skip data export email", "export", ev.ExportID)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("new download token, %w", err)
+	}
+	return h.sendTo(ctx, account, ev.Email, "data_export_ready", tokenMailData{Email: ev.Email, Token: token, ExpireAt: ev.ExpireAt})
+}
+
+// findAccount 查询账号，账号已经删除时返回false，不再发送带凭证的邮件
+func (h *SendAccountEmailHandler) findAccount(ctx context.Context, accountID uuid.UUID) (*domain.Account, bool, error) {
+	account, err := h.Accounts.Find(ctx, accountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		logger.Debug(ctx, "skip email of deleted account", "account", accountID)
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("find account, %w", err)
+	}
+	return account, true, nil
+}
+
+func (h *SendAccountEmailHandler) send(ctx context.Context, accountID uuid.UUID, to, name string, data any) error {
+	// 使用账号的首选语言，账号不存在时使用默认语言
+	var language string
+	if account, err := h.Accounts.Find(ctx, accountID); err == nil {
+		language = account.Language
+	} else if !errors.Is(err, domain.ErrAccountNotFound) {
+		return fmt.Errorf("find account, %w", err)
+	}
+
+	return h.mail(ctx, to, language, name, data)
+}
+
+func (h *SendAccountEmailHandler) sendTo(ctx context.Context, account *domain.Account, to, name string, data any) error {
+	return h.mail(ctx, to, account.Language, name, data)
+}
+
+func (h *SendAccountEmailHandler) mail(ctx context.Context, to, language, name string, data any) error {
+	if err := h.Mail.Send(ctx, to, language, name, data); err != nil {
+		return fmt.Errorf("send %s email, %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// ListSessionsHandler 账号的登录会话列表
+type ListSessionsHandler struct {
+	Sessions adapter.SessionRepository `do:""`
+}
+
+// Handle 执行
+func (h *ListSessionsHandler) Handle(ctx context.Context, account *domain.Account) ([]*domain.Session, error) {
+	sessions, err := h.Sessions.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions, %w", err)
+	}
+	return sessions, nil
+}
+
+// PurgeSessionsHandler 清理过期的会话记录
+type PurgeSessionsHandler struct {
+	Sessions adapter.SessionRepository `do:""`
+}
+
+// Handle 执行，返回清理的数量
+func (h *PurgeSessionsHandler) Handle(ctx context.Context) (int64, error) {
+	return h.Sessions.DeleteExpired(ctx, time.Now())
+}
+
+// RevokeSession 撤销指定会话，参数
+type RevokeSession struct {
+	Account   *domain.Account
+	SessionID uuid.UUID
+}
+
+// RevokeSessionHandler 撤销指定会话
+type RevokeSessionHandler struct {
+	DB      *sqlx.DB                     `do:""`
+	Session *service.SessionTokenService `do:""`
+}
+
+// Handle 执行
+func (h *RevokeSessionHandler) Handle(ctx context.Context, args RevokeSession) error {
+	return entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		if err := h.Session.WithDB(db).Revoke(ctx, args.Account, args.SessionID); err != nil {
+			return err
+		}
+
+		return service.NewOutboxService(db).Publish(ctx, event.SessionRevoked{
+			AccountID: args.Account.ID,
+			SessionID: args.SessionID,
+		})
+	})
+}
+
+// RevokeOtherSessionsHandler 撤销除当前会话之外的所有会话
+type RevokeOtherSessionsHandler struct {
+	DB      *sqlx.DB                     `do:""`
+	Session *service.SessionTokenService `do:""`
+}
+
+// Handle 执行，返回当前会话的新凭证
+func (h *RevokeOtherSessionsHandler) Handle(ctx context.Context, account *domain.Account, current uuid.UUID) (token string, err error) {
+	err = entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		token, err = h.Session.WithDB(db).RevokeOthers(ctx, account, current)
+		if err != nil {
+			return fmt.Errorf("revoke other sessions, %w", err)
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.SessionsSuspended{
+			AccountID: account.ID,
+			Except:    current,
+		}); err != nil {
+			return fmt.Errorf("publish sessions suspended event, %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
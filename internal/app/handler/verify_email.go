@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// VerifyEmail 验证email，参数
+type VerifyEmail struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// VerifyEmailHandler 验证email
+type VerifyEmailHandler struct {
+	DB           *sqlx.DB                          `do:""`
+	Verification *service.EmailVerificationService `do:""`
+}
+
+// Handle 执行，验证结果和领域事件在同一个事务内保存
+func (h *VerifyEmailHandler) Handle(ctx context.Context, args VerifyEmail) (account *domain.Account, err error) {
+	err = entity.TransactionX(ctx, h.DB, func(db entity.DB) error {
+		account, err = h.Verification.WithDB(db).Verify(ctx, args.Token)
+		if err != nil {
+			return fmt.Errorf("verify email, %w", err)
+		}
+
+		if err := service.NewOutboxService(db).Publish(ctx, event.EmailVerified{
+			AccountID: account.ID,
+			Email:     account.Email,
+		}); err != nil {
+			return fmt.Errorf("publish email verified event, %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ResendVerificationHandler 重新发送email验证邮件
+type ResendVerificationHandler struct {
+	Events *service.OutboxService `do:""`
+}
+
+// Handle 执行
+func (h *ResendVerificationHandler) Handle(ctx context.Context, account *domain.Account) error {
+	if account.EmailVerified {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	if err := h.Events.Publish(ctx, event.VerificationRequested{
+		AccountID: account.ID,
+		Email:     account.Email,
+	}); err != nil {
+		return fmt.Errorf("publish verification event, %w", err)
+	}
+	return nil
+}
@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"time"
 
 	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
 	"ddd-example/internal/app/internal/service"
 	"ddd-example/internal/domain"
+	"ddd-example/pkg/logger"
 	"ddd-example/pkg/oauth"
 )
 
@@ -19,6 +22,10 @@ type VerifyOauth struct {
 	// 从三方验证完毕重定向回来时，附带的url query
 	RawQuery string     `json:"query" validate:"required"`
 	Query    url.Values `json:"-"`
+	// 跳转到三方站点时绑定到访问者的state
+	State string `json:"-"`
+
+	ClientInfo domain.ClientInfo `json:"-"`
 }
 
 // VerifyOauthResult 三方登录验证结果
@@ -30,25 +37,47 @@ type VerifyOauthResult struct {
 	SessionToken string
 	// 三方账号的缓存凭证，后续使用这个凭证可以注册新账号或者绑定已有账号
 	OauthToken string
+	// 账号开启了两步验证时，下发挑战凭证代替会话凭证
+	MFAChallenge string
 }
 
 // VerifyOauthHandler 三方登录验证
 type VerifyOauthHandler struct {
 	OauthToken *service.OauthTokenService   `do:""`
+	States     *service.OauthStateService   `do:""`
 	Session    *service.SessionTokenService `do:""`
+	Challenges *service.MFAChallengeService `do:""`
 	Oauth      adapter.OauthRepository      `do:""`
 	Accounts   adapter.AccountRepository    `do:""`
+	Events     *service.OutboxService       `do:""`
 }
 
 // Handle 验证三方登录
 func (h *VerifyOauthHandler) Handle(ctx context.Context, args VerifyOauth) (result VerifyOauthResult, err error) {
+	if err = verifyRedirectURI(ctx, args.Client, args.RedirectURI); err != nil {
+		return
+	}
+
 	code := args.Query.Get("code")
 	if code == "" {
 		err = errors.New("empty oauth code")
 		return
 	}
 
-	vendorUser, err := args.Client.Authorize(code, args.RedirectURI)
+	// 回调的state必须与发起登录的访问者一致，并且只能使用一次
+	state := args.Query.Get("state")
+	if state == "" || state != args.State {
+		err = domain.ErrInvalidOauthState
+		return
+	}
+
+	params, err := h.States.Consume(ctx, args.Client.Vendor(), state)
+	if err != nil {
+		err = fmt.Errorf("consume oauth state, %w", err)
+		return
+	}
+
+	vendorUser, err := args.Client.Authorize(code, args.RedirectURI, params)
 	if err != nil {
 		err = fmt.Errorf("verify by vendor, %w", err)
 		return
@@ -69,16 +98,35 @@ func (h *VerifyOauthHandler) Handle(ctx context.Context, args VerifyOauth) (resu
 	} else if err != nil {
 		err = fmt.Errorf("find account by vendor uid, %w", err)
 		return
+	} else if err = account.CheckLogin(time.Now()); err != nil {
+		return
 	}
 
-	sessionToken, err := h.Session.Generate(ctx, account)
+	result.Account = account
+	if account.MFAEnabled() {
+		result.MFAChallenge, err = h.Challenges.New(ctx, account)
+		if err != nil {
+			err = fmt.Errorf("new mfa challenge, %w", err)
+		}
+		return
+	}
+
+	sessionToken, err := h.Session.Generate(ctx, account, args.ClientInfo)
 	if err != nil {
 		err = fmt.Errorf("generate session token, %w", err)
 		return
 	}
-
-	result.Account = account
 	result.SessionToken = sessionToken
+
+	if err := h.Events.Publish(ctx, event.Login{
+		AccountID: account.ID,
+		Method:    event.LoginByOauth,
+		Vendor:    vendorUser.Vendor,
+		IP:        args.ClientInfo.IP,
+		Device:    args.ClientInfo.Device,
+	}); err != nil {
+		logger.Error(ctx, "publish login event", "account", account.ID, "error", err)
+	}
 	return
 }
 
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/internal/service"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// CreateWebhook 创建webhook，参数
+type CreateWebhook struct {
+	URL string `json:"url" validate:"required"`
+	// 订阅的事件类型，为空时订阅所有事件，内部事件不能订阅
+	Events []string `json:"events"`
+}
+
+// CreateWebhookHandler 创建webhook
+type CreateWebhookHandler struct {
+	Webhooks adapter.WebhookRepository `do:""`
+}
+
+// Handle 执行，返回的webhook包含签名密钥
+func (h *CreateWebhookHandler) Handle(ctx context.Context, args CreateWebhook) (*domain.Webhook, error) {
+	for _, name := range args.Events {
+		if !event.IsPublic(name) {
+			return nil, fmt.Errorf("%w, %q", domain.ErrUnknownEventType, name)
+		}
+	}
+
+	hook, err := domain.NewWebhook(args.URL, args.Events)
+	if err != nil {
+		return nil, fmt.Errorf("new webhook, %w", err)
+	} else if err := h.Webhooks.Create(ctx, hook); err != nil {
+		return nil, fmt.Errorf("save webhook, %w", err)
+	}
+	return hook, nil
+}
+
+// ListWebhooksHandler webhook列表
+type ListWebhooksHandler struct {
+	Webhooks adapter.WebhookRepository `do:""`
+}
+
+// Handle 执行
+func (h *ListWebhooksHandler) Handle(ctx context.Context) ([]*domain.Webhook, error) {
+	hooks, err := h.Webhooks.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks, %w", err)
+	}
+	return hooks, nil
+}
+
+// DeleteWebhookHandler 删除webhook
+type DeleteWebhookHandler struct {
+	Webhooks adapter.WebhookRepository `do:""`
+}
+
+// Handle 执行
+func (h *DeleteWebhookHandler) Handle(ctx context.Context, webhookID uuid.UUID) error {
+	return h.Webhooks.Delete(ctx, webhookID)
+}
+
+// ListWebhookDeliveries 查询投递记录，参数
+type ListWebhookDeliveries struct {
+	WebhookID uuid.UUID `json:"-"`
+	Status    string    `json:"status" validate:"omitempty,oneof=pending delivered dead"`
+	Limit     int       `json:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// ListWebhookDeliveriesHandler 查询投递记录
+type ListWebhookDeliveriesHandler struct {
+	Webhooks   adapter.WebhookRepository         `do:""`
+	Deliveries adapter.WebhookDeliveryRepository `do:""`
+}
+
+// Handle 执行，最新的在前
+func (h *ListWebhookDeliveriesHandler) Handle(ctx context.Context, args ListWebhookDeliveries) ([]*domain.WebhookDelivery, error) {
+	if _, err := h.Webhooks.Find(ctx, args.WebhookID); err != nil {
+		return nil, err
+	}
+
+	limit := args.Limit
+	if limit == 0 {
+		limit = 20
+	}
+
+	deliveries, err := h.Deliveries.ListByWebhook(ctx, args.WebhookID, args.Status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries, %w", err)
+	}
+	return deliveries, nil
+}
+
+// ReplayWebhookDeliveryHandler 重新投递
+type ReplayWebhookDeliveryHandler struct {
+	Deliveries adapter.WebhookDeliveryRepository `do:""`
+}
+
+// Handle 执行，投递记录会在下一次后台投递时发送
+func (h *ReplayWebhookDeliveryHandler) Handle(ctx context.Context, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	delivery, err := h.Deliveries.Find(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	} else if err := delivery.Replay(); err != nil {
+		return nil, err
+	} else if err := h.Deliveries.Update(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("save delivery, %w", err)
+	}
+	return delivery, nil
+}
+
+// EnqueueWebhooksHandler 为领域事件生成webhook投递记录
+type EnqueueWebhooksHandler struct {
+	Webhooks *service.WebhookService `do:""`
+}
+
+// Handle 执行，返回生成的投递记录数量
+func (h *EnqueueWebhooksHandler) Handle(ctx context.Context, ev any) (int, error) {
+	return h.Webhooks.Enqueue(ctx, ev)
+}
+
+// DeliverWebhooksHandler 投递到期的webhook
+type DeliverWebhooksHandler struct {
+	Webhooks *service.WebhookService `do:""`
+}
+
+// Handle 执行，返回处理的数量
+func (h *DeliverWebhooksHandler) Handle(ctx context.Context) (int, error) {
+	return h.Webhooks.Deliver(ctx)
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"ddd-example/internal/app/adapter"
 	"ddd-example/internal/domain"
 	"ddd-example/internal/infra"
+	"ddd-example/pkg/logger"
 
 	"github.com/joyparty/entity"
 )
@@ -25,26 +27,65 @@ func NewAccountService(db entity.DB) *AccountService {
 }
 
 // Authorize 验证
+//
+// 验证通过后，如果密码使用的是旧算法或者较弱的参数，会使用当前算法重新计算并保存
+//
+// 密码正确但账号不能登录时返回对应的错误，见Account.CheckLogin
 func (s *AccountService) Authorize(ctx context.Context, email, password string) (*domain.Account, error) {
 	account, err := s.Accounts.FindByEmail(ctx, domain.NormalizeEmail(email))
 	if err != nil {
 		return nil, err
 	} else if !account.ComparePassword(password) {
 		return nil, domain.ErrWrongPassword
+	} else if err := account.CheckLogin(time.Now()); err != nil {
+		return nil, err
+	}
+
+	if account.PasswordNeedsRehash() {
+		// 重新计算失败不影响本次登录，下次登录时会再次尝试
+		if err := s.rehashPassword(ctx, account, password); err != nil {
+			logger.Warn(ctx, "rehash password", "account", account.ID, "error", err)
+		}
 	}
 	return account, nil
 }
 
-// Create 创建新账号
-func (s *AccountService) Create(ctx context.Context, email, password string) (*domain.Account, error) {
-	_, err := s.Accounts.FindByEmail(ctx, domain.NormalizeEmail(email))
+func (s *AccountService) rehashPassword(ctx context.Context, account *domain.Account, password string) error {
+	if err := account.SetPassword(password); err != nil {
+		return fmt.Errorf("set password, %w", err)
+	} else if err := s.Accounts.Update(ctx, account); err != nil {
+		return fmt.Errorf("save account, %w", err)
+	}
+	return nil
+}
+
+// Create 创建新账号，language为首选语言，可以为空
+//
+// 注销账号的email在保留期过后可以重新注册
+func (s *AccountService) Create(ctx context.Context, email, password, language string) (*domain.Account, error) {
+	existing, err := s.Accounts.FindByEmail(ctx, domain.NormalizeEmail(email))
+	if err == nil && existing.ReleaseEmail(time.Now()) {
+		if err := s.Accounts.Update(ctx, existing); err != nil {
+			return nil, fmt.Errorf("release deleted account email, %w", err)
+		}
+		err = domain.ErrAccountNotFound
+	}
+
 	if errors.Is(err, domain.ErrAccountNotFound) {
 		account := &domain.Account{}
 		if err := account.SetEmail(email); err != nil {
 			return nil, fmt.Errorf("set email, %w", err)
-		} else if err := account.SetPassword(password); err != nil {
-			return nil, fmt.Errorf("set password, %w", err)
-		} else if err := s.Accounts.Create(ctx, account); err != nil {
+		}
+		account.SetLanguage(language)
+
+		// 三方账号注册的账号没有密码
+		if password != "" {
+			if err := account.SetPassword(password); err != nil {
+				return nil, fmt.Errorf("set password, %w", err)
+			}
+		}
+
+		if err := s.Accounts.Create(ctx, account); err != nil {
 			return nil, err
 		}
 		return account, nil
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func (r *memoryAccountRepository) Create(_ context.Context, account *domain.Account) error {
+	if account.ID == uuid.Nil {
+		account.ID = uuid.New()
+	}
+	r.data[account.ID] = account
+	return nil
+}
+
+func TestAccountServiceCreateDeletedEmail(t *testing.T) {
+	ctx := context.Background()
+	accounts := &memoryAccountRepository{data: map[uuid.UUID]*domain.Account{}}
+	s := &AccountService{Accounts: accounts}
+
+	deleted, err := s.Create(ctx, "test@example.com", "abcdef", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if err := deleted.MarkDeleted("", uuid.Nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 保留期内不能重新注册
+	if _, err := s.Create(ctx, "test@example.com", "abcdef", ""); !errors.Is(err, domain.ErrEmailRegistered) {
+		t.Fatalf("expected %v, got %v", domain.ErrEmailRegistered, err)
+	}
+
+	deleted.StatusChange.At = time.Now().Add(-domain.DeletedEmailRetention)
+	account, err := s.Create(ctx, "test@example.com", "abcdef", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if account.ID == deleted.ID {
+		t.Fatal("should create a new account")
+	} else if deleted.Email == account.Email {
+		t.Fatal("deleted account email should be released")
+	}
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+	"ddd-example/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+)
+
+// 导出文件生成策略
+const (
+	dataExportBatchSize = 10
+	dataExportLease     = 10 * time.Minute
+	// 导出的事件记录数量上限
+	dataExportEventLimit = 10000
+)
+
+// 下载凭证的用途
+const dataExportTokenPurpose = "data_export"
+
+// 登录历史包含的事件类型
+var loginHistoryTypes = []string{
+	event.TypeOf(event.Login{}),
+	event.TypeOf(event.Logout{}),
+	event.TypeOf(event.LoginLocked{}),
+}
+
+// DataExportService 个人数据导出，后台任务生成压缩包，通过签名链接下载
+type DataExportService struct {
+	DB            *sqlx.DB                       `do:""`
+	Exports       adapter.DataExportRepository   `do:""`
+	Accounts      adapter.AccountRepository      `do:""`
+	Oauth         adapter.OauthRepository        `do:""`
+	Sessions      adapter.SessionRepository      `do:""`
+	AccountEvents adapter.AccountEventRepository `do:""`
+	Storage       adapter.FileStorage            `do:""`
+	Tokens        *SignedTokenService            `do:""`
+}
+
+// Build 生成一批到期的导出文件，返回处理的数量
+func (s *DataExportService) Build(ctx context.Context) (int, error) {
+	exports, err := s.Exports.Claim(ctx, dataExportBatchSize, dataExportLease)
+	if err != nil {
+		return 0, fmt.Errorf("claim data exports, %w", err)
+	}
+
+	for i, export := range exports {
+		account, err := s.build(ctx, export)
+		if err != nil {
+			export.Fail(err, time.Now())
+			logger.Warn(ctx, "build data export",
+				"export", export.ID,
+				"account", export.AccountID,
+				"attempts", export.Attempts,
+				"error", err,
+			)
+		}
+
+		if err := s.save(ctx, export, account); err != nil {
+			// 没有保存成功的记录会在领取期过后重新生成
+			return i, fmt.Errorf("save data export, %s, %w", export.ID, err)
+		}
+	}
+	return len(exports), nil
+}
+
+// save 保存生成结果，生成成功时和领域事件在同一个事务内保存
+func (s *DataExportService) save(ctx context.Context, export *domain.DataExport, account *domain.Account) error {
+	if export.Status != domain.DataExportReady {
+		return s.Exports.Update(ctx, export)
+	}
+
+	return entity.TransactionX(ctx, s.DB, func(db entity.DB) error {
+		if err := infra.NewDataExportRepository(db).Update(ctx, export); err != nil {
+			return err
+		}
+
+		// 下载凭证在发送邮件时生成
+		if err := NewOutboxService(db).Publish(ctx, event.DataExportReady{
+			AccountID: account.ID,
+			Email:     account.Email,
+			ExportID:  export.ID,
+			ExpireAt:  export.ExpireAt,
+		}); err != nil {
+			return fmt.Errorf("publish data export ready event, %w", err)
+		}
+		return nil
+	})
+}
+
+// build 生成压缩包并保存到文件存储
+func (s *DataExportService) build(ctx context.Context, export *domain.DataExport) (*domain.Account, error) {
+	account, err := s.Accounts.Find(ctx, export.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("find account, %w", err)
+	} else if account.Status == domain.AccountDeleted {
+		return nil, fmt.Errorf("account %s", account.Status)
+	}
+
+	identities, err := s.Oauth.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list oauth identities, %w", err)
+	}
+
+	sessions, err := s.Sessions.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions, %w", err)
+	}
+
+	logins, err := s.AccountEvents.ListByAccount(ctx, account.ID, loginHistoryTypes, dataExportEventLimit)
+	if err != nil {
+		return nil, fmt.Errorf("list login history, %w", err)
+	}
+
+	events, err := s.AccountEvents.ListByAccount(ctx, account.ID, nil, dataExportEventLimit)
+	if err != nil {
+		return nil, fmt.Errorf("list account events, %w", err)
+	}
+
+	// 账号的json序列化本身不包含密码等敏感字段
+	files := []struct {
+		name string
+		data any
+	}{
+		{"account.json", account},
+		{"oauth.json", identities},
+		{"sessions.json", sessions},
+		{"login_history.json", exportedEvents(logins)},
+		{"events.json", exportedEvents(events)},
+	}
+
+	now := time.Now()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: now,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create %s, %w", f.name, err)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, fmt.Errorf("encode %s, %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("close archive, %w", err)
+	}
+
+	size, err := s.Storage.Put(ctx, export.File, buf)
+	if err != nil {
+		return nil, fmt.Errorf("save archive, %w", err)
+	}
+
+	export.Complete(size, now)
+	return account, nil
+}
+
+// NewToken 构造下载凭证，和导出文件同时失效
+func (s *DataExportService) NewToken(ctx context.Context, exportID uuid.UUID) (string, error) {
+	export, err := s.Exports.Find(ctx, exportID)
+	if err != nil {
+		return "", err
+	} else if !export.Downloadable(time.Now()) {
+		return "", domain.ErrDataExportNotFound
+	}
+
+	return s.Tokens.Sign(dataExportTokenPurpose, time.Until(export.ExpireAt), export.ID.String())
+}
+
+// Open 使用下载凭证打开导出文件
+func (s *DataExportService) Open(ctx context.Context, token string) (*domain.DataExport, io.ReadSeekCloser, error) {
+	claims, err := s.Tokens.Verify(dataExportTokenPurpose, token)
+	if err != nil {
+		return nil, nil, err
+	} else if len(claims) != 1 {
+		return nil, nil, domain.ErrInvalidToken
+	}
+
+	exportID, err := uuid.Parse(claims[0])
+	if err != nil {
+		return nil, nil, domain.ErrInvalidToken
+	}
+
+	export, err := s.Exports.Find(ctx, exportID)
+	if err != nil {
+		return nil, nil, err
+	} else if !export.Downloadable(time.Now()) {
+		return nil, nil, domain.ErrDataExportNotFound
+	}
+
+	f, err := s.Storage.Open(ctx, export.File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, domain.ErrDataExportNotFound
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("open archive, %w", err)
+	}
+	return export, f, nil
+}
+
+// Purge 删除一批过期的导出文件和记录，返回删除的数量
+func (s *DataExportService) Purge(ctx context.Context, before time.Time, limit int) (int, error) {
+	exports, err := s.Exports.ListExpired(ctx, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("list expired data exports, %w", err)
+	}
+
+	for i, export := range exports {
+		if err := s.Storage.Delete(ctx, export.File); err != nil {
+			return i, fmt.Errorf("delete archive, %s, %w", export.ID, err)
+		} else if err := s.Exports.Delete(ctx, export.ID); err != nil {
+			return i, fmt.Errorf("delete data export, %s, %w", export.ID, err)
+		}
+	}
+	return len(exports), nil
+}
+
+// exportedEvent 导出文件中的事件记录格式
+type exportedEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func exportedEvents(events []*adapter.AccountEvent) []exportedEvent {
+	result := make([]exportedEvent, 0, len(events))
+	for _, ev := range events {
+		result = append(result, exportedEvent{
+			ID:         ev.ID,
+			Type:       ev.Type,
+			OccurredAt: ev.OccurredAt,
+			Data:       ev.Data,
+		})
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/google/uuid"
+	"github.com/joyparty/entity"
+)
+
+const (
+	emailChangePurpose = "change-email"
+	emailChangeExpire  = 24 * time.Hour
+
+	emailRevertPurpose = "revert-email"
+	emailRevertExpire  = 72 * time.Hour
+)
+
+// EmailChangeService 修改email
+//
+// 新email需要通过邮件凭证确认之后才会生效，生效之后旧email会收到撤销凭证，可以在有效期内改回旧email。
+// 两种凭证都绑定了修改前后的email，确认凭证还绑定了会话签名盐，撤销凭证绑定了账号上保存的随机数，
+// 替换email时两者都会更新，凭证只能使用一次
+type EmailChangeService struct {
+	Accounts adapter.AccountRepository `do:""`
+	Session  *SessionTokenService      `do:""`
+	Tokens   *SignedTokenService       `do:""`
+}
+
+// WithDB 使用指定的数据库连接构造新的服务对象，用于在事务内替换email
+func (s *EmailChangeService) WithDB(db entity.DB) *EmailChangeService {
+	return &EmailChangeService{
+		Accounts: infra.NewAccountRepository(db),
+		Session:  s.Session.WithDB(db),
+		Tokens:   s.Tokens,
+	}
+}
+
+// NewToken 检查新email是否可用，构造确认凭证
+func (s *EmailChangeService) NewToken(ctx context.Context, account *domain.Account, newEmail string) (string, error) {
+	if err := s.CheckAvailable(ctx, account, newEmail); err != nil {
+		return "", err
+	}
+
+	// 凭证内容可以被解码，只保存签名盐的hash
+	return s.Tokens.Sign(emailChangePurpose, emailChangeExpire,
+		account.ID.String(),
+		account.Email,
+		newEmail,
+		hashToken(account.SessionSalt),
+	)
+}
+
+// Confirm 验证确认凭证，替换为新email，账号的所有会话失效，返回账号和修改前的email
+func (s *EmailChangeService) Confirm(ctx context.Context, token string) (*domain.Account, string, error) {
+	account, claims, err := s.verify(ctx, emailChangePurpose, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	oldEmail, newEmail := claims[1], claims[2]
+	if account.Email != oldEmail || claims[3] != hashToken(account.SessionSalt) {
+		return nil, "", domain.ErrInvalidToken
+	}
+
+	// 申请之后新email可能已经被其它账号注册
+	if err := s.CheckAvailable(ctx, account, newEmail); err != nil {
+		return nil, "", err
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate revert nonce, %w", err)
+	}
+	account.EmailRevertNonce = nonce
+
+	if err := s.swap(ctx, account, newEmail); err != nil {
+		return nil, "", err
+	}
+	return account, oldEmail, nil
+}
+
+// NewRevertToken 构造撤销凭证，发送到修改前的email
+//
+// 已经撤销或者再次修改过email时返回domain.ErrInvalidToken
+func (s *EmailChangeService) NewRevertToken(account *domain.Account, oldEmail string) (string, time.Time, error) {
+	if account.EmailRevertNonce == "" {
+		return "", time.Time{}, domain.ErrInvalidToken
+	}
+
+	token, err := s.Tokens.Sign(emailRevertPurpose, emailRevertExpire,
+		account.ID.String(),
+		oldEmail,
+		account.Email,
+		account.EmailRevertNonce,
+	)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, time.Now().Add(emailRevertExpire), nil
+}
+
+// Revert 验证撤销凭证，改回修改前的email，账号的所有会话失效，返回账号和被撤销的email
+func (s *EmailChangeService) Revert(ctx context.Context, token string) (*domain.Account, string, error) {
+	account, claims, err := s.verify(ctx, emailRevertPurpose, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	oldEmail, newEmail := claims[1], claims[2]
+	if account.Email != newEmail || account.EmailRevertNonce == "" || claims[3] != account.EmailRevertNonce {
+		return nil, "", domain.ErrInvalidToken
+	}
+
+	if err := s.CheckAvailable(ctx, account, oldEmail); err != nil {
+		return nil, "", err
+	}
+
+	account.EmailRevertNonce = ""
+	if err := s.swap(ctx, account, oldEmail); err != nil {
+		return nil, "", err
+	}
+	return account, newEmail, nil
+}
+
+// verify 验证凭证签名，返回账号和凭证内容：账号ID、修改前的email、修改后的email、绑定的随机数
+func (s *EmailChangeService) verify(ctx context.Context, purpose, token string) (*domain.Account, []string, error) {
+	claims, err := s.Tokens.Verify(purpose, token)
+	if err != nil {
+		return nil, nil, err
+	} else if len(claims) != 4 {
+		return nil, nil, domain.ErrInvalidToken
+	}
+
+	accountID, err := uuid.Parse(claims[0])
+	if err != nil {
+		return nil, nil, domain.ErrInvalidToken
+	}
+
+	account, err := s.Accounts.Find(ctx, accountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return nil, nil, domain.ErrInvalidToken
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("find account, %w", err)
+	}
+	return account, claims, nil
+}
+
+// CheckAvailable email是否可以被账号使用
+func (s *EmailChangeService) CheckAvailable(ctx context.Context, account *domain.Account, email string) error {
+	if email == account.Email {
+		return domain.ErrEmailRegistered
+	}
+
+	other, err := s.Accounts.FindByEmail(ctx, email)
+	if err == nil {
+		if other.ID != account.ID {
+			return domain.ErrEmailRegistered
+		}
+	} else if !errors.Is(err, domain.ErrAccountNotFound) {
+		return fmt.Errorf("find account by email, %w", err)
+	}
+	return nil
+}
+
+// swap 替换email，能收到凭证说明email属于本人，直接标记为已验证
+func (s *EmailChangeService) swap(ctx context.Context, account *domain.Account, email string) error {
+	if err := account.SetEmail(email); err != nil {
+		return fmt.Errorf("set email, %w", err)
+	}
+	account.VerifyEmail()
+
+	// Suspend会同时保存账号
+	if err := s.Session.Suspend(ctx, account); err != nil {
+		return fmt.Errorf("suspend sessions, %w", err)
+	}
+	return nil
+}
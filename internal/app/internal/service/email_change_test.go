@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/keyring"
+
+	"github.com/google/uuid"
+)
+
+func (r *memoryAccountRepository) Find(_ context.Context, accountID uuid.UUID) (*domain.Account, error) {
+	if a, ok := r.data[accountID]; ok {
+		return a, nil
+	}
+	return nil, domain.ErrAccountNotFound
+}
+
+type fakeSessionRepository struct {
+	adapter.SessionRepository
+}
+
+func (fakeSessionRepository) DeleteByAccount(context.Context, uuid.UUID, ...uuid.UUID) error {
+	return nil
+}
+
+func TestEmailChangeService(t *testing.T) {
+	ctx := context.Background()
+	keys, err := keyring.Load(filepath.Join(t.TempDir(), "token.keys"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := &memoryAccountRepository{data: map[uuid.UUID]*domain.Account{}}
+	account := &domain.Account{ID: uuid.New(), Email: "old@example.com", SessionSalt: "salt"}
+	other := &domain.Account{ID: uuid.New(), Email: "other@example.com"}
+	accounts.data[account.ID] = account
+	accounts.data[other.ID] = other
+
+	s := &EmailChangeService{
+		Accounts: accounts,
+		Session:  &SessionTokenService{Accounts: accounts, Sessions: fakeSessionRepository{}},
+		Tokens:   &SignedTokenService{Keys: keys},
+	}
+
+	for _, email := range []string{"old@example.com", "other@example.com"} {
+		if _, err := s.NewToken(ctx, account, email); !errors.Is(err, domain.ErrEmailRegistered) {
+			t.Fatalf("%s, expected %v, got %v", email, domain.ErrEmailRegistered, err)
+		}
+	}
+
+	token, err := s.NewToken(ctx, account, "new@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, oldEmail, err := s.Confirm(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	} else if changed.Email != "new@example.com" || oldEmail != "old@example.com" {
+		t.Fatalf("unexpected email, %s -> %s", oldEmail, changed.Email)
+	} else if !changed.EmailVerified {
+		t.Fatal("confirmed email should be verified")
+	} else if changed.SessionSalt == "salt" {
+		t.Fatal("sessions should be suspended")
+	}
+
+	// email已经修改，凭证不能重复使用
+	if _, _, err := s.Confirm(ctx, token); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+
+	revertToken, expireAt, err := s.NewRevertToken(changed, oldEmail)
+	if err != nil {
+		t.Fatal(err)
+	} else if !expireAt.After(time.Now()) {
+		t.Fatal("revert token should expire in the future")
+	} else if _, _, err := s.Confirm(ctx, revertToken); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatal("revert token should not be used to confirm")
+	}
+
+	reverted, revertedEmail, err := s.Revert(ctx, revertToken)
+	if err != nil {
+		t.Fatal(err)
+	} else if reverted.Email != "old@example.com" || revertedEmail != "new@example.com" {
+		t.Fatalf("unexpected email, %s -> %s", revertedEmail, reverted.Email)
+	} else if _, _, err := s.Revert(ctx, revertToken); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+
+	// 改回旧email之后，email重新匹配，确认凭证仍然不能再次使用
+	if _, _, err := s.Confirm(ctx, token); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	} else if _, _, err := s.NewRevertToken(reverted, revertedEmail); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+
+	// 再次修改email之后，之前的撤销凭证失效
+	token, err = s.NewToken(ctx, reverted, "new@example.com")
+	if err != nil {
+		t.Fatal(err)
+	} else if _, _, err := s.Confirm(ctx, token); err != nil {
+		t.Fatal(err)
+	} else if _, _, err := s.Revert(ctx, revertToken); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+}
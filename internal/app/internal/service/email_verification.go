@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/google/uuid"
+	"github.com/joyparty/entity"
+)
+
+const (
+	emailVerificationPurpose = "verify-email"
+	emailVerificationExpire  = 24 * time.Hour
+)
+
+// EmailVerificationService 邮箱验证
+type EmailVerificationService struct {
+	Accounts adapter.AccountRepository `do:""`
+	Tokens   *SignedTokenService       `do:""`
+}
+
+// WithDB 使用指定的数据库连接构造新的服务对象，用于在事务内保存验证结果
+func (s *EmailVerificationService) WithDB(db entity.DB) *EmailVerificationService {
+	return &EmailVerificationService{
+		Accounts: infra.NewAccountRepository(db),
+		Tokens:   s.Tokens,
+	}
+}
+
+// NewToken 构造邮箱验证凭证
+//
+// 凭证绑定了当前的email，修改email之后旧凭证自动失效
+func (s *EmailVerificationService) NewToken(account *domain.Account) (string, error) {
+	if account.EmailVerified {
+		return "", domain.ErrEmailAlreadyVerified
+	}
+
+	return s.Tokens.Sign(emailVerificationPurpose, emailVerificationExpire,
+		account.ID.String(),
+		account.Email,
+	)
+}
+
+// Verify 验证凭证，并把账号标记为已验证
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) (*domain.Account, error) {
+	claims, err := s.Tokens.Verify(emailVerificationPurpose, token)
+	if err != nil {
+		return nil, err
+	} else if len(claims) != 2 {
+		return nil, domain.ErrInvalidToken
+	}
+
+	accountID, err := uuid.Parse(claims[0])
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	account, err := s.Accounts.Find(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("find account, %w", err)
+	} else if account.Email != claims[1] {
+		return nil, domain.ErrInvalidToken
+	} else if account.EmailVerified {
+		return account, nil
+	}
+
+	account.VerifyEmail()
+	if err := s.Accounts.Update(ctx, account); err != nil {
+		return nil, fmt.Errorf("save account, %w", err)
+	}
+	return account, nil
+}
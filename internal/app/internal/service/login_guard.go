@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// LoginGuardService 密码登录失败次数限制
+//
+// 分别按账号和客户端IP记录连续失败次数，超过限制之后逐次延长等待时间，最终暂时锁定。
+// 计数的读写不是原子操作，并发请求下可能少计几次，对于防止暴力破解来说足够了
+type LoginGuardService struct {
+	Accounts adapter.AccountRepository `do:""`
+	Cache    adapter.Cacher            `do:""`
+	Events   *OutboxService            `do:""`
+}
+
+// Check 检查是否允许尝试登录，不允许时返回*domain.LoginLockedError
+func (s *LoginGuardService) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	for _, key := range loginGuardKeys(email, ip) {
+		attempts, err := s.get(ctx, key.cacheKey)
+		if err != nil {
+			return err
+		} else if err := attempts.Check(now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fail 记录一次失败，被锁定时发布领域事件
+func (s *LoginGuardService) Fail(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	email = domain.NormalizeEmail(email)
+
+	var locks []event.LoginLocked
+	for _, key := range loginGuardKeys(email, ip) {
+		attempts, err := s.get(ctx, key.cacheKey)
+		if err != nil {
+			return err
+		}
+
+		if attempts.Fail(now, key.policy) {
+			locks = append(locks, event.LoginLocked{
+				Email: email,
+				IP:    ip,
+				Scope: key.scope,
+				Until: attempts.LockedUntil,
+			})
+		}
+
+		ttl := max(key.policy.Window, attempts.LockedUntil.Sub(now))
+		if err := s.put(ctx, key.cacheKey, attempts, ttl); err != nil {
+			return err
+		}
+	}
+
+	if len(locks) == 0 {
+		return nil
+	}
+
+	accountID, err := s.accountID(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	events := make([]any, 0, len(locks))
+	for _, ev := range locks {
+		ev.AccountID = accountID
+		events = append(events, ev)
+	}
+	return s.Events.Publish(ctx, events...)
+}
+
+// Reset 登录成功之后清除账号的失败记录
+//
+// IP的失败记录不清除，否则攻击者可以用自己的账号登录来重置IP计数
+func (s *LoginGuardService) Reset(ctx context.Context, email string) error {
+	return s.Cache.Delete(ctx, loginGuardKeys(email, "")[0].cacheKey)
+}
+
+func (s *LoginGuardService) accountID(ctx context.Context, email string) (uuid.UUID, error) {
+	account, err := s.Accounts.FindByEmail(ctx, email)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return uuid.Nil, nil
+	} else if err != nil {
+		return uuid.Nil, fmt.Errorf("find account, %w", err)
+	}
+	return account.ID, nil
+}
+
+func (s *LoginGuardService) get(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	attempts := &domain.LoginAttempts{}
+
+	value, err := s.Cache.Get(ctx, key)
+	if errors.Is(err, domain.ErrMissingCache) {
+		return attempts, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get login attempts, %w", err)
+	} else if err := json.Unmarshal(value, attempts); err != nil {
+		return nil, fmt.Errorf("decode login attempts, %w", err)
+	}
+	return attempts, nil
+}
+
+func (s *LoginGuardService) put(ctx context.Context, key string, attempts *domain.LoginAttempts, ttl time.Duration) error {
+	value, err := json.Marshal(attempts)
+	if err != nil {
+		return fmt.Errorf("encode login attempts, %w", err)
+	}
+
+	if err := s.Cache.Put(ctx, key, value, ttl); err != nil {
+		return fmt.Errorf("save login attempts, %w", err)
+	}
+	return nil
+}
+
+type loginGuardKey struct {
+	scope    string
+	cacheKey string
+	policy   domain.LoginGuardPolicy
+}
+
+// loginGuardKeys 账号在前，IP在后，ip为空时只有账号
+func loginGuardKeys(email, ip string) []loginGuardKey {
+	keys := []loginGuardKey{
+		{
+			scope:    event.LoginLockedByAccount,
+			cacheKey: fmt.Sprintf("login_guard:account:%s", hashToken(domain.NormalizeEmail(email))),
+			policy:   domain.AccountLoginPolicy,
+		},
+	}
+
+	if ip != "" {
+		keys = append(keys, loginGuardKey{
+			scope:    event.LoginLockedByIP,
+			cacheKey: fmt.Sprintf("login_guard:ip:%s", ip),
+			policy:   domain.IPLoginPolicy,
+		})
+	}
+	return keys
+}
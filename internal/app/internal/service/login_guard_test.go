@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+)
+
+// 只实现测试用到的方法
+type fakeAccountRepository struct {
+	adapter.AccountRepository
+}
+
+func (fakeAccountRepository) FindByEmail(context.Context, string) (*domain.Account, error) {
+	return nil, domain.ErrAccountNotFound
+}
+
+type fakeOutboxRepository struct {
+	adapter.OutboxRepository
+	messages []*adapter.OutboxMessage
+}
+
+func (r *fakeOutboxRepository) Add(_ context.Context, messages ...*adapter.OutboxMessage) error {
+	r.messages = append(r.messages, messages...)
+	return nil
+}
+
+func TestLoginGuardService(t *testing.T) {
+	ctx := context.Background()
+	outbox := &fakeOutboxRepository{}
+	guard := &LoginGuardService{
+		Accounts: fakeAccountRepository{},
+		Cache:    infra.NewMemoryCache(),
+		Events:   &OutboxService{Outbox: outbox},
+	}
+
+	email, ip := "Test@Example.com", "127.0.0.1"
+	policy := domain.AccountLoginPolicy
+
+	for range policy.FreeAttempts {
+		if err := guard.Fail(ctx, email, ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := guard.Check(ctx, email, ip); err != nil {
+		t.Fatalf("free attempts should not delay, %v", err)
+	}
+
+	// 登录成功之后清除账号的失败记录
+	if err := guard.Reset(ctx, "test@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	for range policy.LockoutAttempts {
+		if err := guard.Fail(ctx, email, ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := guard.Check(ctx, email, ip); !errors.Is(err, domain.ErrLoginLocked) {
+		t.Fatalf("expected %v, got %v", domain.ErrLoginLocked, err)
+	} else if err := guard.Check(ctx, "other@example.com", "127.0.0.2"); err != nil {
+		t.Fatalf("other account and ip should not be locked, %v", err)
+	}
+
+	if len(outbox.messages) != 1 {
+		t.Fatalf("expected 1 lockout event, got %d", len(outbox.messages))
+	} else if v := outbox.messages[0].Type; v != "account.login_locked" {
+		t.Fatalf("unexpected event type %q", v)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const magicLinkExpire = 15 * time.Minute
+
+// MagicLinkService 免密码登录，通过邮件中的一次性凭证登录
+//
+// 凭证只在缓存中保存hash，使用一次之后立即删除
+type MagicLinkService struct {
+	Cache    adapter.Cacher            `do:""`
+	Accounts adapter.AccountRepository `do:""`
+}
+
+// NewToken 生成登录凭证，返回凭证和过期时间
+func (s *MagicLinkService) NewToken(ctx context.Context, account *domain.Account) (string, time.Time, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("generate token, %w", err)
+	}
+
+	if err := s.Cache.Put(ctx, magicLinkTokenKey(hashToken(token)), []byte(account.ID.String()), magicLinkExpire); err != nil {
+		return "", time.Time{}, fmt.Errorf("save token, %w", err)
+	}
+	return token, time.Now().Add(magicLinkExpire), nil
+}
+
+// Consume 查找凭证对应的账号，凭证随即失效
+//
+// 读取和删除是原子操作，同一个凭证并发使用时只有一个能成功
+func (s *MagicLinkService) Consume(ctx context.Context, token string) (*domain.Account, error) {
+	value, err := s.Cache.Take(ctx, magicLinkTokenKey(hashToken(token)))
+	if errors.Is(err, domain.ErrMissingCache) {
+		return nil, domain.ErrInvalidToken
+	} else if err != nil {
+		return nil, fmt.Errorf("take token, %w", err)
+	}
+
+	accountID, err := uuid.ParseBytes(value)
+	if err != nil {
+		return nil, fmt.Errorf("parse account id, %w", err)
+	}
+
+	account, err := s.Accounts.Find(ctx, accountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return nil, domain.ErrInvalidToken
+	}
+	return account, err
+}
+
+func magicLinkTokenKey(hash string) string {
+	return fmt.Sprintf("magic_link:%s", hash)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/google/uuid"
+)
+
+func TestMagicLinkService(t *testing.T) {
+	ctx := context.Background()
+	accounts := &memoryAccountRepository{data: map[uuid.UUID]*domain.Account{}}
+	account := &domain.Account{ID: uuid.New(), Email: "test@example.com"}
+	accounts.data[account.ID] = account
+
+	s := &MagicLinkService{
+		Cache:    infra.NewMemoryCache(),
+		Accounts: accounts,
+	}
+
+	token, _, err := s.NewToken(ctx, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := s.Consume(ctx, token); err != nil {
+		t.Fatal(err)
+	} else if found.ID != account.ID {
+		t.Fatal("unexpected account")
+	}
+
+	// 凭证只能使用一次
+	if _, err := s.Consume(ctx, token); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	} else if _, err := s.Consume(ctx, "not-exist"); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+}
+
+func TestMagicLinkServiceConcurrentConsume(t *testing.T) {
+	ctx := context.Background()
+	accounts := &memoryAccountRepository{data: map[uuid.UUID]*domain.Account{}}
+	account := &domain.Account{ID: uuid.New(), Email: "test@example.com"}
+	accounts.data[account.ID] = account
+
+	s := &MagicLinkService{
+		Cache:    infra.NewMemoryCache(),
+		Accounts: accounts,
+	}
+
+	token, _, err := s.NewToken(ctx, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		consumed atomic.Int32
+	)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Consume(ctx, token); err == nil {
+				consumed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := consumed.Load(); n != 1 {
+		t.Fatalf("token should be consumed once, got %d", n)
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"ddd-example/internal/app/adapter"
+)
+
+// 没有用户语言对应的模板时使用的语言
+const defaultMailLanguage = "zh"
+
+// mailTemplateFS 邮件模板
+//
+// 每种语言一个目录，每封邮件包含两个文件：
+// <name>.txt 定义subject和text，<name>.html 定义content，使用layout.html排版
+//
+//go:embed templates/mail
+var mailTemplateFS embed.FS
+
+type mailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// mailTemplates language -> name -> template
+var mailTemplates = sync.OnceValues(func() (map[string]map[string]*mailTemplate, error) {
+	root, err := fs.Sub(mailTemplateFS, "templates/mail")
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := fs.ReadDir(root, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]map[string]*mailTemplate{}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		language := dir.Name()
+		files, err := fs.Glob(root, path.Join(language, "*.txt"))
+		if err != nil {
+			return nil, err
+		}
+
+		result[language] = map[string]*mailTemplate{}
+		for _, file := range files {
+			name := strings.TrimSuffix(path.Base(file), ".txt")
+			tpl, err := parseMailTemplate(root, language, name)
+			if err != nil {
+				return nil, fmt.Errorf("parse mail template %s/%s, %w", language, name, err)
+			}
+			result[language][name] = tpl
+		}
+	}
+	return result, nil
+})
+
+func parseMailTemplate(root fs.FS, language, name string) (*mailTemplate, error) {
+	textFile := path.Join(language, name+".txt")
+	text, err := texttemplate.New(name).ParseFS(root, textFile)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.New(name).
+		Funcs(htmltemplate.FuncMap{
+			"language": func() string { return language },
+		}).
+		ParseFS(root, "layout.html", textFile, path.Join(language, name+".html"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &mailTemplate{text: text, html: html}, nil
+}
+
+// MailService 发送模板邮件
+type MailService struct {
+	Mailer adapter.Mailer `do:""`
+}
+
+// Send 使用模板发送邮件，language没有对应的模板时使用默认语言
+func (s *MailService) Send(ctx context.Context, to, language, name string, data any) error {
+	mail, err := renderMail(language, name, data)
+	if err != nil {
+		return err
+	}
+	mail.To = to
+
+	return s.Mailer.Send(ctx, mail)
+}
+
+func renderMail(language, name string, data any) (*adapter.Mail, error) {
+	templates, err := mailTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("load mail templates, %w", err)
+	}
+
+	tpl, ok := templates[mailLanguage(templates, language)][name]
+	if !ok {
+		return nil, fmt.Errorf("mail template %q not found", name)
+	}
+
+	subject, err := executeTemplate(tpl.text, "subject", data)
+	if err != nil {
+		return nil, fmt.Errorf("render mail %q subject, %w", name, err)
+	}
+
+	text, err := executeTemplate(tpl.text, "text", data)
+	if err != nil {
+		return nil, fmt.Errorf("render mail %q text, %w", name, err)
+	}
+
+	html, err := executeTemplate(tpl.html, "layout", data)
+	if err != nil {
+		return nil, fmt.Errorf("render mail %q html, %w", name, err)
+	}
+
+	return &adapter.Mail{
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	}, nil
+}
+
+func executeTemplate(tpl interface {
+	ExecuteTemplate(io.Writer, string, any) error
+}, name string, data any) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tpl.ExecuteTemplate(buf, name, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// mailLanguage 选择模板语言，先完整匹配，再匹配主语言，例如zh-cn使用zh
+func mailLanguage(templates map[string]map[string]*mailTemplate, language string) string {
+	language = strings.ToLower(language)
+	if _, ok := templates[language]; ok {
+		return language
+	}
+
+	primary, _, _ := strings.Cut(language, "-")
+	if _, ok := templates[primary]; ok {
+		return primary
+	}
+	return defaultMailLanguage
+}
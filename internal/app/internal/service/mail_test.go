@@ -0,0 +1,57 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMail(t *testing.T) {
+	templates, err := mailTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每种语言都需要有相同的模板
+	for language, names := range templates {
+		for name := range templates[defaultMailLanguage] {
+			if _, ok := names[name]; !ok {
+				t.Fatalf("mail template %s/%s not found", language, name)
+			}
+		}
+	}
+
+	data := struct {
+		Email  string
+		Token  string
+		Reason string
+	}{
+		Email:  "test@example.com",
+		Token:  "<token>",
+		Reason: "reset",
+	}
+
+	for _, c := range []struct {
+		language string
+		subject  string
+	}{
+		{"", "重置密码"},
+		{"en", "Reset your password"},
+		{"en-us", "Reset your password"},
+		{"fr", "重置密码"},
+	} {
+		mail, err := renderMail(c.language, "password_reset", data)
+		if err != nil {
+			t.Fatal(err)
+		} else if mail.Subject != c.subject {
+			t.Fatalf("language %q, expected subject %q, got %q", c.language, c.subject, mail.Subject)
+		} else if !strings.Contains(mail.Text, "<token>") {
+			t.Fatalf("text should contain raw token, %q", mail.Text)
+		} else if !strings.Contains(mail.HTML, "&lt;token&gt;") {
+			t.Fatalf("html should escape token, %q", mail.HTML)
+		}
+	}
+
+	if _, err := renderMail("en", "not_exist", data); err == nil {
+		t.Fatal("render not exist template should fail")
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	mfaChallengeExpire = 5 * time.Minute
+	// 每个挑战凭证允许的验证码尝试次数
+	mfaChallengeAttempts = 5
+)
+
+// MFAChallengeService 两步验证挑战凭证
+//
+// 开启两步验证的账号密码验证通过后，先下发挑战凭证，
+// 使用挑战凭证和验证码换取真正的会话凭证
+type MFAChallengeService struct {
+	Cache adapter.Cacher `do:""`
+}
+
+// MFAChallenge 挑战凭证对应的账号以及已经尝试的次数
+type MFAChallenge struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Expire    int64     `json:"expire"`
+	Attempts  int       `json:"attempts"`
+}
+
+// New 生成挑战凭证
+func (s *MFAChallengeService) New(ctx context.Context, account *domain.Account) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("generate token, %w", err)
+	}
+
+	challenge := MFAChallenge{
+		AccountID: account.ID,
+		Expire:    time.Now().Add(mfaChallengeExpire).Unix(),
+	}
+	if err := s.save(ctx, token, challenge); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Take 取出挑战凭证，取出之后凭证失效，并发请求只有一个能拿到
+//
+// 验证码错误时调用Fail放回
+func (s *MFAChallengeService) Take(ctx context.Context, token string) (MFAChallenge, error) {
+	var challenge MFAChallenge
+
+	value, err := s.Cache.Take(ctx, mfaChallengeKey(token))
+	if errors.Is(err, domain.ErrMissingCache) {
+		return challenge, domain.ErrInvalidToken
+	} else if err != nil {
+		return challenge, fmt.Errorf("take challenge, %w", err)
+	} else if err := json.Unmarshal(value, &challenge); err != nil {
+		return challenge, fmt.Errorf("decode challenge, %w", err)
+	} else if time.Unix(challenge.Expire, 0).Before(time.Now()) {
+		return challenge, domain.ErrInvalidToken
+	}
+	return challenge, nil
+}
+
+// Fail 记录一次验证失败，没有超过尝试次数时放回挑战凭证，否则凭证失效
+func (s *MFAChallengeService) Fail(ctx context.Context, token string, challenge MFAChallenge) error {
+	challenge.Attempts++
+	if challenge.Attempts >= mfaChallengeAttempts {
+		return nil
+	}
+	return s.Restore(ctx, token, challenge)
+}
+
+// Restore 放回取出的挑战凭证，不计入尝试次数
+func (s *MFAChallengeService) Restore(ctx context.Context, token string, challenge MFAChallenge) error {
+	return s.save(ctx, token, challenge)
+}
+
+// save 更新尝试次数时不延长有效期
+func (s *MFAChallengeService) save(ctx context.Context, token string, challenge MFAChallenge) error {
+	value, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("encode challenge, %w", err)
+	}
+
+	ttl := time.Until(time.Unix(challenge.Expire, 0))
+	if err := s.Cache.Put(ctx, mfaChallengeKey(token), value, ttl); err != nil {
+		return fmt.Errorf("save challenge, %w", err)
+	}
+	return nil
+}
+
+func mfaChallengeKey(token string) string {
+	return fmt.Sprintf("mfa_challenge:%s", hashToken(token))
+}
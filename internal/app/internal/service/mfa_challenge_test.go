@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/google/uuid"
+)
+
+func TestMFAChallengeService(t *testing.T) {
+	ctx := context.Background()
+	s := &MFAChallengeService{Cache: infra.NewMemoryCache()}
+	account := &domain.Account{ID: uuid.New()}
+
+	token, err := s.New(ctx, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := s.Take(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	} else if challenge.AccountID != account.ID {
+		t.Fatalf("unexpected account %s", challenge.AccountID)
+	}
+
+	// 取出之后不能再次使用
+	if _, err := s.Take(ctx, token); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+
+	// 失败之后放回，超过尝试次数之后失效
+	for i := range mfaChallengeAttempts {
+		if err := s.Fail(ctx, token, challenge); err != nil {
+			t.Fatal(err)
+		}
+
+		challenge, err = s.Take(ctx, token)
+		if i < mfaChallengeAttempts-1 {
+			if err != nil {
+				t.Fatalf("attempt %d, %v", i+1, err)
+			}
+		} else if !errors.Is(err, domain.ErrInvalidToken) {
+			t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+		}
+	}
+}
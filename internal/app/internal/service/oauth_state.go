@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/oauth"
+)
+
+const oauthStateExpire = 10 * time.Minute
+
+// OauthStateService 三方登录流程参数缓存
+//
+// 跳转到三方站点之前保存state、nonce、PKCE verifier，
+// 回调验证时使用state取出，每个state只能使用一次
+type OauthStateService struct {
+	Cache adapter.Cacher `do:""`
+}
+
+type oauthState struct {
+	Vendor string           `json:"vendor"`
+	Params oauth.AuthParams `json:"params"`
+}
+
+// Save 保存参数
+func (s *OauthStateService) Save(ctx context.Context, vendor string, params oauth.AuthParams) error {
+	value, err := json.Marshal(oauthState{
+		Vendor: vendor,
+		Params: params,
+	})
+	if err != nil {
+		return fmt.Errorf("encode oauth state, %w", err)
+	}
+
+	return s.Cache.Put(ctx, oauthStateKey(params.State), value, oauthStateExpire)
+}
+
+// Consume 取出state对应的参数，取出之后state失效
+func (s *OauthStateService) Consume(ctx context.Context, vendor, state string) (oauth.AuthParams, error) {
+	key := oauthStateKey(state)
+
+	value, err := s.Cache.Get(ctx, key)
+	if errors.Is(err, domain.ErrMissingCache) {
+		return oauth.AuthParams{}, domain.ErrInvalidOauthState
+	} else if err != nil {
+		return oauth.AuthParams{}, fmt.Errorf("get oauth state, %w", err)
+	} else if err := s.Cache.Delete(ctx, key); err != nil {
+		return oauth.AuthParams{}, fmt.Errorf("delete oauth state, %w", err)
+	}
+
+	v := oauthState{}
+	if err := json.Unmarshal(value, &v); err != nil {
+		return oauth.AuthParams{}, fmt.Errorf("decode oauth state, %w", err)
+	} else if v.Vendor != vendor || v.Params.State != state {
+		return oauth.AuthParams{}, domain.ErrInvalidOauthState
+	}
+	return v.Params, nil
+}
+
+func oauthStateKey(state string) string {
+	return fmt.Sprintf("oauth_state:%s", hashToken(state))
+}
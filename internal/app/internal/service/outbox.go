@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/infra"
+	"ddd-example/pkg/logger"
+
+	"github.com/joyparty/entity"
+)
+
+// OutboxService 领域事件发布
+//
+// 事件先写入outbox，由后台任务投递到事件流。需要和业务数据保持一致时，
+// 使用NewOutboxService(tx)在同一个事务内写入
+type OutboxService struct {
+	Outbox adapter.OutboxRepository `do:""`
+}
+
+// NewOutboxService 构造函数
+func NewOutboxService(db entity.DB) *OutboxService {
+	return &OutboxService{
+		Outbox: infra.NewOutboxRepository(db),
+	}
+}
+
+// Publish 发布领域事件
+func (s *OutboxService) Publish(ctx context.Context, events ...any) error {
+	messages := make([]*adapter.OutboxMessage, 0, len(events))
+	for _, ev := range events {
+		envelope, err := event.NewEnvelope(ev)
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(envelope)
+		if err != nil {
+			return fmt.Errorf("encode event envelope, %w", err)
+		}
+
+		messages = append(messages, &adapter.OutboxMessage{
+			ID:            envelope.ID,
+			Type:          envelope.Type,
+			Payload:       payload,
+			Status:        adapter.OutboxPending,
+			NextAttemptAt: envelope.OccurredAt,
+			CreateAt:      envelope.OccurredAt,
+		})
+	}
+	return s.Outbox.Add(ctx, messages...)
+}
+
+// outbox投递策略
+const (
+	outboxBatchSize   = 100
+	outboxLease       = time.Minute
+	outboxMaxAttempts = 10
+	outboxMaxBackoff  = time.Hour
+)
+
+// Dispatch 投递一批到期的事件，返回投递成功的数量
+//
+// 所有消费者都处理成功之后才标记为已投递，进程在处理过程中退出时，领取期过后会重新投递。
+// 投递失败的事件按指数退避重试，只重新交给失败的消费者，超过最大次数后标记为失败，不再重试
+func (s *OutboxService) Dispatch(ctx context.Context) (int, error) {
+	messages, err := s.Outbox.Claim(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, fmt.Errorf("claim outbox messages, %w", err)
+	}
+
+	var n int
+	for _, msg := range messages {
+		if err := s.dispatch(ctx, msg); err != nil {
+			logger.Warn(ctx, "dispatch outbox message",
+				"id", msg.ID,
+				"type", msg.Type,
+				"attempts", msg.Attempts+1,
+				"error", err,
+			)
+
+			msg.Attempts++
+			msg.LastError = err.Error()
+			msg.NextAttemptAt = time.Now().Add(outboxBackoff(msg.Attempts))
+			if msg.Attempts >= outboxMaxAttempts {
+				msg.Status = adapter.OutboxFailed
+			}
+		} else {
+			msg.Status = adapter.OutboxDispatched
+			n++
+		}
+
+		if err := s.Outbox.Update(ctx, msg); err != nil {
+			// 没有保存成功的消息会在领取期过后再次投递
+			return n, fmt.Errorf("save outbox message, %s, %w", msg.ID, err)
+		}
+	}
+	return n, nil
+}
+
+// dispatch 交给消费者处理，处理成功的消费者记录在消息上
+func (s *OutboxService) dispatch(ctx context.Context, msg *adapter.OutboxMessage) error {
+	envelope := &event.Envelope{}
+	if err := json.Unmarshal(msg.Payload, envelope); err != nil {
+		return fmt.Errorf("decode event envelope, %w", err)
+	}
+
+	ev, err := envelope.Event()
+	if err != nil {
+		return err
+	}
+
+	msg.Handled, err = event.Dispatch(ctx, ev, msg.Handled)
+	return err
+}
+
+// Purge 清理已投递和已失败的事件
+func (s *OutboxService) Purge(ctx context.Context, dispatchedBefore, failedBefore time.Time) (int64, error) {
+	dispatched, err := s.Outbox.Purge(ctx, adapter.OutboxDispatched, dispatchedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("purge dispatched messages, %w", err)
+	}
+
+	failed, err := s.Outbox.Purge(ctx, adapter.OutboxFailed, failedBefore)
+	if err != nil {
+		return dispatched, fmt.Errorf("purge failed messages, %w", err)
+	}
+	return dispatched + failed, nil
+}
+
+// outboxBackoff 第n次失败之后的重试间隔
+func outboxBackoff(attempts int) time.Duration {
+	d := time.Second << min(attempts, 12)
+	return min(d, outboxMaxBackoff)
+}
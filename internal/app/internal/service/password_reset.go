@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const passwordResetExpire = 30 * time.Minute
+
+// PasswordResetService 忘记密码，通过邮件凭证重置密码
+//
+// 凭证只在缓存中保存hash，每个账号同时只有最新申请的凭证有效
+type PasswordResetService struct {
+	Cache    adapter.Cacher            `do:""`
+	Accounts adapter.AccountRepository `do:""`
+}
+
+// NewToken 生成重置凭证，同一账号之前申请的凭证会失效
+func (s *PasswordResetService) NewToken(ctx context.Context, account *domain.Account) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("generate token, %w", err)
+	}
+	hash := hashToken(token)
+
+	accountKey := passwordResetAccountKey(account.ID)
+	if prev, err := s.Cache.Get(ctx, accountKey); err == nil {
+		if err := s.Cache.Delete(ctx, passwordResetTokenKey(string(prev))); err != nil {
+			return "", fmt.Errorf("delete previous token, %w", err)
+		}
+	} else if !errors.Is(err, domain.ErrMissingCache) {
+		return "", fmt.Errorf("get previous token, %w", err)
+	}
+
+	if err := s.Cache.Put(ctx, passwordResetTokenKey(hash), []byte(account.ID.String()), passwordResetExpire); err != nil {
+		return "", fmt.Errorf("save token, %w", err)
+	} else if err := s.Cache.Put(ctx, accountKey, []byte(hash), passwordResetExpire); err != nil {
+		return "", fmt.Errorf("save token, %w", err)
+	}
+	return token, nil
+}
+
+// Retrieve 查找凭证对应的账号
+func (s *PasswordResetService) Retrieve(ctx context.Context, token string) (*domain.Account, error) {
+	value, err := s.Cache.Get(ctx, passwordResetTokenKey(hashToken(token)))
+	if errors.Is(err, domain.ErrMissingCache) {
+		return nil, domain.ErrInvalidToken
+	} else if err != nil {
+		return nil, fmt.Errorf("get token, %w", err)
+	}
+
+	accountID, err := uuid.ParseBytes(value)
+	if err != nil {
+		return nil, fmt.Errorf("parse account id, %w", err)
+	}
+
+	account, err := s.Accounts.Find(ctx, accountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return nil, domain.ErrInvalidToken
+	}
+	return account, err
+}
+
+// Consume 使凭证失效，凭证已经被使用过或者不属于这个账号时返回domain.ErrInvalidToken
+//
+// 读取和删除是原子操作，同一个凭证并发使用时只有一个能成功
+func (s *PasswordResetService) Consume(ctx context.Context, account *domain.Account, token string) error {
+	value, err := s.Cache.Take(ctx, passwordResetTokenKey(hashToken(token)))
+	if errors.Is(err, domain.ErrMissingCache) {
+		return domain.ErrInvalidToken
+	} else if err != nil {
+		return fmt.Errorf("take token, %w", err)
+	} else if string(value) != account.ID.String() {
+		return domain.ErrInvalidToken
+	}
+
+	if err := s.Cache.Delete(ctx, passwordResetAccountKey(account.ID)); err != nil {
+		return fmt.Errorf("delete token, %w", err)
+	}
+	return nil
+}
+
+func passwordResetTokenKey(hash string) string {
+	return fmt.Sprintf("password_reset:%s", hash)
+}
+
+func passwordResetAccountKey(accountID uuid.UUID) string {
+	return fmt.Sprintf("password_reset:account:%s", accountID)
+}
+
+// randomToken 生成随机凭证
+func randomToken() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// hashToken 缓存中只保存凭证的hash，缓存泄露时无法直接使用
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/google/uuid"
+)
+
+func TestPasswordResetService(t *testing.T) {
+	ctx := context.Background()
+	accounts := &memoryAccountRepository{data: map[uuid.UUID]*domain.Account{}}
+	account := &domain.Account{ID: uuid.New(), Email: "test@example.com"}
+	other := &domain.Account{ID: uuid.New(), Email: "other@example.com"}
+	accounts.data[account.ID] = account
+	accounts.data[other.ID] = other
+
+	s := &PasswordResetService{
+		Cache:    infra.NewMemoryCache(),
+		Accounts: accounts,
+	}
+
+	// 重新申请之后，之前的凭证失效
+	prev, err := s.NewToken(ctx, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := s.NewToken(ctx, account)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := s.Retrieve(ctx, prev); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+
+	if found, err := s.Retrieve(ctx, token); err != nil {
+		t.Fatal(err)
+	} else if found.ID != account.ID {
+		t.Fatal("unexpected account")
+	}
+
+	// 凭证只能被对应的账号消费
+	otherToken, err := s.NewToken(ctx, other)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := s.Consume(ctx, account, otherToken); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+
+	if err := s.Consume(ctx, account, token); err != nil {
+		t.Fatal(err)
+	} else if err := s.Consume(ctx, account, token); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	} else if _, err := s.Retrieve(ctx, token); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidToken, err)
+	}
+}
+
+func TestPasswordResetServiceConcurrentConsume(t *testing.T) {
+	ctx := context.Background()
+	accounts := &memoryAccountRepository{data: map[uuid.UUID]*domain.Account{}}
+	account := &domain.Account{ID: uuid.New(), Email: "test@example.com"}
+	accounts.data[account.ID] = account
+
+	s := &PasswordResetService{
+		Cache:    infra.NewMemoryCache(),
+		Accounts: accounts,
+	}
+
+	token, err := s.NewToken(ctx, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		consumed atomic.Int32
+	)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Consume(ctx, account, token); err == nil {
+				consumed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := consumed.Load(); n != 1 {
+		t.Fatalf("token should be consumed once, got %d", n)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+
+	"github.com/joyparty/entity"
+)
+
+// RoleService 角色和权限
+type RoleService struct {
+	Accounts adapter.AccountRepository `do:""`
+	Roles    adapter.RoleRepository    `do:""`
+}
+
+// WithDB 使用指定的数据库连接构造新的服务对象，用于在事务内修改角色
+func (s *RoleService) WithDB(db entity.DB) *RoleService {
+	return &RoleService{
+		Accounts: infra.NewAccountRepository(db),
+		Roles:    infra.NewRoleRepository(db),
+	}
+}
+
+// Permissions 账号拥有的所有权限
+func (s *RoleService) Permissions(ctx context.Context, account *domain.Account) (domain.Permissions, error) {
+	roles, err := s.Roles.ListByNames(ctx, account.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("list roles, %w", err)
+	}
+	return domain.NewPermissions(roles...), nil
+}
+
+// Assign 分配角色，已经拥有这个角色时返回false
+func (s *RoleService) Assign(ctx context.Context, account *domain.Account, role string) (bool, error) {
+	if _, err := s.Roles.Find(ctx, role); err != nil {
+		return false, err
+	} else if !account.AssignRole(role) {
+		return false, nil
+	} else if err := s.Accounts.Update(ctx, account); err != nil {
+		return false, fmt.Errorf("save account, %w", err)
+	}
+	return true, nil
+}
+
+// Revoke 撤销角色，没有这个角色时返回false
+//
+// 不能撤销最后一个超级管理员，否则没有人可以再分配角色
+func (s *RoleService) Revoke(ctx context.Context, account *domain.Account, role string) (bool, error) {
+	if !account.HasRole(role) {
+		return false, nil
+	}
+
+	if role == domain.RoleSuperAdmin {
+		if n, err := s.Roles.CountAccounts(ctx, role); err != nil {
+			return false, fmt.Errorf("count super admins, %w", err)
+		} else if n <= 1 {
+			return false, domain.ErrLastSuperAdmin
+		}
+	}
+
+	account.RevokeRole(role)
+	if err := s.Accounts.Update(ctx, account); err != nil {
+		return false, fmt.Errorf("save account, %w", err)
+	}
+	return true, nil
+}
+
+// Bootstrap 把已注册的账号设置为第一个超级管理员，已经存在超级管理员时返回domain.ErrSuperAdminExists
+func (s *RoleService) Bootstrap(ctx context.Context, email string) (*domain.Account, error) {
+	if n, err := s.Roles.CountAccounts(ctx, domain.RoleSuperAdmin); err != nil {
+		return nil, fmt.Errorf("count super admins, %w", err)
+	} else if n > 0 {
+		return nil, domain.ErrSuperAdminExists
+	}
+
+	account, err := s.Accounts.FindByEmail(ctx, domain.NormalizeEmail(email))
+	if err != nil {
+		return nil, err
+	} else if _, err := s.Assign(ctx, account, domain.RoleSuperAdmin); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
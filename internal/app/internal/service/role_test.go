@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// 按账号上的角色统计，模拟account_roles表
+type fakeRoleRepository struct {
+	adapter.RoleRepository
+	accounts *memoryAccountRepository
+}
+
+func (r fakeRoleRepository) Find(_ context.Context, name string) (*domain.Role, error) {
+	if name != domain.RoleSuperAdmin && name != "support" {
+		return nil, domain.ErrRoleNotFound
+	}
+	return &domain.Role{Name: name}, nil
+}
+
+func (r fakeRoleRepository) CountAccounts(_ context.Context, name string) (int, error) {
+	var n int
+	for _, a := range r.accounts.data {
+		if a.HasRole(name) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+type memoryAccountRepository struct {
+	adapter.AccountRepository
+	data map[uuid.UUID]*domain.Account
+}
+
+func (r *memoryAccountRepository) FindByEmail(_ context.Context, email string) (*domain.Account, error) {
+	for _, a := range r.data {
+		if a.Email == email {
+			return a, nil
+		}
+	}
+	return nil, domain.ErrAccountNotFound
+}
+
+func (r *memoryAccountRepository) Update(_ context.Context, account *domain.Account) error {
+	r.data[account.ID] = account
+	return nil
+}
+
+func TestRoleService(t *testing.T) {
+	ctx := context.Background()
+	accounts := &memoryAccountRepository{data: map[uuid.UUID]*domain.Account{}}
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		a := &domain.Account{ID: uuid.New(), Email: email}
+		accounts.data[a.ID] = a
+	}
+	s := &RoleService{
+		Accounts: accounts,
+		Roles:    fakeRoleRepository{accounts: accounts},
+	}
+
+	first, err := s.Bootstrap(ctx, "A@example.com")
+	if err != nil {
+		t.Fatal(err)
+	} else if !first.HasRole(domain.RoleSuperAdmin) {
+		t.Fatal("bootstrap should assign super admin")
+	} else if _, err := s.Bootstrap(ctx, "b@example.com"); !errors.Is(err, domain.ErrSuperAdminExists) {
+		t.Fatalf("expected %v, got %v", domain.ErrSuperAdminExists, err)
+	}
+
+	if _, err := s.Revoke(ctx, first, domain.RoleSuperAdmin); !errors.Is(err, domain.ErrLastSuperAdmin) {
+		t.Fatalf("expected %v, got %v", domain.ErrLastSuperAdmin, err)
+	}
+
+	second, _ := accounts.FindByEmail(ctx, "b@example.com")
+	if _, err := s.Assign(ctx, second, "nobody"); !errors.Is(err, domain.ErrRoleNotFound) {
+		t.Fatalf("expected %v, got %v", domain.ErrRoleNotFound, err)
+	} else if ok, err := s.Assign(ctx, second, domain.RoleSuperAdmin); err != nil || !ok {
+		t.Fatalf("assign super admin, %v", err)
+	}
+
+	if ok, err := s.Revoke(ctx, first, domain.RoleSuperAdmin); err != nil || !ok {
+		t.Fatalf("revoke super admin, %v", err)
+	} else if ok, err := s.Revoke(ctx, first, domain.RoleSuperAdmin); err != nil || ok {
+		t.Fatalf("revoke missing role should return false, %v", err)
+	}
+}
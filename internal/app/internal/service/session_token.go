@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/md5"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strconv"
@@ -10,49 +12,161 @@ import (
 	"time"
 
 	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
 	"ddd-example/internal/domain"
+	"ddd-example/internal/infra"
+	"ddd-example/pkg/keyring"
 
 	"github.com/google/uuid"
+	"github.com/joyparty/entity"
 )
 
 var (
-	tokenExpire = 30 * 24 * time.Hour // 30天
+	tokenExpire = domain.SessionLifetime
 	tokenRenew  = 7 * 24 * time.Hour
 )
 
+const (
+	// md5(payload,salt)签名的旧格式，只用于验证，不再下发
+	tokenVersionLegacy = 1
+	// 使用服务器密钥环HMAC-SHA256签名
+	tokenVersionHMAC = 2
+
+	tokenPrefixHMAC = "v2."
+)
+
 // SessionTokenService 会话凭证逻辑
 type SessionTokenService struct {
 	Accounts adapter.AccountRepository `do:""`
+	Sessions adapter.SessionRepository `do:""`
+	Keys     *keyring.KeyRing          `do:""`
+	Events   *OutboxService            `do:""`
 }
 
-// Generate 构造会话凭证
-func (s *SessionTokenService) Generate(ctx context.Context, account *domain.Account) (payload string, err error) {
-	if err := s.Suspend(ctx, account); err != nil {
+// WithDB 使用指定的数据库连接构造新的服务对象，用于在事务内修改会话
+func (s *SessionTokenService) WithDB(db entity.DB) *SessionTokenService {
+	return &SessionTokenService{
+		Accounts: infra.NewAccountRepository(db),
+		Sessions: infra.NewSessionRepository(db),
+		Keys:     s.Keys,
+		Events:   NewOutboxService(db),
+	}
+}
+
+// Generate 登录新会话并构造会话凭证，不影响同一账号的其它会话
+//
+// 所有登录方式都经过这里，不能登录的账号返回对应的错误，见Account.CheckLogin。
+// 宽限期内申请注销的账号登录时，撤销注销申请
+func (s *SessionTokenService) Generate(ctx context.Context, account *domain.Account, client domain.ClientInfo) (payload string, err error) {
+	if err := account.CheckLogin(time.Now()); err != nil {
 		return "", err
+	} else if account.Status == domain.AccountPendingDeletion {
+		if err := s.cancelDeletion(ctx, account); err != nil {
+			return "", err
+		}
+	}
+
+	session, err := domain.NewSession(account.ID, client)
+	if err != nil {
+		return "", fmt.Errorf("new session, %w", err)
+	} else if err := s.Sessions.Create(ctx, session); err != nil {
+		return "", fmt.Errorf("save session, %w", err)
 	}
 
-	return s.Renew(account)
+	return s.Renew(account, session.ID)
 }
 
-// Renew 构造会话凭证，但不刷新session salt
-func (s *SessionTokenService) Renew(account *domain.Account) (payload string, err error) {
+// Upgrade 为没有会话记录的旧凭证补充会话记录，构造新格式的会话凭证
+//
+// 旧凭证没有经过登录验证，补充的会话不能用于需要重新验证身份的操作
+func (s *SessionTokenService) Upgrade(ctx context.Context, account *domain.Account, client domain.ClientInfo) (payload string, err error) {
+	session, err := domain.NewUpgradedSession(account.ID, client)
+	if err != nil {
+		return "", fmt.Errorf("new session, %w", err)
+	} else if err := s.Sessions.Create(ctx, session); err != nil {
+		return "", fmt.Errorf("save session, %w", err)
+	}
+
+	return s.Renew(account, session.ID)
+}
+
+func (s *SessionTokenService) cancelDeletion(ctx context.Context, account *domain.Account) error {
+	if err := account.CancelDeletion(account.ID); err != nil {
+		return err
+	} else if err := s.Accounts.Update(ctx, account); err != nil {
+		return fmt.Errorf("save account, %w", err)
+	}
+
+	if err := s.Events.Publish(ctx, event.AccountDeletionCancelled{
+		AccountID: account.ID,
+	}); err != nil {
+		return fmt.Errorf("publish account deletion cancelled event, %w", err)
+	}
+	return nil
+}
+
+// Renew 重新构造会话凭证，延长有效期，但不刷新session salt
+func (s *SessionTokenService) Renew(account *domain.Account, sessionID uuid.UUID) (payload string, err error) {
+	key, err := s.Keys.Active()
+	if err != nil {
+		return "", fmt.Errorf("get signing key, %w", err)
+	}
+
 	token := newSessionToken(account)
-	return s.encode(token, account.SessionSalt), nil
+	token.KeyID = key.ID
+	token.SessionID = sessionID
+	return s.encode(token, account.SessionSalt)
 }
 
-// Suspend 使指定账号会话失效
+// Suspend 使指定账号的所有会话失效
 func (s *SessionTokenService) Suspend(ctx context.Context, account *domain.Account) error {
-	// 通过替换sesion salt达到会话失效的目的
+	// 通过替换sesion salt达到会话失效的目的，同时可以使没有会话记录的旧凭证失效
 	if err := account.RefreshSessionSalt(); err != nil {
 		return fmt.Errorf("refresh session token, %w", err)
 	} else if err := s.Accounts.Update(ctx, account); err != nil {
 		return fmt.Errorf("save account, %w", err)
+	} else if err := s.Sessions.DeleteByAccount(ctx, account.ID); err != nil {
+		return fmt.Errorf("delete sessions, %w", err)
 	}
 	return nil
 }
 
+// Revoke 撤销账号的指定会话
+func (s *SessionTokenService) Revoke(ctx context.Context, account *domain.Account, sessionID uuid.UUID) error {
+	session, err := s.Sessions.Find(ctx, sessionID)
+	if err != nil {
+		return err
+	} else if session.AccountID != account.ID {
+		return domain.ErrSessionNotFound
+	}
+
+	return s.Sessions.Delete(ctx, sessionID)
+}
+
+// RevokeOthers 撤销除当前会话之外的所有会话，返回当前会话的新凭证
+func (s *SessionTokenService) RevokeOthers(ctx context.Context, account *domain.Account, current uuid.UUID) (payload string, err error) {
+	// 同时替换session salt，使没有会话记录的旧凭证也失效
+	if err := account.RefreshSessionSalt(); err != nil {
+		return "", fmt.Errorf("refresh session token, %w", err)
+	} else if err := s.Accounts.Update(ctx, account); err != nil {
+		return "", fmt.Errorf("save account, %w", err)
+	}
+
+	var except []uuid.UUID
+	if current != uuid.Nil {
+		except = append(except, current)
+	}
+	if err := s.Sessions.DeleteByAccount(ctx, account.ID, except...); err != nil {
+		return "", fmt.Errorf("delete sessions, %w", err)
+	}
+
+	return s.Renew(account, current)
+}
+
 // Retrieve 恢复凭证内的信息
-func (s *SessionTokenService) Retrieve(ctx context.Context, payload string) (*domain.Account, SessionToken, error) {
+//
+// 凭证对应的会话记录被撤销后，凭证也随之失效
+func (s *SessionTokenService) Retrieve(ctx context.Context, payload string, client domain.ClientInfo) (*domain.Account, SessionToken, error) {
 	token, err := s.decode(payload)
 	if err != nil {
 		return nil, token, fmt.Errorf("decode token, %w", err)
@@ -63,22 +177,75 @@ func (s *SessionTokenService) Retrieve(ctx context.Context, payload string) (*do
 		return nil, token, err
 	}
 
-	if s.encode(token, account.SessionSalt) != payload {
-		return nil, token, errors.New("invalid token signature")
+	if err := s.verify(token, account.SessionSalt, payload); err != nil {
+		return nil, token, fmt.Errorf("invalid token signature, %w", err)
+	}
+
+	if token.SessionID != uuid.Nil {
+		session, err := s.Sessions.Find(ctx, token.SessionID)
+		if err != nil {
+			return nil, token, fmt.Errorf("find session, %w", err)
+		} else if session.AccountID != account.ID || session.Expired(time.Now()) {
+			return nil, token, domain.ErrSessionNotFound
+		}
+
+		if session.Touch(client) {
+			if err := s.Sessions.Update(ctx, session); err != nil {
+				return nil, token, fmt.Errorf("update session, %w", err)
+			}
+		}
+	}
+
+	// 旧格式或者使用已轮换密钥签名的凭证，需要尽快换成新凭证
+	if token.Version < tokenVersionHMAC {
+		token.outdated = true
+	} else if key, err := s.Keys.Active(); err == nil && key.ID != token.KeyID {
+		token.outdated = true
 	}
 	return account, token, nil
 }
 
 // 构造包含签名的token字符串
-func (s *SessionTokenService) encode(token SessionToken, salt string) string {
+//
+//	v2.<key id>.<account id>,<expire>[,<session id>].<signature>
+func (s *SessionTokenService) encode(token SessionToken, salt string) (string, error) {
+	key, err := s.Keys.Get(token.KeyID)
+	if err != nil {
+		return "", fmt.Errorf("get signing key, %w", err)
+	}
+
 	payload := fmt.Sprintf("%s,%d", token.AccountID, token.Expire)
-	signature := s.sign(payload, salt)
+	if token.SessionID != uuid.Nil {
+		payload = fmt.Sprintf("%s,%s", payload, token.SessionID)
+	}
+	signature := key.Sign([]byte(fmt.Sprintf("%s,%s", payload, salt)))
 
-	return fmt.Sprintf("%s;%s", payload, signature)
+	return fmt.Sprintf("%s%s.%s.%s",
+		tokenPrefixHMAC,
+		token.KeyID,
+		payload,
+		base64.RawURLEncoding.EncodeToString(signature),
+	), nil
 }
 
 func (s *SessionTokenService) decode(payload string) (SessionToken, error) {
-	payload, _, ok := strings.Cut(payload, ";")
+	var (
+		token = SessionToken{Version: tokenVersionLegacy}
+		ok    bool
+	)
+
+	if rest, found := strings.CutPrefix(payload, tokenPrefixHMAC); found {
+		token.Version = tokenVersionHMAC
+
+		token.KeyID, rest, ok = strings.Cut(rest, ".")
+		if !ok {
+			return SessionToken{}, domain.ErrInvalidSessionToken
+		}
+
+		payload, _, ok = strings.Cut(rest, ".")
+	} else {
+		payload, _, ok = strings.Cut(payload, ";")
+	}
 	if !ok {
 		return SessionToken{}, domain.ErrInvalidSessionToken
 	}
@@ -93,29 +260,82 @@ func (s *SessionTokenService) decode(payload string) (SessionToken, error) {
 		return SessionToken{}, fmt.Errorf("invalid account id, %w", err)
 	}
 
+	// 只有v2格式可能包含会话ID
+	expire, sessionID, hasSession := strings.Cut(expire, ",")
+	if hasSession {
+		if token.Version < tokenVersionHMAC {
+			return SessionToken{}, domain.ErrInvalidSessionToken
+		}
+
+		id, err := uuid.Parse(sessionID)
+		if err != nil {
+			return SessionToken{}, fmt.Errorf("invalid session id, %w", err)
+		}
+		token.SessionID = id
+	}
+
 	expireTime, err := strconv.Atoi(expire)
 	if err != nil {
 		return SessionToken{}, fmt.Errorf("invalid expire time, %w", err)
 	}
 
-	return SessionToken{AccountID: accountID, Expire: int64(expireTime)}, nil
+	token.AccountID = accountID
+	token.Expire = int64(expireTime)
+	return token, nil
 }
 
-// 计算数字签名
-func (s *SessionTokenService) sign(payload string, salt string) string {
+// verify 验证凭证签名
+func (s *SessionTokenService) verify(token SessionToken, salt, payload string) error {
+	if token.Version == tokenVersionLegacy {
+		if !legacyTokenAccepted(token, time.Now()) {
+			return errors.New("legacy token no longer accepted")
+		} else if !hmac.Equal([]byte(s.encodeLegacy(token, salt)), []byte(payload)) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+
+	expected, err := s.encode(token, salt)
+	if err != nil {
+		return err
+	} else if !hmac.Equal([]byte(expected), []byte(payload)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// legacyTokenAccepted 旧格式凭证只在迁移截止时间之前有效，
+// 过期时间晚于截止时间加凭证有效期的凭证不可能是截止之前下发的，视为伪造
+func legacyTokenAccepted(token SessionToken, now time.Time) bool {
+	until := domain.LegacySessionTokenUntil
+	return now.Before(until) && !token.ExpireTime().After(until.Add(tokenExpire))
+}
+
+// encodeLegacy 旧格式凭证，只用于验证迁移期间仍然有效的凭证
+func (s *SessionTokenService) encodeLegacy(token SessionToken, salt string) string {
+	payload := fmt.Sprintf("%s,%d", token.AccountID, token.Expire)
 	signature := fmt.Sprintf("%s,%s", payload, salt)
-	return fmt.Sprintf("%x", md5.Sum([]byte(signature)))
+
+	return fmt.Sprintf("%s;%x", payload, md5.Sum([]byte(signature)))
 }
 
 // SessionToken 会话凭证
 type SessionToken struct {
+	Version   int
+	KeyID     string // 签名密钥ID
 	AccountID uuid.UUID
 	Expire    int64 // 凭证过期时间
+	// 会话记录ID，旧凭证没有会话记录
+	SessionID uuid.UUID
+
+	// 凭证使用了旧格式或者已轮换的密钥
+	outdated bool
 }
 
 // newSessionToken 生成会话凭证
 func newSessionToken(account *domain.Account) SessionToken {
 	return SessionToken{
+		Version:   tokenVersionHMAC,
 		AccountID: account.ID,
 		Expire:    time.Now().Add(tokenExpire).Unix(),
 	}
@@ -139,7 +359,9 @@ func (token SessionToken) IsExpired() bool {
 
 // NeedRenew 是否需要延期
 func (token SessionToken) NeedRenew() bool {
-	if t := token.ExpireTime(); !t.IsZero() {
+	if token.outdated {
+		return true
+	} else if t := token.ExpireTime(); !t.IsZero() {
 		return t.Before(time.Now().Add(tokenRenew))
 	}
 	return false
@@ -1,23 +1,39 @@
 package service
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"ddd-example/internal/domain"
+	"ddd-example/pkg/keyring"
 
 	"github.com/google/uuid"
 )
 
 func TestSessionTokenService(t *testing.T) {
+	keys, err := keyring.Load(filepath.Join(t.TempDir(), "session.keys"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := keys.Active()
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	account := &domain.Account{
 		ID:          uuid.New(),
 		SessionSalt: "54381095jfepwoqrp2",
 	}
 	token := newSessionToken(account)
+	token.KeyID = active.ID
+	token.SessionID = uuid.New()
 
-	service := SessionTokenService{}
-	payload := service.encode(token, account.SessionSalt)
-	if payload == "" {
+	service := SessionTokenService{Keys: keys}
+	payload, err := service.encode(token, account.SessionSalt)
+	if err != nil {
+		t.Fatal(err)
+	} else if payload == "" {
 		t.Fatal("payload should not be empty")
 	}
 
@@ -26,9 +42,57 @@ func TestSessionTokenService(t *testing.T) {
 		t.Fatal(err)
 	} else if decoded != token {
 		t.Fatalf("decoded token should be equal to token")
-	} else if v := service.encode(decoded, account.SessionSalt); v != payload {
-		t.Fatalf("encoded token should be equal to payload")
-	} else if v := service.encode(decoded, "abcfaof"); v == payload {
-		t.Fatalf("encoded token should not be equal to payload")
+	} else if err := service.verify(decoded, account.SessionSalt, payload); err != nil {
+		t.Fatalf("verify token, %v", err)
+	} else if err := service.verify(decoded, "abcfaof", payload); err == nil {
+		t.Fatalf("verify should fail with different salt")
+	}
+
+	// 密钥轮换之后，旧密钥签名的凭证仍然有效
+	if _, err := keys.Rotate(); err != nil {
+		t.Fatal(err)
+	} else if err := service.verify(decoded, account.SessionSalt, payload); err != nil {
+		t.Fatalf("verify token signed by retired key, %v", err)
+	}
+}
+
+func TestLegacySessionToken(t *testing.T) {
+	account := &domain.Account{
+		ID:          uuid.New(),
+		SessionSalt: "54381095jfepwoqrp2",
+	}
+	token := newSessionToken(account)
+	token.Version = tokenVersionLegacy
+
+	defer func(v time.Time) { domain.LegacySessionTokenUntil = v }(domain.LegacySessionTokenUntil)
+	domain.LegacySessionTokenUntil = time.Now().Add(time.Hour)
+
+	service := SessionTokenService{}
+	payload := service.encodeLegacy(token, account.SessionSalt)
+
+	decoded, err := service.decode(payload)
+	if err != nil {
+		t.Fatal(err)
+	} else if decoded != token {
+		t.Fatalf("decoded token should be equal to token")
+	} else if err := service.verify(decoded, account.SessionSalt, payload); err != nil {
+		t.Fatalf("verify legacy token, %v", err)
+	} else if err := service.verify(decoded, "abcfaof", payload); err == nil {
+		t.Fatalf("verify should fail with different salt")
+	}
+
+	// 截止时间之后不再接受旧格式凭证
+	domain.LegacySessionTokenUntil = time.Now().Add(-time.Second)
+	if err := service.verify(decoded, account.SessionSalt, payload); err == nil {
+		t.Fatal("legacy token should be rejected after cutoff")
+	}
+
+	// 截止时间之前，过期时间太晚的凭证不可能是截止之前下发的
+	domain.LegacySessionTokenUntil = time.Now().Add(time.Hour)
+	forged := token
+	forged.Expire = time.Now().Add(time.Hour + tokenExpire + time.Minute).Unix()
+	payload = service.encodeLegacy(forged, account.SessionSalt)
+	if err := service.verify(forged, account.SessionSalt, payload); err == nil {
+		t.Fatal("legacy token expiring after cutoff should be rejected")
 	}
 }
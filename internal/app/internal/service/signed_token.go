@@ -0,0 +1,78 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/keyring"
+)
+
+// SignedTokenService 使用服务器密钥环签名的无状态凭证，用于邮件链接等场景
+//
+// 凭证格式为 <base64(purpose|expire|claims...)>.<key id>.<base64(signature)>
+// purpose用于区分不同用途，避免一种用途的凭证被用在其它地方
+type SignedTokenService struct {
+	Keys *keyring.KeyRing `do:""`
+}
+
+// Sign 构造凭证
+func (s *SignedTokenService) Sign(purpose string, ttl time.Duration, claims ...string) (string, error) {
+	fields := append([]string{purpose, strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)}, claims...)
+	for _, v := range fields {
+		if strings.Contains(v, "|") {
+			return "", fmt.Errorf("invalid claim %q", v)
+		}
+	}
+
+	payload := []byte(strings.Join(fields, "|"))
+	keyID, signature, err := s.Keys.Sign(payload)
+	if err != nil {
+		return "", fmt.Errorf("sign token, %w", err)
+	}
+
+	return fmt.Sprintf("%s.%s.%s",
+		base64.RawURLEncoding.EncodeToString(payload),
+		keyID,
+		base64.RawURLEncoding.EncodeToString(signature),
+	), nil
+}
+
+// Verify 验证凭证并返回凭证内的数据
+func (s *SignedTokenService) Verify(purpose, token string) ([]string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, domain.ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	if err := s.Keys.Verify(parts[1], payload, signature); err != nil {
+		return nil, errors.Join(domain.ErrInvalidToken, err)
+	}
+
+	fields := strings.Split(string(payload), "|")
+	if len(fields) < 2 || fields[0] != purpose {
+		return nil, domain.ErrInvalidToken
+	}
+
+	expire, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	} else if time.Unix(expire, 0).Before(time.Now()) {
+		return nil, domain.ErrTokenExpired
+	}
+
+	return fields[2:], nil
+}
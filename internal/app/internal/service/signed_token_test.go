@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/keyring"
+)
+
+func TestSignedTokenService(t *testing.T) {
+	keys, err := keyring.Load(filepath.Join(t.TempDir(), "session.keys"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := SignedTokenService{Keys: keys}
+
+	token, err := service.Sign("test", time.Minute, "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims, err := service.Verify("test", token); err != nil {
+		t.Fatal(err)
+	} else if !slices.Equal(claims, []string{"foo", "bar"}) {
+		t.Fatalf("unexpected claims %v", claims)
+	}
+
+	if _, err := service.Verify("other", token); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected domain.ErrInvalidToken for other purpose, got %v", err)
+	} else if _, err := service.Verify("test", token+"x"); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Fatalf("expected domain.ErrInvalidToken for tampered token, got %v", err)
+	}
+
+	expired, err := service.Sign("test", -time.Minute, "foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := service.Verify("test", expired); !errors.Is(err, domain.ErrTokenExpired) {
+		t.Fatalf("expected domain.ErrTokenExpired, got %v", err)
+	}
+}
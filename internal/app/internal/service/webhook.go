@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/logger"
+)
+
+// webhook投递策略
+const (
+	webhookBatchSize = 50
+	webhookLease     = 2 * time.Minute
+)
+
+// WebhookService webhook投递
+type WebhookService struct {
+	Webhooks   adapter.WebhookRepository         `do:""`
+	Deliveries adapter.WebhookDeliveryRepository `do:""`
+	Sender     adapter.WebhookSender             `do:""`
+}
+
+// Enqueue 为订阅了事件的webhook生成投递记录，返回生成的数量
+//
+// 内部事件不投递，订阅所有事件的webhook同样收不到
+func (s *WebhookService) Enqueue(ctx context.Context, ev any) (int, error) {
+	envelope, err := event.NewEnvelope(ev)
+	if err != nil {
+		return 0, err
+	} else if !event.IsPublic(envelope.Type) {
+		return 0, nil
+	}
+
+	hooks, err := s.Webhooks.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list webhooks, %w", err)
+	}
+
+	var payload []byte
+	var n int
+	for _, hook := range hooks {
+		if !hook.Subscribed(envelope.Type) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(envelope); err != nil {
+				return n, fmt.Errorf("encode event envelope, %w", err)
+			}
+		}
+
+		delivery, err := domain.NewWebhookDelivery(hook, envelope.ID, envelope.Type, payload)
+		if err != nil {
+			return n, err
+		} else if err := s.Deliveries.Create(ctx, delivery); err != nil {
+			return n, fmt.Errorf("save webhook delivery, %w", err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Deliver 投递一批到期的记录，返回处理的数量
+func (s *WebhookService) Deliver(ctx context.Context) (int, error) {
+	deliveries, err := s.Deliveries.Claim(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, fmt.Errorf("claim webhook deliveries, %w", err)
+	}
+
+	for i, delivery := range deliveries {
+		delivery.Record(s.send(ctx, delivery))
+		if delivery.Status == domain.WebhookDead {
+			logger.Warn(ctx, "webhook delivery dead",
+				"delivery", delivery.ID,
+				"webhook", delivery.WebhookID,
+				"event", delivery.EventID,
+			)
+		}
+
+		if err := s.Deliveries.Update(ctx, delivery); err != nil {
+			// 没有保存成功的记录会在领取期过后再次投递
+			return i, fmt.Errorf("save webhook delivery, %s, %w", delivery.ID, err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery *domain.WebhookDelivery) domain.WebhookAttempt {
+	attempt := domain.WebhookAttempt{At: time.Now()}
+
+	hook, err := s.Webhooks.Find(ctx, delivery.WebhookID)
+	if err != nil {
+		attempt.Error = fmt.Sprintf("find webhook, %v", err)
+		return attempt
+	} else if !hook.Enabled {
+		attempt.Error = "webhook disabled"
+		return attempt
+	}
+
+	attempt.StatusCode, err = s.Sender.Send(ctx, hook, delivery)
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
@@ -1,23 +1,35 @@
 package domain
 
 import (
-	"crypto/md5"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // Account 系统账号
 type Account struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	Password     string    `json:"-"`
-	PasswordSalt string    `json:"-"`
-	SessionSalt  string    `json:"-"`
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Status        string    `json:"status"`
+	Password      string    `json:"-"`
+	PasswordSalt  string    `json:"-"` // 只有旧版本的md5密码使用
+	SessionSalt   string    `json:"-"`
+	TOTP          TOTP      `json:"-"`
+	// 修改email之后撤销凭证绑定的随机数，撤销或者再次修改email之后旧的撤销凭证失效
+	EmailRevertNonce string `json:"-"`
+	// 首选语言，BCP 47格式，例如zh-cn、en，用于本地化邮件等通知
+	Language string `json:"language,omitempty"`
+	// 分配的角色名称，有序
+	Roles []string `json:"roles,omitempty"`
+	// 最近一次状态变更
+	StatusChange *AccountStatusChange `json:"status_change,omitempty"`
+	CreateAt     time.Time            `json:"create_at"`
 }
 
 // SetPassword 设置密码
@@ -27,11 +39,13 @@ func (a *Account) SetPassword(password string) error {
 		return errors.New("empty password")
 	}
 
-	if err := a.refreshPasswordSalt(); err != nil {
-		return fmt.Errorf("refresh password salt, %w", err)
+	encoded, err := hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("hash password, %w", err)
 	}
 
-	a.Password = newPassword(password, a.PasswordSalt)
+	a.Password = encoded
+	a.PasswordSalt = ""
 	return nil
 }
 
@@ -44,15 +58,47 @@ func (a *Account) SetEmail(email string) error {
 	if !emailRegexp.Match([]byte(email)) {
 		return errors.New("invalid email")
 	}
+
+	// 更换email之后需要重新验证
+	if a.Email != email {
+		a.EmailVerified = false
+	}
 	a.Email = email
 
 	return nil
 }
 
+var languageRegexp = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{1,8})*$`)
+
+// SetLanguage 设置首选语言，格式不正确时清空，使用系统默认语言
+func (a *Account) SetLanguage(language string) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if !languageRegexp.MatchString(language) {
+		language = ""
+	}
+	a.Language = language
+}
+
+// VerifyEmail 标记email已通过验证
+func (a *Account) VerifyEmail() {
+	a.EmailVerified = true
+}
+
 // ComparePassword 验证密码是否一致
 func (a *Account) ComparePassword(password string) bool {
 	return password != "" &&
-		a.Password == newPassword(password, a.PasswordSalt)
+		a.Password != "" &&
+		verifyPassword(a.Password, a.PasswordSalt, password)
+}
+
+// HasPassword 是否可以使用密码登录，三方账号注册的账号没有密码
+func (a *Account) HasPassword() bool {
+	return a.Password != ""
+}
+
+// PasswordNeedsRehash 密码哈希是否使用了旧算法或者弱于当前配置的参数
+func (a *Account) PasswordNeedsRehash() bool {
+	return a.Password != "" && passwordNeedsRehash(a.Password)
 }
 
 // RefreshSessionSalt 更新会话签名盐，更新后同一账号的其它会话会自动失效
@@ -65,15 +111,6 @@ func (a *Account) RefreshSessionSalt() error {
 	return nil
 }
 
-func (a *Account) refreshPasswordSalt() error {
-	s, err := newSalt(8)
-	if err != nil {
-		return err
-	}
-	a.PasswordSalt = s
-	return nil
-}
-
 func newSalt(length int) (string, error) {
 	data := make([]byte, length)
 	if _, err := rand.Read(data); err != nil {
@@ -82,11 +119,6 @@ func newSalt(length int) (string, error) {
 	return fmt.Sprintf("%x", data), nil
 }
 
-func newPassword(password string, salt string) string {
-	data := append([]byte(password), []byte(salt)...)
-	return fmt.Sprintf("%x", md5.Sum(data))
-}
-
 // NormalizeEmail 规范化email输入
 func NormalizeEmail(email string) string {
 	return strings.TrimSpace(strings.ToLower(email))
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 账号状态
+const (
+	// AccountActive 正常
+	AccountActive = "active"
+	// AccountDisabled 被管理员停用
+	AccountDisabled = "disabled"
+	// AccountLocked 因安全原因锁定，例如疑似被盗，需要管理员解锁
+	AccountLocked = "locked"
+	// AccountPendingDeletion 申请注销，宽限期内可以撤销
+	AccountPendingDeletion = "pending_deletion"
+	// AccountDeleted 已注销，不能再恢复
+	AccountDeleted = "deleted"
+)
+
+// accountTransitions 允许的状态变更
+var accountTransitions = map[string][]string{
+	AccountActive:          {AccountDisabled, AccountLocked, AccountPendingDeletion, AccountDeleted},
+	AccountDisabled:        {AccountActive, AccountDeleted},
+	AccountLocked:          {AccountActive, AccountDisabled, AccountDeleted},
+	AccountPendingDeletion: {AccountActive, AccountDeleted},
+}
+
+var (
+	// AccountDeletionGrace 申请注销之后的宽限期，宽限期内重新登录会撤销注销申请
+	AccountDeletionGrace = 14 * 24 * time.Hour
+	// DeletedEmailRetention 注销的账号保留email的时长，过期之后email才可以重新注册
+	DeletedEmailRetention = 30 * 24 * time.Hour
+)
+
+// AccountStatusChange 状态变更记录
+type AccountStatusChange struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+	// 操作者，账号本人或者管理员，系统自动执行时为空
+	ActorID uuid.UUID `json:"actor_id"`
+	At      time.Time `json:"at"`
+}
+
+// status 当前状态，没有设置状态的新账号视为正常
+func (a *Account) status() string {
+	return cmp.Or(a.Status, AccountActive)
+}
+
+// IsActive 是否可以正常登录
+func (a *Account) IsActive() bool {
+	return a.status() == AccountActive
+}
+
+// CheckActive 检查账号是否可以正常登录，不同的状态返回不同的错误
+func (a *Account) CheckActive() error {
+	switch a.status() {
+	case AccountActive:
+		return nil
+	case AccountDisabled:
+		return ErrAccountDisabled
+	case AccountLocked:
+		return ErrAccountLocked
+	case AccountPendingDeletion:
+		return ErrAccountPendingDeletion
+	default:
+		return ErrAccountDeleted
+	}
+}
+
+// CheckLogin 检查账号是否可以登录
+//
+// 与CheckActive的区别是，宽限期内申请注销的账号也可以登录，登录之后撤销注销申请
+func (a *Account) CheckLogin(now time.Time) error {
+	if a.status() == AccountPendingDeletion && !a.DeletionDue(now) {
+		return nil
+	}
+	return a.CheckActive()
+}
+
+// DeletionDue 申请注销的宽限期是否已经结束，结束之后账号会被清除
+func (a *Account) DeletionDue(now time.Time) bool {
+	if a.status() != AccountPendingDeletion {
+		return false
+	} else if v := a.StatusChange; v != nil {
+		return !now.Before(v.At.Add(AccountDeletionGrace))
+	}
+	return true
+}
+
+// Disable 停用账号
+func (a *Account) Disable(reason string, actor uuid.UUID) error {
+	return a.transit(AccountDisabled, reason, actor)
+}
+
+// Enable 恢复停用的账号
+func (a *Account) Enable(actor uuid.UUID) error {
+	return a.restore(AccountDisabled, "", actor)
+}
+
+// Lock 因安全原因锁定账号
+func (a *Account) Lock(reason string, actor uuid.UUID) error {
+	return a.transit(AccountLocked, reason, actor)
+}
+
+// Unlock 解锁账号
+func (a *Account) Unlock(actor uuid.UUID) error {
+	return a.restore(AccountLocked, "", actor)
+}
+
+// RequestDeletion 申请注销
+func (a *Account) RequestDeletion(reason string, actor uuid.UUID) error {
+	return a.transit(AccountPendingDeletion, reason, actor)
+}
+
+// CancelDeletion 撤销注销申请
+func (a *Account) CancelDeletion(actor uuid.UUID) error {
+	return a.restore(AccountPendingDeletion, "", actor)
+}
+
+// MarkDeleted 注销账号，注销之后不能再恢复
+//
+// 同时清除密码、两步验证、角色等个人数据，只保留email直到保留期结束
+func (a *Account) MarkDeleted(reason string, actor uuid.UUID) error {
+	if err := a.transit(AccountDeleted, reason, actor); err != nil {
+		return err
+	}
+
+	a.Password = ""
+	a.PasswordSalt = ""
+	a.TOTP = TOTP{}
+	a.Language = ""
+	a.Roles = nil
+	return nil
+}
+
+// EmailRetained 注销的账号是否还占用email，保留期过后email可以被重新注册
+func (a *Account) EmailRetained(now time.Time) bool {
+	if a.status() != AccountDeleted {
+		return true
+	} else if v := a.StatusChange; v != nil {
+		return now.Before(v.At.Add(DeletedEmailRetention))
+	}
+	return false
+}
+
+// ReleaseEmail 释放注销账号占用的email，替换为不会被注册的地址，保留期内返回false
+func (a *Account) ReleaseEmail(now time.Time) bool {
+	if a.EmailRetained(now) {
+		return false
+	}
+	a.Email = fmt.Sprintf("%s@deleted.invalid", a.ID)
+	a.EmailVerified = false
+	return true
+}
+
+// restore 从指定状态恢复正常
+func (a *Account) restore(from, reason string, actor uuid.UUID) error {
+	if v := a.status(); v != from {
+		return fmt.Errorf("%w, %s to %s", ErrInvalidStatusTransition, v, AccountActive)
+	}
+	return a.transit(AccountActive, reason, actor)
+}
+
+func (a *Account) transit(to, reason string, actor uuid.UUID) error {
+	from := a.status()
+	if !slices.Contains(accountTransitions[from], to) {
+		return fmt.Errorf("%w, %s to %s", ErrInvalidStatusTransition, from, to)
+	}
+
+	a.Status = to
+	a.StatusChange = &AccountStatusChange{
+		From:    from,
+		To:      to,
+		Reason:  reason,
+		ActorID: actor,
+		At:      time.Now(),
+	}
+	return nil
+}
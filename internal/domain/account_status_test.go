@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAccountStatus(t *testing.T) {
+	actor := uuid.New()
+	a := &Account{}
+
+	if err := a.CheckActive(); err != nil {
+		t.Fatalf("new account should be active, %v", err)
+	} else if err := a.Enable(actor); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("enable active account, expected %v, got %v", ErrInvalidStatusTransition, err)
+	}
+
+	if err := a.Disable("spam", actor); err != nil {
+		t.Fatal(err)
+	} else if err := a.CheckActive(); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("expected %v, got %v", ErrAccountDisabled, err)
+	} else if v := a.StatusChange; v.From != AccountActive || v.Reason != "spam" || v.ActorID != actor {
+		t.Fatalf("unexpected status change %+v", v)
+	} else if err := a.Unlock(actor); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatal("unlock disabled account should fail")
+	} else if err := a.Enable(actor); err != nil || !a.IsActive() {
+		t.Fatalf("enable account, %v", err)
+	}
+
+	if err := a.Lock("compromised", uuid.Nil); err != nil {
+		t.Fatal(err)
+	} else if err := a.CheckActive(); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("expected %v, got %v", ErrAccountLocked, err)
+	} else if err := a.RequestDeletion("", actor); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatal("locked account should not request deletion")
+	} else if err := a.Unlock(actor); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.RequestDeletion("", actor); err != nil {
+		t.Fatal(err)
+	} else if err := a.CheckActive(); !errors.Is(err, ErrAccountPendingDeletion) {
+		t.Fatalf("expected %v, got %v", ErrAccountPendingDeletion, err)
+	} else if err := a.MarkDeleted("", uuid.Nil); err != nil {
+		t.Fatal(err)
+	} else if err := a.CheckLogin(time.Now()); !errors.Is(err, ErrAccountDeleted) {
+		t.Fatalf("expected %v, got %v", ErrAccountDeleted, err)
+	} else if err := a.CancelDeletion(actor); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatal("deleted account should not be restored")
+	}
+}
+
+func TestAccountDeletionGrace(t *testing.T) {
+	a := &Account{Roles: []string{"support"}}
+	if err := a.SetPassword("12345678"); err != nil {
+		t.Fatal(err)
+	} else if err := a.RequestDeletion("", a.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if err := a.CheckLogin(now); err != nil {
+		t.Fatalf("login within grace period, %v", err)
+	} else if a.DeletionDue(now) {
+		t.Fatal("deletion should not be due within grace period")
+	}
+
+	due := now.Add(AccountDeletionGrace)
+	if !a.DeletionDue(due) {
+		t.Fatal("deletion should be due after grace period")
+	} else if err := a.CheckLogin(due); !errors.Is(err, ErrAccountPendingDeletion) {
+		t.Fatalf("expected %v, got %v", ErrAccountPendingDeletion, err)
+	}
+
+	if err := a.MarkDeleted("", uuid.Nil); err != nil {
+		t.Fatal(err)
+	} else if a.HasPassword() || len(a.Roles) > 0 {
+		t.Fatal("deleted account should not keep credentials")
+	} else if a.DeletionDue(due) {
+		t.Fatal("deleted account should not be due again")
+	}
+}
+
+func TestAccountReleaseEmail(t *testing.T) {
+	a := &Account{ID: uuid.New(), Email: "test@example.com"}
+
+	if a.ReleaseEmail(time.Now()) {
+		t.Fatal("active account should not release email")
+	} else if err := a.MarkDeleted("", uuid.Nil); err != nil {
+		t.Fatal(err)
+	} else if a.ReleaseEmail(time.Now()) {
+		t.Fatal("email should be retained within retention")
+	}
+
+	if !a.ReleaseEmail(time.Now().Add(DeletedEmailRetention)) {
+		t.Fatal("email should be released after retention")
+	} else if a.Email == "test@example.com" {
+		t.Fatal("email not replaced")
+	}
+}
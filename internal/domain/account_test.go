@@ -15,3 +15,28 @@ func TestAccount(t *testing.T) {
 		t.Fatal("compare password should be false")
 	}
 }
+
+func TestAccountLegacyPassword(t *testing.T) {
+	a := &Account{
+		PasswordSalt: "0123456789abcdef",
+	}
+	a.Password = legacyPassword("abcdefg", a.PasswordSalt)
+
+	if !a.ComparePassword("abcdefg") {
+		t.Fatal("legacy password should be verified")
+	} else if a.ComparePassword("abcdef") {
+		t.Fatal("compare password should be false")
+	} else if !a.PasswordNeedsRehash() {
+		t.Fatal("legacy password should be rehashed")
+	}
+
+	if err := a.SetPassword("abcdefg"); err != nil {
+		t.Fatal(err)
+	} else if a.PasswordSalt != "" {
+		t.Fatal("password salt should be cleared")
+	} else if a.PasswordNeedsRehash() {
+		t.Fatal("new password should not be rehashed")
+	} else if !a.ComparePassword("abcdefg") {
+		t.Fatal("compare password should be true")
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 个人数据导出状态
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	// DataExportFailed 超过最大尝试次数，需要重新申请
+	DataExportFailed = "failed"
+)
+
+const (
+	// 生成导出文件的最大尝试次数
+	dataExportMaxAttempts = 3
+	// 第一次重试的间隔，之后每次翻倍
+	dataExportBackoff = time.Minute
+)
+
+// DataExportRetention 导出文件的保留时长，也是下载链接的有效期
+var DataExportRetention = 7 * 24 * time.Hour
+
+// DataExport 个人数据导出，由后台任务生成压缩包
+type DataExport struct {
+	ID        uuid.UUID `json:"id"`
+	AccountID uuid.UUID `json:"-"`
+	Status    string    `json:"status"`
+	// 文件存储中的文件名
+	File          string    `json:"-"`
+	Size          int64     `json:"size,omitempty"`
+	Attempts      int       `json:"-"`
+	LastError     string    `json:"-"`
+	NextAttemptAt time.Time `json:"-"`
+	// 生成完毕或者失败之后开始计算，过期之后删除文件
+	ExpireAt time.Time `json:"expire_at,omitzero"`
+	CreateAt time.Time `json:"create_at"`
+}
+
+// NewDataExport 构造等待生成的导出
+func NewDataExport(accountID uuid.UUID) (*DataExport, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("create id, %w", err)
+	}
+
+	now := time.Now()
+	return &DataExport{
+		ID:            id,
+		AccountID:     accountID,
+		Status:        DataExportPending,
+		File:          fmt.Sprintf("exports/%s.zip", id),
+		NextAttemptAt: now,
+		CreateAt:      now,
+	}, nil
+}
+
+// Complete 导出文件已生成
+func (e *DataExport) Complete(size int64, now time.Time) {
+	e.Status = DataExportReady
+	e.Size = size
+	e.LastError = ""
+	e.ExpireAt = now.Add(DataExportRetention)
+}
+
+// Fail 记录失败原因，按指数退避安排重试，超过最大次数后进入failed状态
+func (e *DataExport) Fail(err error, now time.Time) {
+	e.Attempts++
+	e.LastError = err.Error()
+
+	if e.Attempts >= dataExportMaxAttempts {
+		e.Status = DataExportFailed
+		e.ExpireAt = now.Add(DataExportRetention)
+		return
+	}
+	e.NextAttemptAt = now.Add(dataExportBackoff << (e.Attempts - 1))
+}
+
+// Downloadable 导出文件是否可以下载
+func (e *DataExport) Downloadable(now time.Time) bool {
+	return e.Status == DataExportReady && now.Before(e.ExpireAt)
+}
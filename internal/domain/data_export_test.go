@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDataExport(t *testing.T) {
+	now := time.Now()
+
+	e, err := NewDataExport(uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	} else if e.Downloadable(now) {
+		t.Fatal("pending export should not be downloadable")
+	}
+
+	e.Fail(errors.New("disk full"), now)
+	if e.Status != DataExportPending || !e.NextAttemptAt.After(now) {
+		t.Fatalf("first failure should retry later, got %+v", e)
+	}
+
+	e.Complete(1024, now)
+	if !e.Downloadable(now) {
+		t.Fatal("ready export should be downloadable")
+	} else if e.Downloadable(now.Add(DataExportRetention)) {
+		t.Fatal("expired export should not be downloadable")
+	}
+
+	failed, _ := NewDataExport(uuid.New())
+	for range dataExportMaxAttempts {
+		failed.Fail(errors.New("disk full"), now)
+	}
+	if failed.Status != DataExportFailed || failed.ExpireAt.IsZero() {
+		t.Fatalf("export should fail after max attempts, got %+v", failed)
+	}
+}
@@ -5,6 +5,18 @@ import "errors"
 var (
 	// ErrAccountNotFound 账号不存在
 	ErrAccountNotFound = errors.New("account not found")
+	// ErrAccountDisabled 账号已停用
+	ErrAccountDisabled = errors.New("account disabled")
+	// ErrAccountLocked 账号因安全原因被锁定
+	ErrAccountLocked = errors.New("account locked")
+	// ErrAccountPendingDeletion 账号已申请注销
+	ErrAccountPendingDeletion = errors.New("account pending deletion")
+	// ErrAccountDeleted 账号已注销
+	ErrAccountDeleted = errors.New("account deleted")
+	// ErrInvalidStatusTransition 账号当前状态不允许这个操作
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	// ErrReauthRequired 敏感操作需要重新验证身份
+	ErrReauthRequired = errors.New("reauthentication required")
 	// ErrWrongPassword 密码错误
 	ErrWrongPassword = errors.New("wrong password")
 	// ErrEmailRegistered email已注册
@@ -13,8 +25,58 @@ var (
 	ErrInvalidSessionToken = errors.New("invalid session token")
 	// ErrSessionTokenExpired 会话凭证已过期
 	ErrSessionTokenExpired = errors.New("session token expired")
+	// ErrSessionNotFound 会话不存在或者已被撤销
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrEmailAlreadyVerified email已经验证过
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+	// ErrEmailNotVerified email未验证
+	ErrEmailNotVerified = errors.New("email not verified")
+	// ErrInvalidToken 无效的凭证
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired 凭证已过期
+	ErrTokenExpired = errors.New("token expired")
+	// ErrMFAAlreadyEnabled 已经开启了两步验证
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	// ErrMFANotEnabled 没有开启两步验证
+	ErrMFANotEnabled = errors.New("mfa not enabled")
+	// ErrMFANotEnrolled 没有生成待确认的两步验证密钥
+	ErrMFANotEnrolled = errors.New("mfa not enrolled")
+	// ErrWrongMFACode 两步验证码错误
+	ErrWrongMFACode = errors.New("wrong mfa code")
 	// ErrMissingCache 缓存不存在
 	ErrMissingCache = errors.New("missing cache")
 	// ErrInvalidOauthToken 无效的三方验证信息缓存凭证
 	ErrInvalidOauthToken = errors.New("invalid oauth token")
+	// ErrOauthBoundToOther 三方账号已经绑定了其它账号
+	ErrOauthBoundToOther = errors.New("oauth identity bound to another account")
+	// ErrOauthNotBound 没有绑定指定的三方账号
+	ErrOauthNotBound = errors.New("oauth identity not bound")
+	// ErrLastLoginMethod 不能移除账号唯一的登录方式
+	ErrLastLoginMethod = errors.New("last login method")
+	// ErrInvalidOauthState 三方登录回调的state缺失、已使用或者不匹配
+	ErrInvalidOauthState = errors.New("invalid oauth state")
+	// ErrLoginLocked 登录失败次数过多，暂时不允许登录，具体的解锁时间见LoginLockedError
+	ErrLoginLocked = errors.New("login locked")
+	// ErrInvalidWebhookURL webhook地址无效
+	ErrInvalidWebhookURL = errors.New("invalid webhook url")
+	// ErrUnknownEventType 未知或者不能订阅的事件类型
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrWebhookNotFound webhook不存在
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookDeliveryNotFound webhook投递记录不存在
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrWebhookDeliveryPending webhook正在等待投递
+	ErrWebhookDeliveryPending = errors.New("webhook delivery is pending")
+	// ErrDataExportNotFound 数据导出不存在、还没有生成完毕或者已过期
+	ErrDataExportNotFound = errors.New("data export not found")
+	// ErrDataExportInProgress 已经有正在生成的数据导出
+	ErrDataExportInProgress = errors.New("data export in progress")
+	// ErrRoleNotFound 角色不存在
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrPermissionDenied 没有操作权限
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrLastSuperAdmin 不能撤销最后一个超级管理员
+	ErrLastSuperAdmin = errors.New("last super admin")
+	// ErrSuperAdminExists 已经存在超级管理员，不能再次初始化
+	ErrSuperAdminExists = errors.New("super admin already exists")
 )
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginGuardPolicy 登录失败限制策略
+//
+// 连续失败超过FreeAttempts次之后，每次失败都需要等待一段时间才能再次尝试，
+// 等待时间逐次翻倍；失败达到LockoutAttempts次之后锁定，每次锁定的时长也逐次翻倍
+type LoginGuardPolicy struct {
+	// 不限制的失败次数
+	FreeAttempts int
+	// 第一次延迟时长
+	Delay    time.Duration
+	MaxDelay time.Duration
+	// 达到这个失败次数之后锁定
+	LockoutAttempts int
+	// 第一次锁定时长
+	Lockout    time.Duration
+	MaxLockout time.Duration
+	// 最后一次失败之后，失败记录保留的时长
+	Window time.Duration
+}
+
+var (
+	// AccountLoginPolicy 按账号限制
+	AccountLoginPolicy = LoginGuardPolicy{
+		FreeAttempts:    3,
+		Delay:           time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutAttempts: 10,
+		Lockout:         15 * time.Minute,
+		MaxLockout:      24 * time.Hour,
+		Window:          24 * time.Hour,
+	}
+
+	// IPLoginPolicy 按客户端IP限制，同一个出口IP后面可能有很多用户，限制比账号宽松
+	IPLoginPolicy = LoginGuardPolicy{
+		FreeAttempts:    10,
+		Delay:           time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutAttempts: 50,
+		Lockout:         15 * time.Minute,
+		MaxLockout:      24 * time.Hour,
+		Window:          24 * time.Hour,
+	}
+)
+
+// LoginAttempts 登录失败记录
+type LoginAttempts struct {
+	// 上次锁定之后的连续失败次数
+	Failures int `json:"failures"`
+	// 已经锁定过的次数
+	Lockouts    int       `json:"lockouts"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Check 检查是否允许尝试登录
+func (a *LoginAttempts) Check(now time.Time) error {
+	if now.Before(a.LockedUntil) {
+		return &LoginLockedError{Until: a.LockedUntil}
+	}
+	return nil
+}
+
+// Fail 记录一次失败，返回是否因此被锁定
+func (a *LoginAttempts) Fail(now time.Time, policy LoginGuardPolicy) (locked bool) {
+	a.Failures++
+
+	if a.Failures >= policy.LockoutAttempts {
+		a.Lockouts++
+		a.Failures = 0
+		a.LockedUntil = now.Add(backoff(policy.Lockout, a.Lockouts-1, policy.MaxLockout))
+		return true
+	}
+
+	if n := a.Failures - policy.FreeAttempts; n > 0 {
+		a.LockedUntil = now.Add(backoff(policy.Delay, n-1, policy.MaxDelay))
+	}
+	return false
+}
+
+// backoff base * 2^n，不超过limit
+func backoff(base time.Duration, n int, limit time.Duration) time.Duration {
+	d := base << min(n, 30)
+	if d <= 0 || d > limit {
+		return limit
+	}
+	return d
+}
+
+// LoginLockedError 登录失败次数过多，Until之前不允许再次尝试
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLoginLocked, e.Until.Format(time.RFC3339))
+}
+
+// Is 可以使用errors.Is(err, ErrLoginLocked)判断
+func (e *LoginLockedError) Is(target error) bool {
+	return target == ErrLoginLocked
+}
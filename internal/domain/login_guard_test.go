@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoginAttempts(t *testing.T) {
+	policy := LoginGuardPolicy{
+		FreeAttempts:    2,
+		Delay:           time.Second,
+		MaxDelay:        3 * time.Second,
+		LockoutAttempts: 5,
+		Lockout:         time.Minute,
+		MaxLockout:      90 * time.Second,
+		Window:          time.Hour,
+	}
+
+	now := time.Now()
+	a := &LoginAttempts{}
+
+	// 前两次失败不限制
+	for range 2 {
+		if a.Fail(now, policy) {
+			t.Fatal("should not lock")
+		} else if err := a.Check(now); err != nil {
+			t.Fatalf("free attempts should not delay, %v", err)
+		}
+	}
+
+	// 之后每次失败的等待时间翻倍，不超过MaxDelay
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second} {
+		if a.Fail(now, policy) {
+			t.Fatal("should not lock")
+		}
+
+		var locked *LoginLockedError
+		if err := a.Check(now); !errors.As(err, &locked) || !errors.Is(err, ErrLoginLocked) {
+			t.Fatalf("expected login locked error, got %v", err)
+		} else if d := locked.Until.Sub(now); d != delay {
+			t.Fatalf("expected delay %v, got %v", delay, d)
+		} else if err := a.Check(locked.Until); err != nil {
+			t.Fatalf("should allow after delay, %v", err)
+		}
+	}
+
+	// 达到锁定次数
+	if !a.Fail(now, policy) {
+		t.Fatal("should lock")
+	} else if d := a.LockedUntil.Sub(now); d != time.Minute {
+		t.Fatalf("expected lockout %v, got %v", time.Minute, d)
+	} else if a.Failures != 0 {
+		t.Fatal("failures should reset after lockout")
+	}
+
+	// 再次锁定时长翻倍，不超过MaxLockout
+	for range policy.LockoutAttempts - 1 {
+		a.Fail(now, policy)
+	}
+	if !a.Fail(now, policy) {
+		t.Fatal("should lock again")
+	} else if d := a.LockedUntil.Sub(now); d != 90*time.Second {
+		t.Fatalf("expected lockout %v, got %v", 90*time.Second, d)
+	}
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ddd-example/pkg/totp"
+)
+
+const (
+	// 允许前后一个周期的时钟误差
+	totpSkew = 1
+	// 恢复码数量
+	recoveryCodeCount = 10
+)
+
+// TOTP 账号的两步验证设置
+type TOTP struct {
+	Enabled bool
+	Secret  string
+	// 已生成但还未确认的密钥
+	PendingSecret string
+	// 恢复码的sha256值，每个恢复码只能使用一次
+	RecoveryCodes []string
+	// 最后一次使用的验证码周期，同一个验证码不能重复使用
+	LastStep int64
+}
+
+// MFAEnabled 是否开启了两步验证
+func (a *Account) MFAEnabled() bool {
+	return a.TOTP.Enabled
+}
+
+// EnrollTOTP 生成新的TOTP密钥，需要使用验证码确认之后才会开启
+func (a *Account) EnrollTOTP(issuer string) (secret, uri string, err error) {
+	if a.TOTP.Enabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("generate totp secret, %w", err)
+	}
+	a.TOTP.PendingSecret = secret
+
+	return secret, totp.URI(issuer, a.Email, secret), nil
+}
+
+// ConfirmTOTP 使用验证器应用生成的第一个验证码确认并开启两步验证
+//
+// 返回的恢复码只在这时以明文出现，账号只保存hash
+func (a *Account) ConfirmTOTP(passcode string) (recoveryCodes []string, err error) {
+	if a.TOTP.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	} else if a.TOTP.PendingSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := totp.Validate(a.TOTP.PendingSecret, passcode, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrWrongMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("generate recovery codes, %w", err)
+	}
+
+	a.TOTP = TOTP{
+		Enabled:       true,
+		Secret:        a.TOTP.PendingSecret,
+		RecoveryCodes: hashes,
+		LastStep:      step,
+	}
+	return codes, nil
+}
+
+// DisableTOTP 关闭两步验证
+func (a *Account) DisableTOTP() error {
+	if !a.TOTP.Enabled {
+		return ErrMFANotEnabled
+	}
+
+	a.TOTP = TOTP{}
+	return nil
+}
+
+// VerifyMFA 验证TOTP验证码或者恢复码
+//
+// 验证通过后账号状态会改变(记录验证码周期或者消耗恢复码)，调用方需要保存账号
+func (a *Account) VerifyMFA(code string) error {
+	if !a.TOTP.Enabled {
+		return ErrMFANotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(a.TOTP.Secret, code, time.Now(), totpSkew); ok {
+		if step <= a.TOTP.LastStep {
+			return ErrWrongMFACode
+		}
+		a.TOTP.LastStep = step
+		return nil
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, v := range a.TOTP.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(hash)) == 1 {
+			a.TOTP.RecoveryCodes = slices.Delete(a.TOTP.RecoveryCodes, i, i+1)
+			return nil
+		}
+	}
+	return ErrWrongMFACode
+}
+
+// newRecoveryCodes 生成恢复码，格式为xxxxx-xxxxx
+func newRecoveryCodes(n int) (codes, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	for range n {
+		data := make([]byte, 7)
+		if _, err := rand.Read(data); err != nil {
+			return nil, nil, err
+		}
+
+		s := strings.ToLower(encoding.EncodeToString(data))[:10]
+		code := s[:5] + "-" + s[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return
+}
+
+// hashRecoveryCode 恢复码不区分大小写，允许省略分隔符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
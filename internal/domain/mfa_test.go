@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ddd-example/pkg/totp"
+
+	"github.com/joyparty/gokit"
+)
+
+func TestAccountTOTP(t *testing.T) {
+	a := &Account{Email: "test@example.com"}
+
+	secret, uri, err := a.EnrollTOTP("Example")
+	if err != nil {
+		t.Fatal(err)
+	} else if secret == "" || uri == "" {
+		t.Fatal("secret and uri should not be empty")
+	} else if a.MFAEnabled() {
+		t.Fatal("mfa should not be enabled before confirm")
+	}
+
+	if _, err := a.ConfirmTOTP("000000x"); !errors.Is(err, ErrWrongMFACode) {
+		t.Fatalf("confirm with wrong code, expected %v, got %v", ErrWrongMFACode, err)
+	}
+
+	now := time.Now()
+	code := gokit.MustReturn(totp.Generate(secret, now))
+	recoveryCodes, err := a.ConfirmTOTP(code)
+	if err != nil {
+		t.Fatal(err)
+	} else if !a.MFAEnabled() {
+		t.Fatal("mfa should be enabled")
+	} else if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", recoveryCodeCount, len(recoveryCodes))
+	}
+
+	// 确认时使用过的验证码不能再用于登录
+	if err := a.VerifyMFA(code); !errors.Is(err, ErrWrongMFACode) {
+		t.Fatalf("replay code, expected %v, got %v", ErrWrongMFACode, err)
+	}
+
+	next := gokit.MustReturn(totp.Generate(secret, now.Add(totp.Period)))
+	if err := a.VerifyMFA(next); err != nil {
+		t.Fatalf("verify next code, %v", err)
+	}
+
+	// 恢复码只能使用一次
+	if err := a.VerifyMFA(recoveryCodes[0]); err != nil {
+		t.Fatalf("verify recovery code, %v", err)
+	} else if err := a.VerifyMFA(recoveryCodes[0]); !errors.Is(err, ErrWrongMFACode) {
+		t.Fatalf("reuse recovery code, expected %v, got %v", ErrWrongMFACode, err)
+	} else if len(a.TOTP.RecoveryCodes) != recoveryCodeCount-1 {
+		t.Fatal("used recovery code should be removed")
+	}
+
+	if err := a.DisableTOTP(); err != nil {
+		t.Fatal(err)
+	} else if a.MFAEnabled() {
+		t.Fatal("mfa should be disabled")
+	} else if err := a.VerifyMFA(recoveryCodes[1]); !errors.Is(err, ErrMFANotEnabled) {
+		t.Fatalf("expected %v, got %v", ErrMFANotEnabled, err)
+	}
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OauthIdentity 账号绑定的三方账号
+type OauthIdentity struct {
+	AccountID uuid.UUID `json:"-"`
+	Vendor    string    `json:"vendor"`
+	VendorUID string    `json:"vendor_uid"`
+	CreateAt  time.Time `json:"create_at"`
+}
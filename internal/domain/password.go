@@ -0,0 +1,271 @@
+package domain
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher 密码哈希算法
+//
+// 哈希结果使用PHC字符串格式保存，例如 $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+type PasswordHasher interface {
+	// ID 算法标识，对应PHC格式中的第一段
+	ID() string
+	// Hash 计算密码哈希
+	Hash(password string) (string, error)
+	// Verify 验证密码与哈希结果是否一致
+	Verify(encoded, password string) (bool, error)
+	// NeedsRehash 哈希结果使用的参数是否弱于当前配置
+	NeedsRehash(encoded string) bool
+}
+
+var (
+	passwordHashers = map[string]PasswordHasher{}
+
+	// 新密码使用的哈希算法
+	currentPasswordHasher PasswordHasher = NewArgon2idHasher(Argon2idParams{})
+)
+
+func init() {
+	RegisterPasswordHasher(NewArgon2idHasher(Argon2idParams{}))
+	RegisterPasswordHasher(NewBcryptHasher(0))
+}
+
+// RegisterPasswordHasher 注册哈希算法，已经存储的密码可以通过算法标识找到对应的验证方法
+func RegisterPasswordHasher(h PasswordHasher) {
+	passwordHashers[h.ID()] = h
+
+	// bcrypt的几个版本前缀使用同样的验证方法
+	if h.ID() == bcryptID {
+		passwordHashers["2b"] = h
+		passwordHashers["2y"] = h
+	}
+}
+
+// SetPasswordHasher 设置新密码使用的哈希算法
+//
+// 旧算法计算的密码在下次登录成功时会被重新哈希
+func SetPasswordHasher(h PasswordHasher) {
+	RegisterPasswordHasher(h)
+	currentPasswordHasher = h
+}
+
+// NewPasswordHasher 根据算法名称构造哈希算法，使用默认参数
+func NewPasswordHasher(name string) (PasswordHasher, error) {
+	switch name {
+	case "", "argon2id":
+		return NewArgon2idHasher(Argon2idParams{}), nil
+	case "bcrypt":
+		return NewBcryptHasher(0), nil
+	default:
+		return nil, fmt.Errorf("unknown password hasher %q", name)
+	}
+}
+
+// hashPassword 使用当前算法计算密码哈希
+func hashPassword(password string) (string, error) {
+	return currentPasswordHasher.Hash(password)
+}
+
+// verifyPassword 验证密码，兼容旧的md5(password+salt)格式
+func verifyPassword(encoded, salt, password string) bool {
+	if !strings.HasPrefix(encoded, "$") {
+		expected := legacyPassword(password, salt)
+		return subtle.ConstantTimeCompare([]byte(encoded), []byte(expected)) == 1
+	}
+
+	h, ok := passwordHashers[phcID(encoded)]
+	if !ok {
+		return false
+	}
+
+	ok, err := h.Verify(encoded, password)
+	return err == nil && ok
+}
+
+// passwordNeedsRehash 密码哈希是否需要使用当前算法重新计算
+func passwordNeedsRehash(encoded string) bool {
+	if !strings.HasPrefix(encoded, "$") {
+		return true
+	}
+
+	h, ok := passwordHashers[phcID(encoded)]
+	if !ok || h.ID() != currentPasswordHasher.ID() {
+		return true
+	}
+	return h.NeedsRehash(encoded)
+}
+
+func phcID(encoded string) string {
+	id, _, _ := strings.Cut(strings.TrimPrefix(encoded, "$"), "$")
+	return id
+}
+
+// legacyPassword 旧版本的密码哈希算法，只用于验证已有数据
+func legacyPassword(password string, salt string) string {
+	data := append([]byte(password), []byte(salt)...)
+	return fmt.Sprintf("%x", md5.Sum(data))
+}
+
+const (
+	argon2idID = "argon2id"
+	bcryptID   = "2a"
+)
+
+// Argon2idParams argon2id算法参数，零值使用OWASP推荐的默认参数
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher argon2id哈希算法
+func NewArgon2idHasher(params Argon2idParams) PasswordHasher {
+	if params.Memory == 0 {
+		params.Memory = 19 * 1024
+	}
+	if params.Iterations == 0 {
+		params.Iterations = 2
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = 1
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = 16
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = 32
+	}
+
+	return &argon2idHasher{params: params}
+}
+
+func (h *argon2idHasher) ID() string {
+	return argon2idID
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt, %w", err)
+	}
+
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idID,
+		argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(encoded, password string) (bool, error) {
+	p, salt, key, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (h *argon2idHasher) NeedsRehash(encoded string) bool {
+	p, _, key, err := h.decode(encoded)
+	if err != nil {
+		return true
+	}
+
+	return p.Memory < h.params.Memory ||
+		p.Iterations < h.params.Iterations ||
+		p.Parallelism < h.params.Parallelism ||
+		uint32(len(key)) < h.params.KeyLength
+}
+
+func (h *argon2idHasher) decode(encoded string) (p Argon2idParams, salt, key []byte, err error) {
+	// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != argon2idID {
+		err = errors.New("invalid argon2id hash format")
+		return
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		err = fmt.Errorf("parse version, %w", err)
+		return
+	} else if version != argon2.Version {
+		err = fmt.Errorf("unsupported argon2 version %d", version)
+		return
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		err = fmt.Errorf("parse params, %w", err)
+		return
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		err = fmt.Errorf("decode salt, %w", err)
+		return
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		err = fmt.Errorf("decode hash, %w", err)
+		return
+	}
+	return
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher bcrypt哈希算法，cost为0时使用bcrypt.DefaultCost
+func NewBcryptHasher(cost int) PasswordHasher {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) ID() string {
+	return bcryptID
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	data, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (h *bcryptHasher) Verify(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.cost
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	for _, h := range []PasswordHasher{
+		NewArgon2idHasher(Argon2idParams{}),
+		NewBcryptHasher(0),
+	} {
+		encoded, err := h.Hash("abcdefg")
+		if err != nil {
+			t.Fatalf("%s: %v", h.ID(), err)
+		} else if !strings.HasPrefix(encoded, "$"+h.ID()+"$") {
+			t.Fatalf("%s: unexpected format %q", h.ID(), encoded)
+		}
+
+		if ok, err := h.Verify(encoded, "abcdefg"); err != nil || !ok {
+			t.Fatalf("%s: verify should be true, %v", h.ID(), err)
+		} else if ok, err := h.Verify(encoded, "abcdef"); err != nil || ok {
+			t.Fatalf("%s: verify should be false, %v", h.ID(), err)
+		} else if h.NeedsRehash(encoded) {
+			t.Fatalf("%s: should not need rehash", h.ID())
+		}
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	defer SetPasswordHasher(currentPasswordHasher)
+
+	weak, err := NewArgon2idHasher(Argon2idParams{Memory: 1024, Iterations: 1}).Hash("abcdefg")
+	if err != nil {
+		t.Fatal(err)
+	} else if !passwordNeedsRehash(weak) {
+		t.Fatal("weak argon2id params should be rehashed")
+	} else if !verifyPassword(weak, "", "abcdefg") {
+		t.Fatal("weak argon2id password should be verified")
+	}
+
+	SetPasswordHasher(NewBcryptHasher(0))
+	if !passwordNeedsRehash(weak) {
+		t.Fatal("argon2id password should be rehashed when current hasher is bcrypt")
+	}
+}
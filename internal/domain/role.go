@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// 权限，格式为"资源:操作"
+const (
+	// PermissionAll 所有权限
+	PermissionAll = "*"
+
+	PermissionAccountsRead  = "accounts:read"
+	PermissionAccountsWrite = "accounts:write"
+	PermissionRolesRead     = "roles:read"
+	PermissionRolesWrite    = "roles:write"
+	PermissionWebhooksRead  = "webhooks:read"
+	PermissionWebhooksWrite = "webhooks:write"
+)
+
+// RoleSuperAdmin 超级管理员，拥有所有权限，由数据库迁移脚本创建
+const RoleSuperAdmin = "super_admin"
+
+// Role 角色
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// Permissions 权限集合
+type Permissions map[string]struct{}
+
+// NewPermissions 合并角色的权限
+func NewPermissions(roles ...*Role) Permissions {
+	perms := Permissions{}
+	for _, role := range roles {
+		for _, p := range role.Permissions {
+			perms[p] = struct{}{}
+		}
+	}
+	return perms
+}
+
+// Allow 是否拥有指定权限
+//
+// "*"匹配所有权限，"accounts:*"匹配accounts资源的所有操作
+func (perms Permissions) Allow(permission string) bool {
+	if _, ok := perms[PermissionAll]; ok {
+		return true
+	} else if _, ok := perms[permission]; ok {
+		return true
+	}
+
+	if resource, _, ok := strings.Cut(permission, ":"); ok {
+		_, ok := perms[resource+":*"]
+		return ok
+	}
+	return false
+}
+
+// HasRole 是否拥有指定角色
+func (a *Account) HasRole(role string) bool {
+	return slices.Contains(a.Roles, role)
+}
+
+// AssignRole 分配角色，已经拥有时返回false
+func (a *Account) AssignRole(role string) bool {
+	if a.HasRole(role) {
+		return false
+	}
+	a.Roles = append(a.Roles, role)
+	slices.Sort(a.Roles)
+	return true
+}
+
+// RevokeRole 撤销角色，没有这个角色时返回false
+func (a *Account) RevokeRole(role string) bool {
+	i := slices.Index(a.Roles, role)
+	if i < 0 {
+		return false
+	}
+	a.Roles = slices.Delete(a.Roles, i, i+1)
+	return true
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPermissionsAllow(t *testing.T) {
+	perms := NewPermissions(
+		&Role{Permissions: []string{PermissionAccountsRead}},
+		&Role{Permissions: []string{"webhooks:*"}},
+	)
+
+	cases := map[string]bool{
+		PermissionAccountsRead:  true,
+		PermissionAccountsWrite: false,
+		PermissionWebhooksRead:  true,
+		PermissionWebhooksWrite: true,
+		PermissionRolesWrite:    false,
+		"webhooks":              false,
+	}
+	for permission, expected := range cases {
+		if got := perms.Allow(permission); got != expected {
+			t.Fatalf("allow %q, expected %v, got %v", permission, expected, got)
+		}
+	}
+
+	all := NewPermissions(&Role{Permissions: []string{PermissionAll}})
+	if !all.Allow(PermissionRolesWrite) {
+		t.Fatal("* should allow any permission")
+	}
+
+	if NewPermissions().Allow(PermissionAccountsRead) {
+		t.Fatal("empty permissions should not allow anything")
+	}
+}
+
+func TestAccountRoles(t *testing.T) {
+	a := &Account{}
+
+	if !a.AssignRole("support") || !a.AssignRole(RoleSuperAdmin) {
+		t.Fatal("assign role failed")
+	} else if a.AssignRole("support") {
+		t.Fatal("assign role twice should return false")
+	} else if !slices.Equal(a.Roles, []string{"super_admin", "support"}) {
+		t.Fatalf("roles should be sorted, got %v", a.Roles)
+	}
+
+	if !a.RevokeRole("support") {
+		t.Fatal("revoke role failed")
+	} else if a.RevokeRole("support") {
+		t.Fatal("revoke missing role should return false")
+	} else if a.HasRole("support") || !a.HasRole(RoleSuperAdmin) {
+		t.Fatalf("unexpected roles %v", a.Roles)
+	}
+}
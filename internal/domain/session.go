@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// 会话最近访问时间的更新间隔，避免每个请求都写数据库
+	sessionTouchInterval = time.Minute
+	// 敏感操作要求会话在这个时间内登录，视为重新验证过身份
+	sessionReauthWindow = 5 * time.Minute
+)
+
+// SessionLifetime 会话在最近一次访问之后保持有效的时长，和会话凭证的有效期一致
+var SessionLifetime = 30 * 24 * time.Hour
+
+// LegacySessionTokenUntil 迁移截止时间，md5签名的旧格式会话凭证在这个时间之后不再接受，零值表示不接受旧格式凭证
+var LegacySessionTokenUntil time.Time
+
+// Session 登录会话，每个设备的每次登录对应一条记录
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	AccountID  uuid.UUID `json:"-"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreateAt   time.Time `json:"create_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// 登录时验证身份的时间，旧凭证升级而来的会话没有验证过身份，为零值
+	AuthAt time.Time `json:"-"`
+	// 过期之后会话凭证已经不可能有效，会话记录由后台任务删除
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// ClientInfo 发起请求的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	Device    string
+	// 客户端首选语言，来自Accept-Language请求头
+	Language string
+}
+
+// NewSession 构造新会话，调用方需要已经验证过身份，例如密码、三方账号或者邮件凭证
+func NewSession(accountID uuid.UUID, client ClientInfo) (*Session, error) {
+	s, err := newSession(accountID, client)
+	if err != nil {
+		return nil, err
+	}
+	s.AuthAt = s.CreateAt
+	return s, nil
+}
+
+// NewUpgradedSession 为没有会话记录的旧凭证补充会话，没有验证过身份
+func NewUpgradedSession(accountID uuid.UUID, client ClientInfo) (*Session, error) {
+	return newSession(accountID, client)
+}
+
+func newSession(accountID uuid.UUID, client ClientInfo) (*Session, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("create id, %w", err)
+	}
+
+	now := time.Now()
+	s := &Session{
+		ID:         id,
+		AccountID:  accountID,
+		Device:     client.Device,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreateAt:   now,
+		LastSeenAt: now,
+	}
+	s.extend(now)
+	return s, nil
+}
+
+// Touch 记录最近一次访问，返回是否需要保存
+func (s *Session) Touch(client ClientInfo) bool {
+	now := time.Now()
+	if now.Sub(s.LastSeenAt) < sessionTouchInterval {
+		return false
+	}
+
+	s.LastSeenAt = now
+	s.extend(now)
+	if client.IP != "" {
+		s.IP = client.IP
+	}
+	return true
+}
+
+// extend 延长有效期，会话凭证在访问时续期，访问时间最多晚sessionTouchInterval记录
+func (s *Session) extend(now time.Time) {
+	s.ExpireAt = now.Add(SessionLifetime + sessionTouchInterval)
+}
+
+// Expired 是否已经过期
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpireAt)
+}
+
+// Reauthenticated 会话是否刚刚验证过身份，没有密码的账号通过重新登录确认身份
+func (s *Session) Reauthenticated(now time.Time) bool {
+	return !s.AuthAt.IsZero() && now.Sub(s.AuthAt) < sessionReauthWindow
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSessionReauthenticated(t *testing.T) {
+	s, err := NewSession(uuid.New(), ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if !s.Reauthenticated(now) {
+		t.Fatal("new session should be reauthenticated")
+	} else if s.Reauthenticated(now.Add(sessionReauthWindow)) {
+		t.Fatal("session should expire after reauth window")
+	}
+
+	// 旧凭证升级的会话没有验证过身份
+	upgraded, err := NewUpgradedSession(uuid.New(), ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	} else if upgraded.Reauthenticated(now) {
+		t.Fatal("upgraded session should not be reauthenticated")
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	s, err := NewSession(uuid.New(), ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if s.Expired(now.Add(SessionLifetime)) {
+		t.Fatal("session should not expire before token lifetime")
+	} else if !s.Expired(now.Add(SessionLifetime + sessionTouchInterval + time.Second)) {
+		t.Fatal("session should expire after token lifetime")
+	}
+
+	// 访问时延长有效期
+	s.LastSeenAt = now.Add(-sessionTouchInterval)
+	if !s.Touch(ClientInfo{}) {
+		t.Fatal("session should be touched")
+	} else if s.Expired(now.Add(SessionLifetime + sessionTouchInterval)) {
+		t.Fatal("touched session should be extended")
+	}
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhook投递状态
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	// WebhookDead 超过最大重试次数，需要管理员处理
+	WebhookDead = "dead"
+)
+
+const (
+	// 单次投递的最大重试次数
+	webhookMaxAttempts = 8
+	// 第一次重试的间隔，之后每次翻倍
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	// 保留的投递记录数量
+	webhookMaxHistory = 20
+)
+
+// Webhook 接收领域事件的外部地址
+type Webhook struct {
+	ID  uuid.UUID `json:"id"`
+	URL string    `json:"url"`
+	// 签名密钥，只在创建时返回给管理员
+	Secret string `json:"-"`
+	// 订阅的事件类型，为空时订阅所有事件
+	Events   []string  `json:"events"`
+	Enabled  bool      `json:"enabled"`
+	CreateAt time.Time `json:"create_at"`
+}
+
+// NewWebhook 构造webhook，生成随机签名密钥
+func NewWebhook(rawURL string, events []string) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("create id, %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate secret, %w", err)
+	}
+
+	return &Webhook{
+		ID:       id,
+		URL:      u.String(),
+		Secret:   "whsec_" + base64.RawURLEncoding.EncodeToString(secret),
+		Events:   events,
+		Enabled:  true,
+		CreateAt: time.Now(),
+	}, nil
+}
+
+// Subscribed 是否订阅了指定类型的事件
+func (w *Webhook) Subscribed(eventType string) bool {
+	return w.Enabled && (len(w.Events) == 0 || slices.Contains(w.Events, eventType))
+}
+
+// Sign 计算请求签名，签名内容为 <unix timestamp>.<body>
+//
+// 接收方使用同样的方式计算并比较签名，同时检查时间戳防止重放
+func (w *Webhook) Sign(timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookAttempt 一次投递的结果
+type WebhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// WebhookDelivery 一个事件到一个webhook的投递
+type WebhookDelivery struct {
+	ID        uuid.UUID `json:"id"`
+	WebhookID uuid.UUID `json:"webhook_id"`
+	EventID   uuid.UUID `json:"event_id"`
+	EventType string    `json:"event_type"`
+	// 事件envelope
+	Payload       []byte           `json:"-"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	History       []WebhookAttempt `json:"history"`
+	NextAttemptAt time.Time        `json:"next_attempt_at"`
+	CreateAt      time.Time        `json:"create_at"`
+}
+
+// NewWebhookDelivery 构造待投递记录
+func NewWebhookDelivery(hook *Webhook, eventID uuid.UUID, eventType string, payload []byte) (*WebhookDelivery, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("create id, %w", err)
+	}
+
+	return &WebhookDelivery{
+		ID:            id,
+		WebhookID:     hook.ID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        WebhookPending,
+		NextAttemptAt: time.Now(),
+		CreateAt:      time.Now(),
+	}, nil
+}
+
+// Record 记录投递结果，失败时按指数退避安排重试，超过最大次数后进入dead状态
+func (d *WebhookDelivery) Record(attempt WebhookAttempt) {
+	d.Attempts++
+	d.History = append(d.History, attempt)
+	if n := len(d.History); n > webhookMaxHistory {
+		d.History = d.History[n-webhookMaxHistory:]
+	}
+
+	if attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+		d.Status = WebhookDelivered
+		return
+	}
+
+	if d.Attempts >= webhookMaxAttempts {
+		d.Status = WebhookDead
+		return
+	}
+
+	backoff := webhookBaseBackoff << min(d.Attempts-1, 16)
+	d.NextAttemptAt = attempt.At.Add(min(backoff, webhookMaxBackoff))
+}
+
+// Replay 重新投递，重置重试次数，保留历史记录
+func (d *WebhookDelivery) Replay() error {
+	if d.Status == WebhookPending {
+		return ErrWebhookDeliveryPending
+	}
+
+	d.Status = WebhookPending
+	d.Attempts = 0
+	d.NextAttemptAt = time.Now()
+	return nil
+}
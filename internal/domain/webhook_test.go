@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewWebhook(t *testing.T) {
+	for _, v := range []string{"", "ftp://example.com", "https://", "example.com/hook"} {
+		if _, err := NewWebhook(v, nil); !errors.Is(err, ErrInvalidWebhookURL) {
+			t.Fatalf("new webhook %q, expected %v, got %v", v, ErrInvalidWebhookURL, err)
+		}
+	}
+
+	hook, err := NewWebhook("https://example.com/hook", []string{"account.registered"})
+	if err != nil {
+		t.Fatal(err)
+	} else if !hook.Subscribed("account.registered") {
+		t.Fatal("should subscribe account.registered")
+	} else if hook.Subscribed("account.login") {
+		t.Fatal("should not subscribe account.login")
+	}
+
+	hook.Events = nil
+	if !hook.Subscribed("account.login") {
+		t.Fatal("empty events should subscribe all events")
+	}
+
+	hook.Enabled = false
+	if hook.Subscribed("account.login") {
+		t.Fatal("disabled webhook should not subscribe any event")
+	}
+}
+
+func TestWebhookSign(t *testing.T) {
+	hook := &Webhook{Secret: "whsec_test"}
+	ts := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"account.login"}`)
+
+	// printf '1700000000.{"type":"account.login"}' | openssl dgst -sha256 -hmac whsec_test
+	sig := hook.Sign(ts, body)
+	if sig != "sha256=6ccfc113547067bf279b9ba6f8b578e0b27472d7ca876e11618f065f796524b0" {
+		t.Fatalf("unexpected signature %q", sig)
+	}
+
+	if hook.Sign(ts.Add(time.Second), body) == sig {
+		t.Fatal("signature should cover timestamp")
+	} else if (&Webhook{Secret: "whsec_other"}).Sign(ts, body) == sig {
+		t.Fatal("signature should depend on secret")
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	hook := &Webhook{ID: uuid.Must(uuid.NewV7()), Enabled: true}
+	d, err := NewWebhookDelivery(hook, uuid.Must(uuid.NewV7()), "account.login", []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Replay(); !errors.Is(err, ErrWebhookDeliveryPending) {
+		t.Fatalf("replay pending delivery, expected %v, got %v", ErrWebhookDeliveryPending, err)
+	}
+
+	now := time.Now()
+	d.Record(WebhookAttempt{At: now, StatusCode: http.StatusInternalServerError})
+	if d.Status != WebhookPending {
+		t.Fatalf("expected status %s, got %s", WebhookPending, d.Status)
+	} else if !d.NextAttemptAt.Equal(now.Add(webhookBaseBackoff)) {
+		t.Fatalf("unexpected next attempt %v", d.NextAttemptAt)
+	}
+
+	d.Record(WebhookAttempt{At: now, Error: "connection refused"})
+	if !d.NextAttemptAt.Equal(now.Add(2 * webhookBaseBackoff)) {
+		t.Fatalf("backoff should double, got %v", d.NextAttemptAt.Sub(now))
+	}
+
+	for d.Status == WebhookPending {
+		d.Record(WebhookAttempt{At: now, StatusCode: http.StatusBadGateway})
+	}
+	if d.Status != WebhookDead {
+		t.Fatalf("expected status %s, got %s", WebhookDead, d.Status)
+	} else if d.Attempts != webhookMaxAttempts {
+		t.Fatalf("expected %d attempts, got %d", webhookMaxAttempts, d.Attempts)
+	}
+
+	if err := d.Replay(); err != nil {
+		t.Fatal(err)
+	} else if d.Status != WebhookPending || d.Attempts != 0 {
+		t.Fatal("replay should reset delivery")
+	} else if len(d.History) != webhookMaxAttempts {
+		t.Fatal("replay should keep history")
+	}
+
+	d.Record(WebhookAttempt{At: now, StatusCode: http.StatusNoContent})
+	if d.Status != WebhookDelivered {
+		t.Fatalf("expected status %s, got %s", WebhookDelivered, d.Status)
+	}
+}
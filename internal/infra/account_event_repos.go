@@ -0,0 +1,97 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/pkg/database"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+	"github.com/samber/do/v2"
+)
+
+// accountEventDBRepository 账号事件记录，数据库存储
+type accountEventDBRepository struct {
+	db entity.DB
+}
+
+// AccountEventRepositoryProvider 账号事件仓库提供者
+func AccountEventRepositoryProvider(injector do.Injector) (adapter.AccountEventRepository, error) {
+	return NewAccountEventRepository(do.MustInvoke[*sqlx.DB](injector)), nil
+}
+
+// NewAccountEventRepository returns account event repository.
+func NewAccountEventRepository(db entity.DB) adapter.AccountEventRepository {
+	return &accountEventDBRepository{db: db}
+}
+
+// Add 保存事件记录，已经存在的事件忽略
+func (r *accountEventDBRepository) Add(ctx context.Context, event *adapter.AccountEvent) error {
+	row := &accountEventRow{
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt.Unix(),
+	}
+	if err := database.SetUUID(&row.ID, event.ID); err != nil {
+		return fmt.Errorf("set id, %w", err)
+	} else if err := database.SetUUID(&row.AccountID, event.AccountID); err != nil {
+		return fmt.Errorf("set account id, %w", err)
+	} else if err := row.Data.Set(event.Data); err != nil {
+		return fmt.Errorf("set data, %w", err)
+	}
+
+	stmt := insertInto(r.db, tableAccountEvents).
+		Rows(row).
+		OnConflict(goqu.DoNothing())
+	_, err := entity.ExecInsert(ctx, r.db, stmt)
+	return err
+}
+
+// ListByAccount 账号的事件记录，最新的在前
+func (r *accountEventDBRepository) ListByAccount(ctx context.Context, accountID uuid.UUID, types []string, limit int) ([]*adapter.AccountEvent, error) {
+	stmt := selectFrom(r.db, tableAccountEvents).
+		Where(colAccountID.Eq(accountID.String())).
+		Order(colOccurredAt.Desc(), colID.Desc()).
+		Limit(uint(limit))
+	if len(types) > 0 {
+		stmt = stmt.Where(colType.In(types))
+	}
+
+	var rows []accountEventRow
+	if err := entity.GetRecords(ctx, &rows, r.db, stmt); err != nil {
+		return nil, err
+	}
+
+	result := make([]*adapter.AccountEvent, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, &adapter.AccountEvent{
+			ID:         row.ID.Bytes,
+			AccountID:  row.AccountID.Bytes,
+			Type:       row.Type,
+			Data:       row.Data.Bytes,
+			OccurredAt: time.Unix(row.OccurredAt, 0),
+		})
+	}
+	return result, nil
+}
+
+// DeleteByAccount 删除账号的所有事件记录
+func (r *accountEventDBRepository) DeleteByAccount(ctx context.Context, accountID uuid.UUID) error {
+	stmt := deleteFrom(r.db, tableAccountEvents).Where(colAccountID.Eq(accountID.String()))
+
+	_, err := entity.ExecDelete(ctx, r.db, stmt)
+	return err
+}
+
+type accountEventRow struct {
+	ID         pgtype.UUID `db:"id"`
+	AccountID  pgtype.UUID `db:"account_id"`
+	Type       string      `db:"type"`
+	Data       pgtype.JSON `db:"data"`
+	OccurredAt int64       `db:"occurred_at"`
+}
@@ -0,0 +1,110 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+	"github.com/samber/do/v2"
+)
+
+// accountDBQuery 账号查询，直接读取数据库，不经过缓存
+type accountDBQuery struct {
+	db entity.DB
+}
+
+// AccountQueryProvider 账号查询提供者
+func AccountQueryProvider(injector do.Injector) (adapter.AccountQuery, error) {
+	return NewAccountQuery(do.MustInvoke[*sqlx.DB](injector)), nil
+}
+
+// NewAccountQuery returns account query.
+func NewAccountQuery(db entity.DB) adapter.AccountQuery {
+	return &accountDBQuery{db: db}
+}
+
+// Search 按条件查询账号
+func (q *accountDBQuery) Search(ctx context.Context, filter adapter.AccountFilter) ([]*domain.Account, int, error) {
+	var conditions []goqu.Expression
+	if v := domain.NormalizeEmail(filter.Email); v != "" {
+		conditions = append(conditions, goqu.L(`email like ? escape '\'`, "%"+escapeLike(v)+"%"))
+	}
+	if v := filter.Status; v != "" {
+		conditions = append(conditions, colStatus.Eq(v))
+	}
+	if v := filter.CreatedFrom; !v.IsZero() {
+		conditions = append(conditions, colCreateAt.Gte(v.Unix()))
+	}
+	if v := filter.CreatedTo; !v.IsZero() {
+		conditions = append(conditions, colCreateAt.Lt(v.Unix()))
+	}
+
+	var total int
+	stmt := selectFrom(q.db, tableAccounts).
+		Select(goqu.COUNT(goqu.Star())).
+		Where(conditions...)
+	if err := entity.GetRecord(ctx, &total, q.db, stmt); err != nil {
+		return nil, 0, fmt.Errorf("count accounts, %w", err)
+	} else if total == 0 {
+		return nil, 0, nil
+	}
+
+	stmt = selectFrom(q.db, tableAccounts).
+		Where(conditions...).
+		Order(colCreateAt.Desc(), colID.Desc()).
+		Offset(uint(filter.Offset)).
+		Limit(uint(filter.Limit))
+
+	accounts, err := q.query(ctx, stmt)
+	if err != nil {
+		return nil, 0, err
+	}
+	return accounts, total, nil
+}
+
+// ListStatusChanged 查找处于指定状态，并且状态变更时间早于before的账号，最早变更的在前
+func (q *accountDBQuery) ListStatusChanged(ctx context.Context, status string, before time.Time, limit int) ([]*domain.Account, error) {
+	stmt := selectFrom(q.db, tableAccounts).
+		Where(
+			colStatus.Eq(status),
+			colStatusUpdate.Lt(before.Unix()),
+		).
+		Order(colStatusUpdate.Asc(), colID.Asc()).
+		Limit(uint(limit))
+
+	return q.query(ctx, stmt)
+}
+
+// query 执行查询，同时读取账号的角色
+func (q *accountDBQuery) query(ctx context.Context, stmt *goqu.SelectDataset) ([]*domain.Account, error) {
+	var rows []accountRow
+	if err := entity.GetRecords(ctx, &rows, q.db, stmt); err != nil {
+		return nil, fmt.Errorf("query accounts, %w", err)
+	}
+
+	accounts := make([]*domain.Account, 0, len(rows))
+	for _, row := range rows {
+		a, err := row.ToDomainObject()
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+
+	if err := loadAccountRoles(ctx, q.db, accounts...); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// escapeLike 转义like查询的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
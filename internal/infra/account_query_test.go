@@ -0,0 +1,134 @@
+//go:build dbtest || pgtest
+// +build dbtest pgtest
+
+package infra
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/joyparty/entity"
+)
+
+func TestAccountQuery(t *testing.T) {
+	if err := entity.Transaction(testDB, func(tx entity.DB) (err error) {
+		defer func() {
+			err = cmp.Or(err, errRollbackTest)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		repos := newAccountDBRepository(tx)
+		query := NewAccountQuery(tx)
+
+		for _, email := range []string{"query_a@test.com", "query_b@test.com", "querya@test.com"} {
+			account := &domain.Account{}
+			if err := account.SetEmail(email); err != nil {
+				return err
+			} else if err := account.SetPassword("abcdef"); err != nil {
+				return err
+			}
+			if email == "query_b@test.com" {
+				if err := account.Disable("test", uuid.Nil); err != nil {
+					return err
+				}
+				account.AssignRole("support")
+			}
+			if err := repos.Create(ctx, account); err != nil {
+				return fmt.Errorf("create account, %w", err)
+			}
+		}
+
+		return testTable{
+			{
+				Name: "Email",
+				Func: func() error {
+					// 下划线需要按字面匹配
+					accounts, total, err := query.Search(ctx, adapter.AccountFilter{Email: "query_", Limit: 10})
+					if err != nil {
+						return err
+					} else if total != 2 || len(accounts) != 2 {
+						return fmt.Errorf("expected 2 accounts, got %d", total)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Status",
+				Func: func() error {
+					accounts, total, err := query.Search(ctx, adapter.AccountFilter{
+						Email:  "query",
+						Status: domain.AccountDisabled,
+						Limit:  10,
+					})
+					if err != nil {
+						return err
+					} else if total != 1 || accounts[0].Email != "query_b@test.com" {
+						return fmt.Errorf("unexpected result, total %d", total)
+					} else if !accounts[0].HasRole("support") {
+						return errors.New("roles not loaded")
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Page",
+				Func: func() error {
+					accounts, total, err := query.Search(ctx, adapter.AccountFilter{
+						Email:       "query",
+						CreatedFrom: time.Now().Add(-time.Hour),
+						CreatedTo:   time.Now().Add(time.Hour),
+						Offset:      2,
+						Limit:       2,
+					})
+					if err != nil {
+						return err
+					} else if total != 3 || len(accounts) != 1 {
+						return fmt.Errorf("expected 1 of 3 accounts, got %d of %d", len(accounts), total)
+					}
+
+					_, total, err = query.Search(ctx, adapter.AccountFilter{
+						CreatedTo: time.Now().Add(-time.Hour),
+						Limit:     10,
+					})
+					if err != nil {
+						return err
+					} else if total != 0 {
+						return fmt.Errorf("expected no account, got %d", total)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "ListStatusChanged",
+				Func: func() error {
+					accounts, err := query.ListStatusChanged(ctx, domain.AccountDisabled, time.Now().Add(time.Minute), 10)
+					if err != nil {
+						return err
+					} else if len(accounts) != 1 || accounts[0].Email != "query_b@test.com" {
+						return fmt.Errorf("unexpected accounts %v", accounts)
+					}
+
+					accounts, err = query.ListStatusChanged(ctx, domain.AccountDisabled, time.Now().Add(-time.Minute), 10)
+					if err != nil {
+						return err
+					} else if len(accounts) != 0 {
+						return fmt.Errorf("expected no account, got %d", len(accounts))
+					}
+					return nil
+				},
+			},
+		}.Execute()
+	}); !errors.Is(err, errRollbackTest) {
+		t.Fatalf("account query, %v", err)
+	}
+}
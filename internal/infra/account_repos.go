@@ -1,16 +1,19 @@
 package infra
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"ddd-example/internal/app/adapter"
 	"ddd-example/internal/domain"
 	"ddd-example/pkg/database"
 
+	"github.com/doug-martin/goqu/v9"
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 	"github.com/jmoiron/sqlx"
@@ -52,12 +55,12 @@ func (r *accountDBRepository) Find(ctx context.Context, accountID uuid.UUID) (*d
 		return nil, err
 	}
 
-	return a, nil
+	return a, r.loadRoles(ctx, a)
 }
 
 // FindByEmail 根据email查找对应账号
 func (r *accountDBRepository) FindByEmail(ctx context.Context, email string) (*domain.Account, error) {
-	stmt := selectAccounts.Where(colEmail.Eq(email)).Limit(1)
+	stmt := selectFrom(r.db, tableAccounts).Where(colEmail.Eq(email)).Limit(1)
 
 	row := &accountRow{}
 	if err := entity.GetRecord(ctx, row, r.db, stmt); err != nil {
@@ -67,30 +70,159 @@ func (r *accountDBRepository) FindByEmail(ctx context.Context, email string) (*d
 		return nil, err
 	}
 
-	return row.ToDomainObject()
+	a, err := row.ToDomainObject()
+	if err != nil {
+		return nil, err
+	}
+	return a, r.loadRoles(ctx, a)
 }
 
 // Create 保存新用户
 func (r *accountDBRepository) Create(ctx context.Context, account *domain.Account) error {
-	return r.base.Create(ctx, account)
+	// 在这里生成ID，否则调用方拿到的账号对象没有ID
+	if account.ID == uuid.Nil {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return fmt.Errorf("create id, %w", err)
+		}
+		account.ID = id
+	}
+	if account.CreateAt.IsZero() {
+		account.CreateAt = time.Now()
+	}
+
+	return r.transaction(ctx, func(r *accountDBRepository) error {
+		if err := r.base.Create(ctx, account); err != nil {
+			return err
+		}
+		return r.saveRoles(ctx, account)
+	})
 }
 
 // Update 更新用户数据
 func (r *accountDBRepository) Update(ctx context.Context, account *domain.Account) error {
-	return r.base.Update(ctx, account)
+	return r.transaction(ctx, func(r *accountDBRepository) error {
+		if err := r.base.Update(ctx, account); err != nil {
+			return err
+		}
+		return r.saveRoles(ctx, account)
+	})
+}
+
+// Delete 删除账号以及角色关联
+func (r *accountDBRepository) Delete(ctx context.Context, accountID uuid.UUID) error {
+	account, err := r.Find(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	return r.transaction(ctx, func(r *accountDBRepository) error {
+		stmt := deleteFrom(r.db, tableAccountRoles).Where(colAccountID.Eq(accountID.String()))
+		if _, err := entity.ExecDelete(ctx, r.db, stmt); err != nil {
+			return fmt.Errorf("delete roles, %w", err)
+		}
+		return r.base.Delete(ctx, account)
+	})
+}
+
+// transaction 账号和角色关联在同一个事务内保存，已经在事务内时直接执行
+func (r *accountDBRepository) transaction(ctx context.Context, fn func(r *accountDBRepository) error) error {
+	return entity.TryTransactionX[*sqlx.Tx](ctx, r.db, func(db entity.DB) error {
+		return fn(newAccountDBRepository(db))
+	})
+}
+
+// loadRoles 读取账号的角色
+func (r *accountDBRepository) loadRoles(ctx context.Context, account *domain.Account) error {
+	return loadAccountRoles(ctx, r.db, account)
+}
+
+// saveRoles 按账号对象上的角色增删关联记录
+func (r *accountDBRepository) saveRoles(ctx context.Context, account *domain.Account) error {
+	stmt := selectFrom(r.db, tableAccountRoles).
+		Select(colRole).
+		Where(colAccountID.Eq(account.ID.String()))
+
+	var saved []string
+	if err := entity.GetRecords(ctx, &saved, r.db, stmt); err != nil {
+		return fmt.Errorf("load roles, %w", err)
+	}
+
+	var revoked []string
+	for _, role := range saved {
+		if !slices.Contains(account.Roles, role) {
+			revoked = append(revoked, role)
+		}
+	}
+	if len(revoked) > 0 {
+		stmt := deleteFrom(r.db, tableAccountRoles).
+			Where(
+				colAccountID.Eq(account.ID.String()),
+				colRole.In(revoked),
+			)
+		if _, err := entity.ExecDelete(ctx, r.db, stmt); err != nil {
+			return fmt.Errorf("revoke roles, %w", err)
+		}
+	}
+
+	now := time.Now().Unix()
+	for _, role := range account.Roles {
+		if slices.Contains(saved, role) {
+			continue
+		}
+
+		stmt := insertInto(r.db, tableAccountRoles).
+			Rows(goqu.Record{
+				"account_id": account.ID.String(),
+				"role":       role,
+				"create_at":  now,
+			}).
+			OnConflict(goqu.DoNothing())
+		if _, err := entity.ExecInsert(ctx, r.db, stmt); err != nil {
+			return fmt.Errorf("assign role, %w", err)
+		}
+	}
+	return nil
 }
 
 type accountRow struct {
 	baseRow
 
-	Email    pgtype.Text `db:"email"`
-	Password pgtype.Text `db:"password"`
-	Setting  pgtype.JSON `db:"setting"`
+	Email         pgtype.Text `db:"email"`
+	EmailVerified bool        `db:"email_verified"`
+	Status        string      `db:"status"`
+	// 最近一次状态变更的时间，用于查找注销宽限期已过的账号
+	StatusUpdateAt int64       `db:"status_update_at"`
+	Password       pgtype.Text `db:"password"`
+	Setting        pgtype.JSON `db:"setting"`
 }
 
 type accountRowSetting struct {
 	PasswordSalt string `json:"password_salt"`
 	SessionSalt  string `json:"session_salt"`
+	Language     string `json:"language,omitempty"`
+	// 两步验证
+	TOTP *accountTOTPSetting `json:"totp,omitempty"`
+	// 最近一次状态变更
+	StatusChange *accountStatusSetting `json:"status_change,omitempty"`
+	// 修改email的撤销凭证
+	EmailRevertNonce string `json:"email_revert_nonce,omitempty"`
+}
+
+type accountStatusSetting struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Reason  string    `json:"reason,omitempty"`
+	ActorID uuid.UUID `json:"actor_id"`
+	At      int64     `json:"at"`
+}
+
+type accountTOTPSetting struct {
+	Enabled       bool     `json:"enabled"`
+	Secret        string   `json:"secret,omitempty"`
+	PendingSecret string   `json:"pending_secret,omitempty"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	LastStep      int64    `json:"last_step,omitempty"`
 }
 
 func (row accountRow) TableName() string {
@@ -106,17 +238,41 @@ func (row accountRow) CacheOption() entity.CacheOption {
 
 func (row *accountRow) Set(_ context.Context, a *domain.Account) error {
 	setting := accountRowSetting{
-		PasswordSalt: a.PasswordSalt,
-		SessionSalt:  a.SessionSalt,
+		PasswordSalt:     a.PasswordSalt,
+		SessionSalt:      a.SessionSalt,
+		Language:         a.Language,
+		EmailRevertNonce: a.EmailRevertNonce,
+	}
+	if v := a.TOTP; v.Enabled || v.PendingSecret != "" {
+		setting.TOTP = &accountTOTPSetting{
+			Enabled:       v.Enabled,
+			Secret:        v.Secret,
+			PendingSecret: v.PendingSecret,
+			RecoveryCodes: v.RecoveryCodes,
+			LastStep:      v.LastStep,
+		}
+	}
+	if v := a.StatusChange; v != nil {
+		setting.StatusChange = &accountStatusSetting{
+			From:    v.From,
+			To:      v.To,
+			Reason:  v.Reason,
+			ActorID: v.ActorID,
+			At:      v.At.Unix(),
+		}
+		row.StatusUpdateAt = v.At.Unix()
 	}
 	if err := row.Setting.Set(setting); err != nil {
 		return fmt.Errorf("set setting, %w", err)
 	}
+	row.EmailVerified = a.EmailVerified
+	row.Status = cmp.Or(a.Status, domain.AccountActive)
 
 	return errors.Join(
 		row.SetID(a.ID),
 		database.SetText(&row.Email, a.Email),
-		database.SetText(&row.Password, a.Password),
+		// password字段不允许null，三方账号注册和已注销的账号没有密码，保存为空字符串
+		row.Password.Set(a.Password),
 	)
 }
 
@@ -126,11 +282,73 @@ func (row accountRow) ToDomainObject() (*domain.Account, error) {
 		return nil, fmt.Errorf("decode setting, %w", err)
 	}
 
-	return &domain.Account{
-		ID:           row.ID.Bytes,
-		Email:        row.Email.String,
-		Password:     row.Password.String,
-		PasswordSalt: setting.PasswordSalt,
-		SessionSalt:  setting.SessionSalt,
-	}, nil
+	account := &domain.Account{
+		ID:            row.ID.Bytes,
+		Email:         row.Email.String,
+		EmailVerified: row.EmailVerified,
+		Status:        row.Status,
+		Password:      row.Password.String,
+		PasswordSalt:  setting.PasswordSalt,
+		SessionSalt:   setting.SessionSalt,
+		Language:      setting.Language,
+		CreateAt:      time.Unix(row.CreateAt, 0),
+
+		EmailRevertNonce: setting.EmailRevertNonce,
+	}
+	if v := setting.TOTP; v != nil {
+		account.TOTP = domain.TOTP{
+			Enabled:       v.Enabled,
+			Secret:        v.Secret,
+			PendingSecret: v.PendingSecret,
+			RecoveryCodes: v.RecoveryCodes,
+			LastStep:      v.LastStep,
+		}
+	}
+	if v := setting.StatusChange; v != nil {
+		account.StatusChange = &domain.AccountStatusChange{
+			From:    v.From,
+			To:      v.To,
+			Reason:  v.Reason,
+			ActorID: v.ActorID,
+			At:      time.Unix(v.At, 0),
+		}
+	}
+	return account, nil
+}
+
+// loadAccountRoles 批量读取账号的角色
+func loadAccountRoles(ctx context.Context, db entity.DB, accounts ...*domain.Account) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(accounts))
+	for _, a := range accounts {
+		ids = append(ids, a.ID.String())
+	}
+
+	stmt := selectFrom(db, tableAccountRoles).
+		Where(colAccountID.In(ids)).
+		Order(colRole.Asc())
+
+	var rows []accountRoleRow
+	if err := entity.GetRecords(ctx, &rows, db, stmt); err != nil {
+		return fmt.Errorf("load roles, %w", err)
+	}
+
+	roles := make(map[uuid.UUID][]string, len(accounts))
+	for _, row := range rows {
+		id := uuid.UUID(row.AccountID.Bytes)
+		roles[id] = append(roles[id], row.Role)
+	}
+	for _, a := range accounts {
+		a.Roles = roles[a.ID]
+	}
+	return nil
+}
+
+type accountRoleRow struct {
+	AccountID pgtype.UUID `db:"account_id"`
+	Role      string      `db:"role"`
+	CreateAt  int64       `db:"create_at"`
 }
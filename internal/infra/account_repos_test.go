@@ -1,5 +1,5 @@
-//go:build dbtest
-// +build dbtest
+//go:build dbtest || pgtest
+// +build dbtest pgtest
 
 package infra
 
@@ -8,11 +8,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 	"time"
 
 	"ddd-example/internal/domain"
 
+	"github.com/google/uuid"
 	"github.com/joyparty/entity"
 )
 
@@ -57,6 +59,137 @@ func TestAccountRepository(t *testing.T) {
 					return err
 				},
 			},
+			{
+				Name: "TOTP",
+				Func: func() error {
+					account, err := repos.FindByEmail(ctx, email)
+					if err != nil {
+						return err
+					}
+
+					secret, _, err := account.EnrollTOTP("test")
+					if err != nil {
+						return fmt.Errorf("enroll totp, %w", err)
+					} else if err := repos.Update(ctx, account); err != nil {
+						return fmt.Errorf("update account, %w", err)
+					}
+
+					account, err = repos.Find(ctx, account.ID)
+					if err != nil {
+						return err
+					} else if account.TOTP.PendingSecret != secret {
+						return errors.New("pending totp secret not saved")
+					}
+					return nil
+				},
+			},
+			{
+				Name: "EmailRevertNonce",
+				Func: func() error {
+					account, err := repos.FindByEmail(ctx, email)
+					if err != nil {
+						return err
+					}
+
+					account.EmailRevertNonce = "nonce"
+					if err := repos.Update(ctx, account); err != nil {
+						return fmt.Errorf("update account, %w", err)
+					}
+
+					account, err = repos.Find(ctx, account.ID)
+					if err != nil {
+						return err
+					} else if account.EmailRevertNonce != "nonce" {
+						return fmt.Errorf("email revert nonce not saved, got %q", account.EmailRevertNonce)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Status",
+				Func: func() error {
+					account, err := repos.FindByEmail(ctx, email)
+					if err != nil {
+						return err
+					}
+
+					actor := uuid.New()
+					if err := account.Lock("compromised", actor); err != nil {
+						return err
+					} else if err := repos.Update(ctx, account); err != nil {
+						return fmt.Errorf("update account, %w", err)
+					}
+
+					account, err = repos.Find(ctx, account.ID)
+					if err != nil {
+						return err
+					} else if account.Status != domain.AccountLocked {
+						return fmt.Errorf("status not saved, got %q", account.Status)
+					} else if v := account.StatusChange; v == nil || v.Reason != "compromised" || v.ActorID != actor {
+						return fmt.Errorf("status change not saved, got %+v", v)
+					}
+
+					if err := account.Unlock(actor); err != nil {
+						return err
+					}
+					return repos.Update(ctx, account)
+				},
+			},
+			{
+				Name: "Roles",
+				Func: func() error {
+					account, err := repos.FindByEmail(ctx, email)
+					if err != nil {
+						return err
+					}
+
+					account.AssignRole("support")
+					account.AssignRole(domain.RoleSuperAdmin)
+					if err := repos.Update(ctx, account); err != nil {
+						return fmt.Errorf("assign roles, %w", err)
+					}
+
+					account, err = repos.Find(ctx, account.ID)
+					if err != nil {
+						return err
+					} else if !slices.Equal(account.Roles, []string{domain.RoleSuperAdmin, "support"}) {
+						return fmt.Errorf("roles not saved, got %v", account.Roles)
+					}
+
+					account.RevokeRole("support")
+					if err := repos.Update(ctx, account); err != nil {
+						return fmt.Errorf("revoke role, %w", err)
+					}
+
+					account, err = repos.FindByEmail(ctx, email)
+					if err != nil {
+						return err
+					} else if !slices.Equal(account.Roles, []string{domain.RoleSuperAdmin}) {
+						return fmt.Errorf("role not revoked, got %v", account.Roles)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Delete",
+				Func: func() error {
+					account, err := repos.FindByEmail(ctx, email)
+					if err != nil {
+						return err
+					} else if err := repos.Delete(ctx, account.ID); err != nil {
+						return err
+					}
+
+					if _, err := repos.Find(ctx, account.ID); !errors.Is(err, domain.ErrAccountNotFound) {
+						return fmt.Errorf("expected %v, got %v", domain.ErrAccountNotFound, err)
+					} else if err := loadAccountRoles(ctx, tx, account); err != nil {
+						return err
+					} else if len(account.Roles) > 0 {
+						return fmt.Errorf("roles not deleted, got %v", account.Roles)
+					}
+					return nil
+				},
+			},
 		}.Execute()
 	}); !errors.Is(err, errRollbackTest) {
 		t.Fatalf("account repository, %v", err)
@@ -0,0 +1,197 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/database"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+	"github.com/samber/do/v2"
+)
+
+// dataExportDBRepository 个人数据导出记录，数据库存储
+type dataExportDBRepository struct {
+	db   entity.DB
+	base *entity.DomainObjectRepository[uuid.UUID, *domain.DataExport, *dataExportRow]
+}
+
+// DataExportRepositoryProvider 数据导出仓库提供者
+func DataExportRepositoryProvider(injector do.Injector) (adapter.DataExportRepository, error) {
+	return newDataExportDBRepository(do.MustInvoke[*sqlx.DB](injector)), nil
+}
+
+// NewDataExportRepository returns data export repository.
+func NewDataExportRepository(db entity.DB) adapter.DataExportRepository {
+	return newDataExportDBRepository(db)
+}
+
+func newDataExportDBRepository(db entity.DB) *dataExportDBRepository {
+	return &dataExportDBRepository{
+		db: db,
+		base: entity.NewDomainObjectRepository(
+			entity.NewRepository[uuid.UUID, *dataExportRow](db),
+		),
+	}
+}
+
+// Find 使用ID查找
+func (r *dataExportDBRepository) Find(ctx context.Context, exportID uuid.UUID) (*domain.DataExport, error) {
+	e, err := r.base.Find(ctx, exportID)
+	if entity.IsNotFound(err) {
+		return nil, domain.ErrDataExportNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ListByAccount 账号的导出记录，最新的在前
+func (r *dataExportDBRepository) ListByAccount(ctx context.Context, accountID uuid.UUID, limit int) ([]*domain.DataExport, error) {
+	stmt := selectFrom(r.db, tableDataExports).
+		Where(colAccountID.Eq(accountID.String())).
+		Order(colCreateAt.Desc(), colID.Desc()).
+		Limit(uint(limit))
+
+	return r.base.Query(ctx, stmt)
+}
+
+// Create 保存新记录
+func (r *dataExportDBRepository) Create(ctx context.Context, export *domain.DataExport) error {
+	return r.base.Create(ctx, export)
+}
+
+// Update 保存生成结果
+func (r *dataExportDBRepository) Update(ctx context.Context, export *domain.DataExport) error {
+	return r.base.Update(ctx, export)
+}
+
+// Delete 删除记录，不删除导出文件
+func (r *dataExportDBRepository) Delete(ctx context.Context, exportID uuid.UUID) error {
+	stmt := deleteFrom(r.db, tableDataExports).Where(colID.Eq(exportID.String()))
+
+	_, err := entity.ExecDelete(ctx, r.db, stmt)
+	return err
+}
+
+// Claim 领取到期的待生成记录，与outbox相同使用next_attempt_at做乐观锁
+func (r *dataExportDBRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*domain.DataExport, error) {
+	now := time.Now()
+	stmt := selectFrom(r.db, tableDataExports).
+		Where(
+			colStatus.Eq(domain.DataExportPending),
+			colNextAttemptAt.Lte(now.Unix()),
+		).
+		Order(colNextAttemptAt.Asc(), colID.Asc()).
+		Limit(uint(limit))
+
+	var rows []dataExportRow
+	if err := entity.GetRecords(ctx, &rows, r.db, stmt); err != nil {
+		return nil, err
+	}
+
+	until := now.Add(lease).Unix()
+	result := make([]*domain.DataExport, 0, len(rows))
+	for _, row := range rows {
+		stmt := updateTable(r.db, tableDataExports).
+			Set(goqu.Record{
+				"next_attempt_at": until,
+				"update_at":       now.Unix(),
+			}).
+			Where(
+				colID.Eq(row.GetID().String()),
+				colStatus.Eq(domain.DataExportPending),
+				colNextAttemptAt.Eq(row.NextAttemptAt),
+			)
+
+		res, err := entity.ExecUpdate(ctx, r.db, stmt)
+		if err != nil {
+			return nil, fmt.Errorf("claim data export, %w", err)
+		} else if n, err := res.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("claim data export, %w", err)
+		} else if n == 0 {
+			continue
+		}
+
+		row.NextAttemptAt = until
+		e, err := row.ToDomainObject()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// ListExpired 已生成或者已失败，并且在before之前过期的记录
+func (r *dataExportDBRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.DataExport, error) {
+	stmt := selectFrom(r.db, tableDataExports).
+		Where(
+			colStatus.Neq(domain.DataExportPending),
+			colExpireAt.Lt(before.Unix()),
+		).
+		Order(colExpireAt.Asc(), colID.Asc()).
+		Limit(uint(limit))
+
+	return r.base.Query(ctx, stmt)
+}
+
+type dataExportRow struct {
+	baseRow
+
+	AccountID     pgtype.UUID `db:"account_id"`
+	Status        string      `db:"status"`
+	File          string      `db:"file"`
+	Size          int64       `db:"size"`
+	Attempts      int         `db:"attempts"`
+	LastError     pgtype.Text `db:"last_error"`
+	NextAttemptAt int64       `db:"next_attempt_at"`
+	ExpireAt      int64       `db:"expire_at"`
+}
+
+func (row dataExportRow) TableName() string {
+	return "data_exports"
+}
+
+func (row *dataExportRow) Set(_ context.Context, e *domain.DataExport) error {
+	row.Status = e.Status
+	row.File = e.File
+	row.Size = e.Size
+	row.Attempts = e.Attempts
+	row.NextAttemptAt = e.NextAttemptAt.Unix()
+	if !e.ExpireAt.IsZero() {
+		row.ExpireAt = e.ExpireAt.Unix()
+	}
+
+	return errors.Join(
+		row.SetID(e.ID),
+		database.SetUUID(&row.AccountID, e.AccountID),
+		database.SetText(&row.LastError, e.LastError),
+	)
+}
+
+func (row dataExportRow) ToDomainObject() (*domain.DataExport, error) {
+	e := &domain.DataExport{
+		ID:            row.ID.Bytes,
+		AccountID:     row.AccountID.Bytes,
+		Status:        row.Status,
+		File:          row.File,
+		Size:          row.Size,
+		Attempts:      row.Attempts,
+		LastError:     row.LastError.String,
+		NextAttemptAt: time.Unix(row.NextAttemptAt, 0),
+		CreateAt:      time.Unix(row.CreateAt, 0),
+	}
+	if row.ExpireAt > 0 {
+		e.ExpireAt = time.Unix(row.ExpireAt, 0)
+	}
+	return e, nil
+}
@@ -0,0 +1,186 @@
+//go:build dbtest || pgtest
+// +build dbtest pgtest
+
+package infra
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/joyparty/entity"
+)
+
+func TestDataExportRepository(t *testing.T) {
+	if err := entity.Transaction(testDB, func(db entity.DB) (err error) {
+		defer func() {
+			err = cmp.Or(err, errRollbackTest)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		repos := newDataExportDBRepository(db)
+		accountID := uuid.Must(uuid.NewV7())
+
+		export, err := domain.NewDataExport(accountID)
+		if err != nil {
+			return err
+		}
+
+		return testTable{
+			{
+				Name: "Create",
+				Func: func() error {
+					if err := repos.Create(ctx, export); err != nil {
+						return err
+					}
+
+					list, err := repos.ListByAccount(ctx, accountID, 10)
+					if err != nil {
+						return err
+					} else if len(list) != 1 || list[0].ID != export.ID || list[0].Status != domain.DataExportPending {
+						return fmt.Errorf("unexpected exports %v", list)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Claim",
+				Func: func() error {
+					claimed, err := repos.Claim(ctx, 10, time.Minute)
+					if err != nil {
+						return err
+					} else if len(claimed) != 1 || claimed[0].ID != export.ID {
+						return fmt.Errorf("expected 1 export, got %d", len(claimed))
+					}
+
+					// lease期间不会被再次领取
+					if again, err := repos.Claim(ctx, 10, time.Minute); err != nil {
+						return err
+					} else if len(again) != 0 {
+						return errors.New("claimed twice")
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Complete",
+				Func: func() error {
+					export.Complete(1024, time.Now())
+					if err := repos.Update(ctx, export); err != nil {
+						return err
+					}
+
+					found, err := repos.Find(ctx, export.ID)
+					if err != nil {
+						return err
+					} else if found.Status != domain.DataExportReady || found.Size != 1024 || found.ExpireAt.IsZero() {
+						return fmt.Errorf("export not saved, got %+v", found)
+					}
+
+					if list, err := repos.ListExpired(ctx, time.Now(), 10); err != nil {
+						return err
+					} else if len(list) != 0 {
+						return errors.New("export should not be expired")
+					} else if list, err := repos.ListExpired(ctx, found.ExpireAt.Add(time.Second), 10); err != nil {
+						return err
+					} else if len(list) != 1 {
+						return fmt.Errorf("expected 1 expired export, got %d", len(list))
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Delete",
+				Func: func() error {
+					if err := repos.Delete(ctx, export.ID); err != nil {
+						return err
+					} else if _, err := repos.Find(ctx, export.ID); !errors.Is(err, domain.ErrDataExportNotFound) {
+						return fmt.Errorf("expected %v, got %v", domain.ErrDataExportNotFound, err)
+					}
+					return nil
+				},
+			},
+		}.Execute()
+	}); !errors.Is(err, errRollbackTest) {
+		t.Fatalf("data export repository, %v", err)
+	}
+}
+
+func TestAccountEventRepository(t *testing.T) {
+	if err := entity.Transaction(testDB, func(db entity.DB) (err error) {
+		defer func() {
+			err = cmp.Or(err, errRollbackTest)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		repos := NewAccountEventRepository(db)
+		accountID := uuid.Must(uuid.NewV7())
+
+		return testTable{
+			{
+				Name: "Add",
+				Func: func() error {
+					now := time.Now()
+					for i, typ := range []string{"account.login", "account.logout", "account.login"} {
+						ev := &adapter.AccountEvent{
+							ID:         uuid.Must(uuid.NewV7()),
+							AccountID:  accountID,
+							Type:       typ,
+							Data:       []byte(`{"ip":"127.0.0.1"}`),
+							OccurredAt: now.Add(time.Duration(i) * time.Second),
+						}
+						if err := repos.Add(ctx, ev); err != nil {
+							return err
+						} else if err := repos.Add(ctx, ev); err != nil {
+							return fmt.Errorf("add duplicate event, %w", err)
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Name: "ListByAccount",
+				Func: func() error {
+					if list, err := repos.ListByAccount(ctx, accountID, nil, 10); err != nil {
+						return err
+					} else if len(list) != 3 || list[0].Type != "account.login" {
+						return fmt.Errorf("unexpected events %v", list)
+					}
+
+					if list, err := repos.ListByAccount(ctx, accountID, []string{"account.logout"}, 10); err != nil {
+						return err
+					} else if len(list) != 1 || string(list[0].Data) == "" {
+						return fmt.Errorf("unexpected events %v", list)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "DeleteByAccount",
+				Func: func() error {
+					if err := repos.DeleteByAccount(ctx, accountID); err != nil {
+						return err
+					} else if list, err := repos.ListByAccount(ctx, accountID, nil, 10); err != nil {
+						return err
+					} else if len(list) != 0 {
+						return fmt.Errorf("events not deleted, got %d", len(list))
+					}
+					return nil
+				},
+			},
+		}.Execute()
+	}); !errors.Is(err, errRollbackTest) {
+		t.Fatalf("account event repository, %v", err)
+	}
+}
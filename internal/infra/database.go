@@ -9,7 +9,9 @@ import (
 	"ddd-example/pkg/database"
 
 	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres" // postgres dialect
 	"github.com/doug-martin/goqu/v9/dialect/sqlite3"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 	"github.com/joyparty/entity"
@@ -23,16 +25,39 @@ func init() {
 var (
 	sqlite = sync.OnceValue(sqliteDialect)
 
-	tableAccounts  = goqu.T((accountRow{}).TableName())
-	selectAccounts = sqlite().From(tableAccounts).Prepared(true)
-
-	tableOauth  = goqu.T((oauthRow{}).TableName())
-	selectOauth = sqlite().From(tableOauth).Prepared(true)
-
-	colAccountID = goqu.C("account_id")
-	colEmail     = goqu.C("email")
-	colVendor    = goqu.C("vendor")
-	colVendorUID = goqu.C("vendor_uid")
+	tableAccounts = goqu.T((accountRow{}).TableName())
+	tableOauth    = goqu.T((oauthRow{}).TableName())
+	tableSessions = goqu.T((sessionRow{}).TableName())
+	tableOutbox   = goqu.T((outboxRow{}).TableName())
+	tableWebhooks = goqu.T((webhookRow{}).TableName())
+	tableRoles    = goqu.T((roleRow{}).TableName())
+
+	tableRolePermissions = goqu.T("role_permissions")
+	tableAccountRoles    = goqu.T("account_roles")
+	tableAccountEvents   = goqu.T("account_events")
+
+	tableWebhookDeliveries = goqu.T((webhookDeliveryRow{}).TableName())
+	tableDataExports       = goqu.T((dataExportRow{}).TableName())
+
+	colID            = goqu.C("id")
+	colAccountID     = goqu.C("account_id")
+	colEmail         = goqu.C("email")
+	colName          = goqu.C("name")
+	colRole          = goqu.C("role")
+	colPermission    = goqu.C("permission")
+	colVendor        = goqu.C("vendor")
+	colVendorUID     = goqu.C("vendor_uid")
+	colWebhookID     = goqu.C("webhook_id")
+	colEventID       = goqu.C("event_id")
+	colType          = goqu.C("type")
+	colStatus        = goqu.C("status")
+	colStatusUpdate  = goqu.C("status_update_at")
+	colCreateAt      = goqu.C("create_at")
+	colUpdateAt      = goqu.C("update_at")
+	colLastSeenAt    = goqu.C("last_seen_at")
+	colNextAttemptAt = goqu.C("next_attempt_at")
+	colExpireAt      = goqu.C("expire_at")
+	colOccurredAt    = goqu.C("occurred_at")
 )
 
 type baseRow struct {
@@ -71,6 +96,35 @@ func (base *baseRow) SetID(id uuid.UUID) error {
 	return database.SetUUID(&base.ID, id)
 }
 
+// selectFrom 按数据库类型构造查询语句
+func selectFrom(db entity.DB, table exp.IdentifierExpression) *goqu.SelectDataset {
+	return dialect(db).From(table).Prepared(true)
+}
+
+// insertInto 按数据库类型构造插入语句
+func insertInto(db entity.DB, table exp.IdentifierExpression) *goqu.InsertDataset {
+	return dialect(db).Insert(table).Prepared(true)
+}
+
+// updateTable 按数据库类型构造更新语句
+func updateTable(db entity.DB, table exp.IdentifierExpression) *goqu.UpdateDataset {
+	return dialect(db).Update(table).Prepared(true)
+}
+
+// deleteFrom 按数据库类型构造删除语句
+func deleteFrom(db entity.DB, table exp.IdentifierExpression) *goqu.DeleteDataset {
+	return dialect(db).Delete(table).Prepared(true)
+}
+
+func dialect(db entity.DB) goqu.DialectWrapper {
+	switch db.DriverName() {
+	case "pgx", "postgres":
+		return goqu.Dialect("postgres")
+	default:
+		return sqlite()
+	}
+}
+
 func sqliteDialect() goqu.DialectWrapper {
 	sqliteOptions := sqlite3.DialectOptions()
 	sqliteOptions.SupportsConflictUpdateWhere = true
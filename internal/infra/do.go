@@ -4,8 +4,19 @@ import "github.com/samber/do/v2"
 
 // Providers 依赖注入配置
 var Providers = do.Package(
-	do.Eager(NewMemoryCache()),
+	do.Lazy(CacherProvider),
+	do.Lazy(FileStorageProvider),
+	do.Lazy(MailerProvider),
 
+	do.Lazy(AccountEventRepositoryProvider),
+	do.Lazy(AccountQueryProvider),
 	do.Lazy(AccountRepositoryProvider),
+	do.Lazy(DataExportRepositoryProvider),
 	do.Lazy(OauthRepositoryProvider),
+	do.Lazy(OutboxRepositoryProvider),
+	do.Lazy(RoleRepositoryProvider),
+	do.Lazy(SessionRepositoryProvider),
+	do.Lazy(WebhookDeliveryRepositoryProvider),
+	do.Lazy(WebhookRepositoryProvider),
+	do.Lazy(WebhookSenderProvider),
 )
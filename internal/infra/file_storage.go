@@ -0,0 +1,13 @@
+package infra
+
+import (
+	"ddd-example/internal/app/adapter"
+	"ddd-example/pkg/storage"
+
+	"github.com/samber/do/v2"
+)
+
+// FileStorageProvider 文件存储提供者，目前只支持本地目录
+func FileStorageProvider(injector do.Injector) (adapter.FileStorage, error) {
+	return do.MustInvoke[*storage.Local](injector), nil
+}
@@ -0,0 +1,161 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/pkg/logger"
+	"ddd-example/pkg/mail"
+
+	"github.com/samber/do/v2"
+)
+
+var (
+	errMailQueueFull = errors.New("mail queue full")
+	errMailerClosed  = errors.New("mailer closed")
+)
+
+// 邮件发送策略，变量方便测试
+var (
+	mailQueueSize   = 100
+	mailWorkers     = 2
+	mailMaxAttempts = 5
+	// 第一次重试的间隔，之后每次翻倍
+	mailRetryBackoff = 5 * time.Second
+)
+
+// MailerProvider 邮件发送提供者
+func MailerProvider(injector do.Injector) (adapter.Mailer, error) {
+	return newAsyncMailer(do.MustInvoke[mail.Sender](injector)), nil
+}
+
+// asyncMailer 在后台发送邮件，失败时按指数退避重试
+//
+// 缓慢的邮件服务器不会阻塞调用方，队列满了之后直接返回错误
+type asyncMailer struct {
+	sender mail.Sender
+	queue  chan mailJob
+	wg     sync.WaitGroup
+
+	// 关闭之后取消还在等待重试的邮件
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+type mailJob struct {
+	ctx context.Context
+	msg *mail.Message
+}
+
+func newAsyncMailer(sender mail.Sender) *asyncMailer {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &asyncMailer{
+		sender: sender,
+		queue:  make(chan mailJob, mailQueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for range mailWorkers {
+		m.wg.Add(1)
+		go m.work()
+	}
+	return m
+}
+
+// Send 把邮件放入发送队列
+func (m *asyncMailer) Send(ctx context.Context, msg *adapter.Mail) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return errMailerClosed
+	}
+
+	job := mailJob{
+		// 保留日志等上下文信息，但不受调用方取消的影响
+		ctx: context.WithoutCancel(ctx),
+		msg: &mail.Message{
+			To:      []string{msg.To},
+			Subject: msg.Subject,
+			Text:    msg.Text,
+			HTML:    msg.HTML,
+		},
+	}
+
+	select {
+	case m.queue <- job:
+		return nil
+	default:
+		return errMailQueueFull
+	}
+}
+
+// Shutdown 停止接收新邮件，等待队列中的邮件发送完成，ctx取消后放弃剩余的重试
+func (m *asyncMailer) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.cancel()
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		<-done
+		return fmt.Errorf("shutdown mailer, %w", ctx.Err())
+	}
+}
+
+func (m *asyncMailer) work() {
+	defer m.wg.Done()
+
+	for job := range m.queue {
+		if err := m.deliver(job); err != nil {
+			logger.Error(job.ctx, "send mail", "to", job.msg.To, "subject", job.msg.Subject, "error", err)
+		}
+	}
+}
+
+func (m *asyncMailer) deliver(job mailJob) error {
+	backoff := mailRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := m.sender.Send(job.ctx, job.msg)
+		if err == nil {
+			return nil
+		} else if attempt >= mailMaxAttempts {
+			return fmt.Errorf("give up after %d attempts, %w", attempt, err)
+		}
+
+		logger.Warn(job.ctx, "send mail, retry later",
+			"to", job.msg.To,
+			"attempt", attempt,
+			"backoff", backoff,
+			"error", err,
+		)
+
+		select {
+		case <-m.ctx.Done():
+			return fmt.Errorf("mailer closed, %w", err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,61 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/pkg/mail"
+)
+
+// flakySender 前几次发送失败
+type flakySender struct {
+	failures int32
+	calls    atomic.Int32
+	memory   *mail.MemorySender
+}
+
+func (s *flakySender) Send(ctx context.Context, msg *mail.Message) error {
+	if s.calls.Add(1) <= s.failures {
+		return errors.New("temporary failure")
+	}
+	return s.memory.Send(ctx, msg)
+}
+
+func TestAsyncMailer(t *testing.T) {
+	defer func(v time.Duration) { mailRetryBackoff = v }(mailRetryBackoff)
+	mailRetryBackoff = time.Millisecond
+
+	sender := &flakySender{
+		failures: 2,
+		memory:   mail.NewMemorySender("noreply@example.com"),
+	}
+	m := newAsyncMailer(sender)
+
+	if err := m.Send(context.Background(), &adapter.Mail{
+		To:      "test@example.com",
+		Subject: "hello",
+		Text:    "hello world",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if messages := sender.memory.Messages(); len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	} else if n := sender.calls.Load(); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+
+	if err := m.Send(context.Background(), &adapter.Mail{To: "test@example.com"}); !errors.Is(err, errMailerClosed) {
+		t.Fatalf("send after shutdown, expected %v, got %v", errMailerClosed, err)
+	}
+}
@@ -2,6 +2,7 @@ package infra
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"ddd-example/internal/app/adapter"
@@ -13,6 +14,8 @@ import (
 // memoryCache 本地内存缓存
 type memoryCache struct {
 	values *cache.Cache
+	// 保证Take的读取和删除不会被其它Take打断
+	takeMu sync.Mutex
 }
 
 // NewMemoryCache 内存缓存
@@ -41,3 +44,16 @@ func (mc *memoryCache) Delete(_ context.Context, key string) error {
 	mc.values.Delete(key)
 	return nil
 }
+
+// Take 读取并删除缓存
+func (mc *memoryCache) Take(_ context.Context, key string) ([]byte, error) {
+	mc.takeMu.Lock()
+	defer mc.takeMu.Unlock()
+
+	v, ok := mc.values.Get(key)
+	if !ok {
+		return nil, domain.ErrMissingCache
+	}
+	mc.values.Delete(key)
+	return v.([]byte), nil
+}
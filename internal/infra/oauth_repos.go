@@ -37,7 +37,7 @@ func NewOauthRepository(db entity.DB) adapter.OauthRepository {
 
 // Find 查询关联用户ID
 func (r *oauthDBRepository) Find(ctx context.Context, vendor, vendorUID string) (uuid.UUID, error) {
-	stmt := selectOauth.
+	stmt := selectFrom(r.db, tableOauth).
 		Select(colAccountID).
 		Where(
 			colVendor.Eq(vendor),
@@ -56,26 +56,80 @@ func (r *oauthDBRepository) Find(ctx context.Context, vendor, vendorUID string)
 }
 
 // Bind 账号绑定
+//
+// 三方账号已经绑定了其它账号时返回domain.ErrOauthBoundToOther，不会覆盖原有绑定
 func (r *oauthDBRepository) Bind(ctx context.Context, accountID uuid.UUID, vendor, vendorUID string) error {
+	if boundID, err := r.Find(ctx, vendor, vendorUID); err == nil {
+		if boundID != accountID {
+			return domain.ErrOauthBoundToOther
+		}
+		return nil
+	} else if !errors.Is(err, domain.ErrAccountNotFound) {
+		return fmt.Errorf("find bound account, %w", err)
+	}
+
 	row := &oauthRow{}
-	if err := row.SetID(oauthID{AccountID: accountID, Vendor: vendor}); err != nil {
+	if err := row.SetID(oauthID{Vendor: vendor, VendorUID: vendorUID}); err != nil {
 		return fmt.Errorf("set oauth id: %w", err)
-	} else if err := database.SetText(&row.VendorID, vendorUID); err != nil {
-		return fmt.Errorf("set vendor_uid: %w", err)
+	} else if err := database.SetUUID(&row.AccountID, accountID); err != nil {
+		return fmt.Errorf("set account_id: %w", err)
+	}
+
+	// 并发绑定时由主键保证唯一
+	if _, err := entity.Insert(ctx, row, r.db); errors.Is(err, entity.ErrConflict) {
+		return domain.ErrOauthBoundToOther
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListByAccount 账号绑定的所有三方账号
+func (r *oauthDBRepository) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]*domain.OauthIdentity, error) {
+	stmt := selectFrom(r.db, tableOauth).
+		Where(colAccountID.Eq(accountID.String())).
+		Order(colCreateAt.Asc())
+
+	var rows []oauthRow
+	if err := entity.GetRecords(ctx, &rows, r.db, stmt); err != nil {
+		return nil, err
 	}
 
-	return entity.Upsert(ctx, row, r.db)
+	result := make([]*domain.OauthIdentity, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row.toDomainObject())
+	}
+	return result, nil
+}
+
+// Unbind 解除账号与指定站点所有三方账号的绑定
+func (r *oauthDBRepository) Unbind(ctx context.Context, accountID uuid.UUID, vendor string) error {
+	stmt := deleteFrom(r.db, tableOauth).Where(
+		colAccountID.Eq(accountID.String()),
+		colVendor.Eq(vendor),
+	)
+
+	_, err := entity.ExecDelete(ctx, r.db, stmt)
+	return err
+}
+
+// UnbindAll 解除账号的所有三方账号绑定
+func (r *oauthDBRepository) UnbindAll(ctx context.Context, accountID uuid.UUID) error {
+	stmt := deleteFrom(r.db, tableOauth).Where(colAccountID.Eq(accountID.String()))
+
+	_, err := entity.ExecDelete(ctx, r.db, stmt)
+	return err
 }
 
 type oauthID struct {
-	AccountID uuid.UUID
 	Vendor    string
+	VendorUID string
 }
 
 type oauthRow struct {
-	AccountID pgtype.UUID `db:"account_id,primaryKey"`
+	AccountID pgtype.UUID `db:"account_id"`
 	Vendor    pgtype.Text `db:"vendor,primaryKey"`
-	VendorID  pgtype.Text `db:"vendor_uid"`
+	VendorID  pgtype.Text `db:"vendor_uid,primaryKey"`
 	CreateAt  pgtype.Int4 `db:"create_at,refuseUpdate"`
 	UpdateAt  pgtype.Int4 `db:"update_at"`
 }
@@ -105,10 +159,19 @@ func (row *oauthRow) BeforeUpdate(_ context.Context) error {
 }
 
 func (row *oauthRow) SetID(id oauthID) error {
-	if err := database.SetUUID(&row.AccountID, id.AccountID); err != nil {
-		return fmt.Errorf("set account_id: %w", err)
-	} else if err := database.SetText(&row.Vendor, id.Vendor); err != nil {
+	if err := database.SetText(&row.Vendor, id.Vendor); err != nil {
 		return fmt.Errorf("set vendor: %w", err)
+	} else if err := database.SetText(&row.VendorID, id.VendorUID); err != nil {
+		return fmt.Errorf("set vendor_uid: %w", err)
 	}
 	return nil
 }
+
+func (row oauthRow) toDomainObject() *domain.OauthIdentity {
+	return &domain.OauthIdentity{
+		AccountID: row.AccountID.Bytes,
+		Vendor:    row.Vendor.String,
+		VendorUID: row.VendorID.String,
+		CreateAt:  time.Unix(int64(row.CreateAt.Int), 0),
+	}
+}
@@ -1,5 +1,5 @@
-//go:build dbtest
-// +build dbtest
+//go:build dbtest || pgtest
+// +build dbtest pgtest
 
 package infra
 
@@ -59,6 +59,72 @@ func TestOauthDBRepository(t *testing.T) {
 					return nil
 				},
 			},
+			{
+				Name: "Conflict",
+				Func: func() error {
+					// 重复绑定同一账号不报错
+					if err := repos.Bind(ctx, accountID, vendor, vendorUID); err != nil {
+						return fmt.Errorf("rebind same account, %w", err)
+					}
+
+					if err := repos.Bind(ctx, uuid.New(), vendor, vendorUID); !errors.Is(err, domain.ErrOauthBoundToOther) {
+						return fmt.Errorf("expected domain.ErrOauthBoundToOther, got %v", err)
+					} else if uid, err := repos.Find(ctx, vendor, vendorUID); err != nil {
+						return err
+					} else if uid != accountID {
+						return errors.New("binding should not be taken over")
+					}
+					return nil
+				},
+			},
+			{
+				Name: "ListByAccount",
+				Func: func() error {
+					// 同一站点可以绑定多个三方账号
+					if err := repos.Bind(ctx, accountID, vendor, uuid.New().String()); err != nil {
+						return err
+					} else if err := repos.Bind(ctx, accountID, "other", uuid.New().String()); err != nil {
+						return err
+					}
+
+					if list, err := repos.ListByAccount(ctx, accountID); err != nil {
+						return err
+					} else if len(list) != 3 {
+						return fmt.Errorf("expected 3 identities, got %d", len(list))
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Unbind",
+				Func: func() error {
+					if err := repos.Unbind(ctx, accountID, vendor); err != nil {
+						return err
+					}
+
+					if _, err := repos.Find(ctx, vendor, vendorUID); !errors.Is(err, domain.ErrAccountNotFound) {
+						return fmt.Errorf("expected domain.ErrAccountNotFound, got %v", err)
+					} else if list, err := repos.ListByAccount(ctx, accountID); err != nil {
+						return err
+					} else if len(list) != 1 || list[0].Vendor != "other" {
+						return fmt.Errorf("unexpected identities %v", list)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "UnbindAll",
+				Func: func() error {
+					if err := repos.UnbindAll(ctx, accountID); err != nil {
+						return err
+					} else if list, err := repos.ListByAccount(ctx, accountID); err != nil {
+						return err
+					} else if len(list) != 0 {
+						return fmt.Errorf("unexpected identities %v", list)
+					}
+					return nil
+				},
+			},
 		}.Execute()
 	}); !errors.Is(err, errRollbackTest) {
 		t.Fatalf("oauth repository, %v", err)
@@ -0,0 +1,174 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/pkg/database"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+	"github.com/samber/do/v2"
+)
+
+// outboxDBRepository 领域事件outbox，数据库存储
+type outboxDBRepository struct {
+	db entity.DB
+}
+
+// OutboxRepositoryProvider outbox仓库提供者
+func OutboxRepositoryProvider(injector do.Injector) (adapter.OutboxRepository, error) {
+	return NewOutboxRepository(do.MustInvoke[*sqlx.DB](injector)), nil
+}
+
+// NewOutboxRepository returns outbox repository.
+func NewOutboxRepository(db entity.DB) adapter.OutboxRepository {
+	return &outboxDBRepository{db: db}
+}
+
+// Add 写入待投递消息
+func (r *outboxDBRepository) Add(ctx context.Context, messages ...*adapter.OutboxMessage) error {
+	for _, msg := range messages {
+		row := &outboxRow{}
+		if err := row.Set(msg); err != nil {
+			return err
+		} else if _, err := entity.Insert(ctx, row, r.db); err != nil {
+			return fmt.Errorf("insert outbox message, %w", err)
+		}
+	}
+	return nil
+}
+
+// Claim 领取到期的待投递消息
+//
+// 先查询再使用next_attempt_at做乐观锁更新，更新成功的才算领取成功
+func (r *outboxDBRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*adapter.OutboxMessage, error) {
+	now := time.Now()
+	stmt := selectFrom(r.db, tableOutbox).
+		Where(
+			colStatus.Eq(adapter.OutboxPending),
+			colNextAttemptAt.Lte(now.Unix()),
+		).
+		Order(colNextAttemptAt.Asc(), colID.Asc()).
+		Limit(uint(limit))
+
+	var rows []outboxRow
+	if err := entity.GetRecords(ctx, &rows, r.db, stmt); err != nil {
+		return nil, err
+	}
+
+	until := now.Add(lease).Unix()
+	result := make([]*adapter.OutboxMessage, 0, len(rows))
+	for _, row := range rows {
+		stmt := updateTable(r.db, tableOutbox).
+			Set(goqu.Record{
+				"next_attempt_at": until,
+				"update_at":       now.Unix(),
+			}).
+			Where(
+				colID.Eq(row.GetID().String()),
+				colStatus.Eq(adapter.OutboxPending),
+				colNextAttemptAt.Eq(row.NextAttemptAt),
+			)
+
+		res, err := entity.ExecUpdate(ctx, r.db, stmt)
+		if err != nil {
+			return nil, fmt.Errorf("claim outbox message, %w", err)
+		} else if n, err := res.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("claim outbox message, %w", err)
+		} else if n == 0 {
+			// 被其它实例领取了
+			continue
+		}
+
+		row.NextAttemptAt = until
+		msg, err := row.toMessage()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, msg)
+	}
+	return result, nil
+}
+
+// Update 保存投递结果
+func (r *outboxDBRepository) Update(ctx context.Context, msg *adapter.OutboxMessage) error {
+	row := &outboxRow{}
+	if err := row.Set(msg); err != nil {
+		return err
+	}
+	return entity.Update(ctx, row, r.db)
+}
+
+// Purge 删除指定状态并且在before之前更新的消息
+func (r *outboxDBRepository) Purge(ctx context.Context, status string, before time.Time) (int64, error) {
+	stmt := deleteFrom(r.db, tableOutbox).Where(
+		colStatus.Eq(status),
+		colUpdateAt.Lt(before.Unix()),
+	)
+
+	res, err := entity.ExecDelete(ctx, r.db, stmt)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type outboxRow struct {
+	baseRow
+
+	Type          string      `db:"type"`
+	Payload       pgtype.JSON `db:"payload"`
+	Status        string      `db:"status"`
+	Attempts      int         `db:"attempts"`
+	NextAttemptAt int64       `db:"next_attempt_at"`
+	LastError     pgtype.Text `db:"last_error"`
+	Handled       pgtype.JSON `db:"handled"`
+}
+
+func (row outboxRow) TableName() string {
+	return "outbox"
+}
+
+func (row *outboxRow) Set(msg *adapter.OutboxMessage) error {
+	if err := row.SetID(msg.ID); err != nil {
+		return fmt.Errorf("set id, %w", err)
+	} else if err := row.Payload.Set(msg.Payload); err != nil {
+		return fmt.Errorf("set payload, %w", err)
+	} else if err := database.SetText(&row.LastError, msg.LastError); err != nil {
+		return fmt.Errorf("set last_error, %w", err)
+	} else if err := row.Handled.Set(msg.Handled); err != nil {
+		return fmt.Errorf("set handled, %w", err)
+	}
+
+	row.Type = msg.Type
+	row.Status = msg.Status
+	row.Attempts = msg.Attempts
+	row.NextAttemptAt = msg.NextAttemptAt.Unix()
+	row.CreateAt = msg.CreateAt.Unix()
+	return nil
+}
+
+func (row outboxRow) toMessage() (*adapter.OutboxMessage, error) {
+	msg := &adapter.OutboxMessage{
+		ID:            row.GetID(),
+		Type:          row.Type,
+		Payload:       row.Payload.Bytes,
+		Status:        row.Status,
+		Attempts:      row.Attempts,
+		NextAttemptAt: time.Unix(row.NextAttemptAt, 0),
+		LastError:     row.LastError.String,
+		CreateAt:      time.Unix(row.CreateAt, 0),
+	}
+	// 升级之前写入的消息没有这个字段
+	if row.Handled.Status == pgtype.Present {
+		if err := row.Handled.AssignTo(&msg.Handled); err != nil {
+			return nil, fmt.Errorf("decode handled, %w", err)
+		}
+	}
+	return msg, nil
+}
@@ -0,0 +1,127 @@
+//go:build dbtest || pgtest
+// +build dbtest pgtest
+
+package infra
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+
+	"github.com/google/uuid"
+	"github.com/joyparty/entity"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	if err := entity.Transaction(testDB, func(db entity.DB) (err error) {
+		defer func() {
+			err = cmp.Or(err, errRollbackTest)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		messages := []*adapter.OutboxMessage{
+			{
+				ID:            uuid.Must(uuid.NewV7()),
+				Type:          "Login",
+				Payload:       []byte(`{"n":1}`),
+				Status:        adapter.OutboxPending,
+				NextAttemptAt: now,
+				CreateAt:      now,
+			},
+			{
+				ID:            uuid.Must(uuid.NewV7()),
+				Type:          "Login",
+				Payload:       []byte(`{"n":2}`),
+				Status:        adapter.OutboxPending,
+				NextAttemptAt: now.Add(time.Hour), // 未到期
+				CreateAt:      now,
+			},
+		}
+
+		repos := NewOutboxRepository(db)
+
+		var claimed []*adapter.OutboxMessage
+		return testTable{
+			{
+				Name: "Add",
+				Func: func() error {
+					return repos.Add(ctx, messages...)
+				},
+			},
+			{
+				Name: "Claim",
+				Func: func() error {
+					claimed, err = repos.Claim(ctx, 10, time.Minute)
+					if err != nil {
+						return err
+					} else if len(claimed) != 1 {
+						return fmt.Errorf("expected 1 claimed message, got %d", len(claimed))
+					} else if msg := claimed[0]; msg.ID != messages[0].ID {
+						return errors.New("claimed wrong message")
+					} else if !bytes.Equal(msg.Payload, messages[0].Payload) {
+						return fmt.Errorf("unexpected payload %s", msg.Payload)
+					}
+
+					// 领取期内不能被再次领取
+					if again, err := repos.Claim(ctx, 10, time.Minute); err != nil {
+						return err
+					} else if len(again) != 0 {
+						return errors.New("claimed message should not be claimed again")
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Update",
+				Func: func() error {
+					msg := claimed[0]
+					msg.Attempts++
+					msg.LastError = "test"
+					msg.Handled = []string{"audit"}
+					msg.NextAttemptAt = time.Now().Add(-time.Second)
+					if err := repos.Update(ctx, msg); err != nil {
+						return err
+					}
+
+					// 重试时间到了之后可以再次领取
+					again, err := repos.Claim(ctx, 10, time.Minute)
+					if err != nil {
+						return err
+					} else if len(again) != 1 {
+						return fmt.Errorf("expected 1 claimed message, got %d", len(again))
+					} else if again[0].Attempts != 1 || again[0].LastError != "test" {
+						return errors.New("delivery result not saved")
+					} else if !slices.Equal(again[0].Handled, []string{"audit"}) {
+						return fmt.Errorf("unexpected handled %v", again[0].Handled)
+					}
+
+					msg.Status = adapter.OutboxDispatched
+					return repos.Update(ctx, msg)
+				},
+			},
+			{
+				Name: "Purge",
+				Func: func() error {
+					if n, err := repos.Purge(ctx, adapter.OutboxDispatched, time.Now().Add(time.Minute)); err != nil {
+						return err
+					} else if n != 1 {
+						return fmt.Errorf("expected 1 purged message, got %d", n)
+					}
+					return nil
+				},
+			},
+		}.Execute()
+	}); !errors.Is(err, errRollbackTest) {
+		t.Fatalf("outbox repository, %v", err)
+	}
+}
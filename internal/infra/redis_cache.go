@@ -0,0 +1,59 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/samber/do/v2"
+)
+
+// redisCache redis缓存，多个服务实例之间共享
+type redisCache struct {
+	client redis.Cmdable
+}
+
+// CacherProvider 缓存提供者，配置了redis时使用redis缓存，否则使用本地内存缓存
+func CacherProvider(injector do.Injector) (adapter.Cacher, error) {
+	if client, err := do.Invoke[*redis.Client](injector); err == nil {
+		return NewRedisCache(client), nil
+	}
+	return NewMemoryCache(), nil
+}
+
+// NewRedisCache redis缓存
+func NewRedisCache(client redis.Cmdable) adapter.Cacher {
+	return &redisCache{client: client}
+}
+
+// Get 获取缓存结果
+func (rc *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := rc.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrMissingCache
+	}
+	return value, err
+}
+
+// Put 保存缓存
+func (rc *redisCache) Put(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return rc.client.Set(ctx, key, value, expiration).Err()
+}
+
+// Delete 删除缓存
+func (rc *redisCache) Delete(ctx context.Context, key string) error {
+	return rc.client.Del(ctx, key).Err()
+}
+
+// Take 读取并删除缓存，使用GETDEL保证多个服务实例之间的原子性
+func (rc *redisCache) Take(ctx context.Context, key string) ([]byte, error) {
+	value, err := rc.client.GetDel(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrMissingCache
+	}
+	return value, err
+}
@@ -0,0 +1,66 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ddd-example/internal/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	ctx := context.Background()
+
+	// 模拟两个服务实例，各自使用独立的redis连接
+	newCache := func() *redisCache {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		return NewRedisCache(client).(*redisCache)
+	}
+	node1, node2 := newCache(), newCache()
+
+	if _, err := node1.Get(ctx, "foo"); !errors.Is(err, domain.ErrMissingCache) {
+		t.Fatalf("expected domain.ErrMissingCache, got %v", err)
+	}
+
+	if err := node1.Put(ctx, "foo", []byte("bar"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := node2.Get(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, []byte("bar")) {
+		t.Fatalf("expected bar, got %q", v)
+	}
+
+	if err := node2.Delete(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	} else if _, err := node1.Get(ctx, "foo"); !errors.Is(err, domain.ErrMissingCache) {
+		t.Fatalf("deleted value should be missing, got %v", err)
+	}
+
+	if err := node1.Put(ctx, "foo", []byte("bar"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(time.Minute)
+	if _, err := node2.Get(ctx, "foo"); !errors.Is(err, domain.ErrMissingCache) {
+		t.Fatalf("expired value should be missing, got %v", err)
+	}
+
+	// 读取并删除，只有一个实例能拿到值
+	if err := node1.Put(ctx, "foo", []byte("bar"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := node2.Take(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, []byte("bar")) {
+		t.Fatalf("expected bar, got %q", v)
+	} else if _, err := node1.Take(ctx, "foo"); !errors.Is(err, domain.ErrMissingCache) {
+		t.Fatalf("taken value should be missing, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+	"github.com/samber/do/v2"
+)
+
+// roleDBRepository 角色，数据库存储
+type roleDBRepository struct {
+	db entity.DB
+}
+
+// RoleRepositoryProvider 角色仓库提供者
+func RoleRepositoryProvider(injector do.Injector) (adapter.RoleRepository, error) {
+	return NewRoleRepository(do.MustInvoke[*sqlx.DB](injector)), nil
+}
+
+// NewRoleRepository returns role repository.
+func NewRoleRepository(db entity.DB) adapter.RoleRepository {
+	return &roleDBRepository{db: db}
+}
+
+// Find 查询角色，不存在的角色返回domain.ErrRoleNotFound
+func (r *roleDBRepository) Find(ctx context.Context, name string) (*domain.Role, error) {
+	roles, err := r.query(ctx, colName.Eq(name))
+	if err != nil {
+		return nil, err
+	} else if len(roles) == 0 {
+		return nil, domain.ErrRoleNotFound
+	}
+	return roles[0], nil
+}
+
+// List 所有角色
+func (r *roleDBRepository) List(ctx context.Context) ([]*domain.Role, error) {
+	return r.query(ctx)
+}
+
+// ListByNames 查询指定的角色，忽略不存在的角色
+func (r *roleDBRepository) ListByNames(ctx context.Context, names []string) ([]*domain.Role, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return r.query(ctx, colName.In(names))
+}
+
+// CountAccounts 拥有角色的账号数量
+func (r *roleDBRepository) CountAccounts(ctx context.Context, name string) (int, error) {
+	stmt := selectFrom(r.db, tableAccountRoles).
+		Select(goqu.COUNT(goqu.Star())).
+		Where(colRole.Eq(name))
+
+	var n int
+	if err := entity.GetRecord(ctx, &n, r.db, stmt); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r *roleDBRepository) query(ctx context.Context, conditions ...goqu.Expression) ([]*domain.Role, error) {
+	stmt := selectFrom(r.db, tableRoles).Where(conditions...).Order(colName.Asc())
+
+	var rows []roleRow
+	if err := entity.GetRecords(ctx, &rows, r.db, stmt); err != nil {
+		return nil, fmt.Errorf("query roles, %w", err)
+	} else if len(rows) == 0 {
+		return nil, nil
+	}
+
+	roles := make([]*domain.Role, 0, len(rows))
+	names := make([]string, 0, len(rows))
+	byName := make(map[string]*domain.Role, len(rows))
+	for _, row := range rows {
+		role := &domain.Role{
+			Name:        row.Name,
+			Description: row.Description,
+			Permissions: []string{},
+		}
+		roles = append(roles, role)
+		names = append(names, row.Name)
+		byName[row.Name] = role
+	}
+
+	stmt = selectFrom(r.db, tableRolePermissions).
+		Where(colRole.In(names)).
+		Order(colRole.Asc(), colPermission.Asc())
+
+	var perms []rolePermissionRow
+	if err := entity.GetRecords(ctx, &perms, r.db, stmt); err != nil {
+		return nil, fmt.Errorf("query role permissions, %w", err)
+	}
+	for _, v := range perms {
+		role := byName[v.Role]
+		role.Permissions = append(role.Permissions, v.Permission)
+	}
+	return roles, nil
+}
+
+type roleRow struct {
+	Name        string `db:"name"`
+	Description string `db:"description"`
+	CreateAt    int64  `db:"create_at"`
+	UpdateAt    int64  `db:"update_at"`
+}
+
+func (row roleRow) TableName() string {
+	return "roles"
+}
+
+type rolePermissionRow struct {
+	Role       string `db:"role"`
+	Permission string `db:"permission"`
+}
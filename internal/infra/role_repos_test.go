@@ -0,0 +1,96 @@
+//go:build dbtest || pgtest
+// +build dbtest pgtest
+
+package infra
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"ddd-example/internal/domain"
+
+	"github.com/joyparty/entity"
+)
+
+func TestRoleRepository(t *testing.T) {
+	if err := entity.Transaction(testDB, func(tx entity.DB) (err error) {
+		defer func() {
+			err = cmp.Or(err, errRollbackTest)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		repos := NewRoleRepository(tx)
+		accounts := newAccountDBRepository(tx)
+
+		return testTable{
+			{
+				Name: "Find",
+				Func: func() error {
+					if _, err := repos.Find(ctx, "nobody"); !errors.Is(err, domain.ErrRoleNotFound) {
+						return fmt.Errorf("expected domain.ErrRoleNotFound, got %v", err)
+					}
+
+					role, err := repos.Find(ctx, domain.RoleSuperAdmin)
+					if err != nil {
+						return err
+					} else if !slices.Equal(role.Permissions, []string{domain.PermissionAll}) {
+						return fmt.Errorf("unexpected super admin permissions %v", role.Permissions)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "ListByNames",
+				Func: func() error {
+					roles, err := repos.ListByNames(ctx, []string{"support", "nobody"})
+					if err != nil {
+						return err
+					} else if len(roles) != 1 || roles[0].Name != "support" {
+						return fmt.Errorf("unexpected roles %v", roles)
+					} else if !domain.NewPermissions(roles...).Allow(domain.PermissionAccountsRead) {
+						return errors.New("support should read accounts")
+					}
+
+					all, err := repos.List(ctx)
+					if err != nil {
+						return err
+					} else if len(all) < 3 {
+						return fmt.Errorf("builtin roles missing, got %d", len(all))
+					}
+					return nil
+				},
+			},
+			{
+				Name: "CountAccounts",
+				Func: func() error {
+					account := &domain.Account{}
+					if err := account.SetEmail("role@test.com"); err != nil {
+						return err
+					} else if err := account.SetPassword("abcdef"); err != nil {
+						return err
+					}
+					account.AssignRole("support")
+					if err := accounts.Create(ctx, account); err != nil {
+						return fmt.Errorf("create account, %w", err)
+					}
+
+					if n, err := repos.CountAccounts(ctx, "support"); err != nil {
+						return err
+					} else if n != 1 {
+						return fmt.Errorf("expected 1 support account, got %d", n)
+					}
+					return nil
+				},
+			},
+		}.Execute()
+	}); !errors.Is(err, errRollbackTest) {
+		t.Fatalf("role repository, %v", err)
+	}
+}
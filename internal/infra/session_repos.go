@@ -0,0 +1,163 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+	"github.com/samber/do/v2"
+)
+
+// sessionDBRepository 登录会话，数据库存储
+type sessionDBRepository struct {
+	db   entity.DB
+	base *entity.DomainObjectRepository[uuid.UUID, *domain.Session, *sessionRow]
+}
+
+// SessionRepositoryProvider 会话仓库提供者
+func SessionRepositoryProvider(injector do.Injector) (adapter.SessionRepository, error) {
+	return newSessionDBRepository(do.MustInvoke[*sqlx.DB](injector)), nil
+}
+
+// NewSessionRepository returns session repository.
+func NewSessionRepository(db entity.DB) adapter.SessionRepository {
+	return newSessionDBRepository(db)
+}
+
+func newSessionDBRepository(db entity.DB) *sessionDBRepository {
+	return &sessionDBRepository{
+		db: db,
+		base: entity.NewDomainObjectRepository(
+			entity.NewRepository[uuid.UUID, *sessionRow](db),
+		),
+	}
+}
+
+// Find 使用ID查找
+func (r *sessionDBRepository) Find(ctx context.Context, sessionID uuid.UUID) (*domain.Session, error) {
+	s, err := r.base.Find(ctx, sessionID)
+	if entity.IsNotFound(err) {
+		return nil, domain.ErrSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListByAccount 账号所有没有过期的会话，最近访问的在前
+func (r *sessionDBRepository) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]*domain.Session, error) {
+	stmt := selectFrom(r.db, tableSessions).
+		Where(
+			colAccountID.Eq(accountID.String()),
+			colExpireAt.Gt(time.Now().Unix()),
+		).
+		Order(colLastSeenAt.Desc())
+
+	return r.base.Query(ctx, stmt)
+}
+
+// Create 保存新会话
+func (r *sessionDBRepository) Create(ctx context.Context, session *domain.Session) error {
+	return r.base.Create(ctx, session)
+}
+
+// Update 更新会话
+func (r *sessionDBRepository) Update(ctx context.Context, session *domain.Session) error {
+	return r.base.Update(ctx, session)
+}
+
+// Delete 删除会话
+func (r *sessionDBRepository) Delete(ctx context.Context, sessionID uuid.UUID) error {
+	stmt := deleteFrom(r.db, tableSessions).Where(colID.Eq(sessionID.String()))
+
+	_, err := entity.ExecDelete(ctx, r.db, stmt)
+	return err
+}
+
+// DeleteByAccount 删除账号的会话
+func (r *sessionDBRepository) DeleteByAccount(ctx context.Context, accountID uuid.UUID, except ...uuid.UUID) error {
+	stmt := deleteFrom(r.db, tableSessions).Where(colAccountID.Eq(accountID.String()))
+	if len(except) > 0 {
+		ids := make([]string, 0, len(except))
+		for _, id := range except {
+			ids = append(ids, id.String())
+		}
+		stmt = stmt.Where(colID.NotIn(ids))
+	}
+
+	_, err := entity.ExecDelete(ctx, r.db, stmt)
+	return err
+}
+
+// DeleteExpired 删除在before之前过期的会话
+func (r *sessionDBRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	stmt := deleteFrom(r.db, tableSessions).Where(colExpireAt.Lt(before.Unix()))
+
+	res, err := entity.ExecDelete(ctx, r.db, stmt)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type sessionRow struct {
+	baseRow
+
+	AccountID  pgtype.UUID `db:"account_id"`
+	Device     pgtype.Text `db:"device"`
+	UserAgent  pgtype.Text `db:"user_agent"`
+	IP         pgtype.Text `db:"ip"`
+	LastSeenAt int64       `db:"last_seen_at"`
+	AuthAt     int64       `db:"auth_at"`
+	ExpireAt   int64       `db:"expire_at"`
+}
+
+func (row sessionRow) TableName() string {
+	return "sessions"
+}
+
+func (row *sessionRow) Set(_ context.Context, s *domain.Session) error {
+	row.LastSeenAt = s.LastSeenAt.Unix()
+	row.ExpireAt = s.ExpireAt.Unix()
+	if !s.AuthAt.IsZero() {
+		row.AuthAt = s.AuthAt.Unix()
+	}
+
+	return errors.Join(
+		row.SetID(s.ID),
+		database.SetUUID(&row.AccountID, s.AccountID),
+		database.SetText(&row.Device, s.Device),
+		database.SetText(&row.UserAgent, s.UserAgent),
+		database.SetText(&row.IP, s.IP),
+	)
+}
+
+func (row sessionRow) ToDomainObject() (*domain.Session, error) {
+	if row.AccountID.Status != pgtype.Present {
+		return nil, fmt.Errorf("session %s without account", row.GetID())
+	}
+
+	s := &domain.Session{
+		ID:         row.ID.Bytes,
+		AccountID:  row.AccountID.Bytes,
+		Device:     row.Device.String,
+		UserAgent:  row.UserAgent.String,
+		IP:         row.IP.String,
+		CreateAt:   time.Unix(row.CreateAt, 0),
+		LastSeenAt: time.Unix(row.LastSeenAt, 0),
+		ExpireAt:   time.Unix(row.ExpireAt, 0),
+	}
+	if row.AuthAt > 0 {
+		s.AuthAt = time.Unix(row.AuthAt, 0)
+	}
+	return s, nil
+}
@@ -0,0 +1,136 @@
+//go:build dbtest || pgtest
+// +build dbtest pgtest
+
+package infra
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/joyparty/entity"
+)
+
+func TestSessionRepository(t *testing.T) {
+	if err := entity.Transaction(testDB, func(db entity.DB) (err error) {
+		defer func() {
+			err = cmp.Or(err, errRollbackTest)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var (
+			accountID = uuid.New()
+			sessions  []*domain.Session
+		)
+
+		repos := newSessionDBRepository(db)
+
+		return testTable{
+			{
+				Name: "Create",
+				Func: func() error {
+					for _, device := range []string{"web", "ios", "android"} {
+						s, err := domain.NewSession(accountID, domain.ClientInfo{
+							IP:     "127.0.0.1",
+							Device: device,
+						})
+						if err != nil {
+							return err
+						} else if err := repos.Create(ctx, s); err != nil {
+							return err
+						}
+						sessions = append(sessions, s)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Find",
+				Func: func() error {
+					if _, err := repos.Find(ctx, uuid.New()); !errors.Is(err, domain.ErrSessionNotFound) {
+						return fmt.Errorf("expected domain.ErrSessionNotFound, got %v", err)
+					}
+
+					s, err := repos.Find(ctx, sessions[0].ID)
+					if err != nil {
+						return err
+					} else if s.AccountID != accountID || s.Device != "web" {
+						return fmt.Errorf("unexpected session %+v", s)
+					} else if s.AuthAt.Unix() != sessions[0].AuthAt.Unix() {
+						return fmt.Errorf("auth time not saved, got %v", s.AuthAt)
+					} else if s.ExpireAt.Unix() != sessions[0].ExpireAt.Unix() {
+						return fmt.Errorf("expire time not saved, got %v", s.ExpireAt)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "DeleteExpired",
+				Func: func() error {
+					expired, err := domain.NewSession(accountID, domain.ClientInfo{})
+					if err != nil {
+						return err
+					}
+					expired.ExpireAt = time.Now().Add(-time.Minute)
+					if err := repos.Create(ctx, expired); err != nil {
+						return err
+					}
+
+					// 过期的会话不出现在列表里
+					if list, err := repos.ListByAccount(ctx, accountID); err != nil {
+						return err
+					} else if len(list) != len(sessions) {
+						return fmt.Errorf("expected %d sessions, got %d", len(sessions), len(list))
+					}
+
+					if n, err := repos.DeleteExpired(ctx, time.Now()); err != nil {
+						return err
+					} else if n < 1 {
+						return errors.New("expired session not deleted")
+					} else if _, err := repos.Find(ctx, expired.ID); !errors.Is(err, domain.ErrSessionNotFound) {
+						return fmt.Errorf("expected domain.ErrSessionNotFound, got %v", err)
+					} else if _, err := repos.Find(ctx, sessions[0].ID); err != nil {
+						return err
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Delete",
+				Func: func() error {
+					if err := repos.Delete(ctx, sessions[0].ID); err != nil {
+						return err
+					} else if list, err := repos.ListByAccount(ctx, accountID); err != nil {
+						return err
+					} else if len(list) != 2 {
+						return fmt.Errorf("expected 2 sessions, got %d", len(list))
+					}
+					return nil
+				},
+			},
+			{
+				Name: "DeleteByAccount",
+				Func: func() error {
+					if err := repos.DeleteByAccount(ctx, accountID, sessions[1].ID); err != nil {
+						return err
+					} else if list, err := repos.ListByAccount(ctx, accountID); err != nil {
+						return err
+					} else if len(list) != 1 || list[0].ID != sessions[1].ID {
+						return fmt.Errorf("expected only session %s left", sessions[1].ID)
+					}
+					return nil
+				},
+			},
+		}.Execute()
+	}); !errors.Is(err, errRollbackTest) {
+		t.Fatalf("session repository, %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+	"ddd-example/pkg/database"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"github.com/joyparty/entity"
+	"github.com/samber/do/v2"
+)
+
+// webhookDBRepository webhook配置，数据库存储
+type webhookDBRepository struct {
+	db   entity.DB
+	base *entity.DomainObjectRepository[uuid.UUID, *domain.Webhook, *webhookRow]
+}
+
+// WebhookRepositoryProvider webhook仓库提供者
+func WebhookRepositoryProvider(injector do.Injector) (adapter.WebhookRepository, error) {
+	return newWebhookDBRepository(do.MustInvoke[*sqlx.DB](injector)), nil
+}
+
+func newWebhookDBRepository(db entity.DB) *webhookDBRepository {
+	return &webhookDBRepository{
+		db: db,
+		base: entity.NewDomainObjectRepository(
+			entity.NewRepository[uuid.UUID, *webhookRow](db),
+		),
+	}
+}
+
+// Find 使用ID查找
+func (r *webhookDBRepository) Find(ctx context.Context, webhookID uuid.UUID) (*domain.Webhook, error) {
+	hook, err := r.base.Find(ctx, webhookID)
+	if entity.IsNotFound(err) {
+		return nil, domain.ErrWebhookNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// List 所有webhook
+func (r *webhookDBRepository) List(ctx context.Context) ([]*domain.Webhook, error) {
+	return r.base.Query(ctx, selectFrom(r.db, tableWebhooks).Order(colCreateAt.Asc()))
+}
+
+// Create 保存新webhook
+func (r *webhookDBRepository) Create(ctx context.Context, hook *domain.Webhook) error {
+	return r.base.Create(ctx, hook)
+}
+
+// Update 更新webhook
+func (r *webhookDBRepository) Update(ctx context.Context, hook *domain.Webhook) error {
+	return r.base.Update(ctx, hook)
+}
+
+// Delete 删除webhook以及投递记录
+func (r *webhookDBRepository) Delete(ctx context.Context, webhookID uuid.UUID) error {
+	hook, err := r.Find(ctx, webhookID)
+	if err != nil {
+		return err
+	} else if err := r.base.Delete(ctx, hook); err != nil {
+		return err
+	}
+
+	// 先删除webhook，投递记录删除失败时，剩下的记录也不会再投递
+	stmt := deleteFrom(r.db, tableWebhookDeliveries).Where(colWebhookID.Eq(webhookID.String()))
+	if _, err := entity.ExecDelete(ctx, r.db, stmt); err != nil {
+		return fmt.Errorf("delete deliveries, %w", err)
+	}
+	return nil
+}
+
+type webhookRow struct {
+	baseRow
+
+	URL     pgtype.Text `db:"url"`
+	Secret  pgtype.Text `db:"secret"`
+	Events  pgtype.JSON `db:"events"`
+	Enabled bool        `db:"enabled"`
+}
+
+func (row webhookRow) TableName() string {
+	return "webhooks"
+}
+
+func (row *webhookRow) Set(_ context.Context, hook *domain.Webhook) error {
+	row.Enabled = hook.Enabled
+
+	return errors.Join(
+		row.SetID(hook.ID),
+		database.SetText(&row.URL, hook.URL),
+		database.SetText(&row.Secret, hook.Secret),
+		row.Events.Set(hook.Events),
+	)
+}
+
+func (row webhookRow) ToDomainObject() (*domain.Webhook, error) {
+	hook := &domain.Webhook{
+		ID:       row.ID.Bytes,
+		URL:      row.URL.String,
+		Secret:   row.Secret.String,
+		Enabled:  row.Enabled,
+		CreateAt: time.Unix(row.CreateAt, 0),
+	}
+	if err := row.Events.AssignTo(&hook.Events); err != nil {
+		return nil, fmt.Errorf("decode events, %w", err)
+	}
+	return hook, nil
+}
+
+// webhookDeliveryDBRepository webhook投递记录，数据库存储
+type webhookDeliveryDBRepository struct {
+	db   entity.DB
+	base *entity.DomainObjectRepository[uuid.UUID, *domain.WebhookDelivery, *webhookDeliveryRow]
+}
+
+// WebhookDeliveryRepositoryProvider webhook投递记录仓库提供者
+func WebhookDeliveryRepositoryProvider(injector do.Injector) (adapter.WebhookDeliveryRepository, error) {
+	return newWebhookDeliveryDBRepository(do.MustInvoke[*sqlx.DB](injector)), nil
+}
+
+func newWebhookDeliveryDBRepository(db entity.DB) *webhookDeliveryDBRepository {
+	return &webhookDeliveryDBRepository{
+		db: db,
+		base: entity.NewDomainObjectRepository(
+			entity.NewRepository[uuid.UUID, *webhookDeliveryRow](db),
+		),
+	}
+}
+
+// Find 使用ID查找
+func (r *webhookDeliveryDBRepository) Find(ctx context.Context, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	d, err := r.base.Find(ctx, deliveryID)
+	if entity.IsNotFound(err) {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Create 保存投递记录，重复的事件由唯一索引过滤
+func (r *webhookDeliveryDBRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	stmt := selectFrom(r.db, tableWebhookDeliveries).
+		Select(colID).
+		Where(
+			colWebhookID.Eq(delivery.WebhookID.String()),
+			colEventID.Eq(delivery.EventID.String()),
+		).
+		Limit(1)
+
+	var id uuid.UUID
+	if err := entity.GetRecord(ctx, &id, r.db, stmt); err == nil {
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("find delivery, %w", err)
+	}
+
+	if err := r.base.Create(ctx, delivery); err != nil && !errors.Is(err, entity.ErrConflict) {
+		return err
+	}
+	return nil
+}
+
+// Update 保存投递结果
+func (r *webhookDeliveryDBRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.base.Update(ctx, delivery)
+}
+
+// Claim 领取到期的待投递记录，与outbox相同使用next_attempt_at做乐观锁
+func (r *webhookDeliveryDBRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	now := time.Now()
+	stmt := selectFrom(r.db, tableWebhookDeliveries).
+		Where(
+			colStatus.Eq(domain.WebhookPending),
+			colNextAttemptAt.Lte(now.Unix()),
+		).
+		Order(colNextAttemptAt.Asc(), colID.Asc()).
+		Limit(uint(limit))
+
+	var rows []webhookDeliveryRow
+	if err := entity.GetRecords(ctx, &rows, r.db, stmt); err != nil {
+		return nil, err
+	}
+
+	until := now.Add(lease).Unix()
+	result := make([]*domain.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		stmt := updateTable(r.db, tableWebhookDeliveries).
+			Set(goqu.Record{
+				"next_attempt_at": until,
+				"update_at":       now.Unix(),
+			}).
+			Where(
+				colID.Eq(row.GetID().String()),
+				colStatus.Eq(domain.WebhookPending),
+				colNextAttemptAt.Eq(row.NextAttemptAt),
+			)
+
+		res, err := entity.ExecUpdate(ctx, r.db, stmt)
+		if err != nil {
+			return nil, fmt.Errorf("claim delivery, %w", err)
+		} else if n, err := res.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("claim delivery, %w", err)
+		} else if n == 0 {
+			continue
+		}
+
+		row.NextAttemptAt = until
+		d, err := row.ToDomainObject()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// ListByWebhook 查询webhook的投递记录
+func (r *webhookDeliveryDBRepository) ListByWebhook(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]*domain.WebhookDelivery, error) {
+	stmt := selectFrom(r.db, tableWebhookDeliveries).
+		Where(colWebhookID.Eq(webhookID.String())).
+		Order(colCreateAt.Desc(), colID.Desc()).
+		Limit(uint(limit))
+	if status != "" {
+		stmt = stmt.Where(colStatus.Eq(status))
+	}
+
+	return r.base.Query(ctx, stmt)
+}
+
+type webhookDeliveryRow struct {
+	baseRow
+
+	WebhookID     pgtype.UUID `db:"webhook_id"`
+	EventID       pgtype.UUID `db:"event_id"`
+	EventType     string      `db:"event_type"`
+	Payload       pgtype.JSON `db:"payload"`
+	Status        string      `db:"status"`
+	Attempts      int         `db:"attempts"`
+	History       pgtype.JSON `db:"history"`
+	NextAttemptAt int64       `db:"next_attempt_at"`
+}
+
+func (row webhookDeliveryRow) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (row *webhookDeliveryRow) Set(_ context.Context, d *domain.WebhookDelivery) error {
+	row.EventType = d.EventType
+	row.Status = d.Status
+	row.Attempts = d.Attempts
+	row.NextAttemptAt = d.NextAttemptAt.Unix()
+
+	return errors.Join(
+		row.SetID(d.ID),
+		database.SetUUID(&row.WebhookID, d.WebhookID),
+		database.SetUUID(&row.EventID, d.EventID),
+		row.Payload.Set(d.Payload),
+		row.History.Set(d.History),
+	)
+}
+
+func (row webhookDeliveryRow) ToDomainObject() (*domain.WebhookDelivery, error) {
+	d := &domain.WebhookDelivery{
+		ID:            row.ID.Bytes,
+		WebhookID:     row.WebhookID.Bytes,
+		EventID:       row.EventID.Bytes,
+		EventType:     row.EventType,
+		Payload:       row.Payload.Bytes,
+		Status:        row.Status,
+		Attempts:      row.Attempts,
+		NextAttemptAt: time.Unix(row.NextAttemptAt, 0),
+		CreateAt:      time.Unix(row.CreateAt, 0),
+	}
+	if err := row.History.AssignTo(&d.History); err != nil {
+		return nil, fmt.Errorf("decode history, %w", err)
+	}
+	return d, nil
+}
@@ -0,0 +1,148 @@
+//go:build dbtest || pgtest
+// +build dbtest pgtest
+
+package infra
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"ddd-example/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/joyparty/entity"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	if err := entity.Transaction(testDB, func(db entity.DB) (err error) {
+		defer func() {
+			err = cmp.Or(err, errRollbackTest)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		hooks := newWebhookDBRepository(db)
+		deliveries := newWebhookDeliveryDBRepository(db)
+
+		hook, err := domain.NewWebhook("https://example.com/hook", []string{"account.login"})
+		if err != nil {
+			return err
+		}
+		eventID := uuid.Must(uuid.NewV7())
+
+		var delivery *domain.WebhookDelivery
+		return testTable{
+			{
+				Name: "Create",
+				Func: func() error {
+					if err := hooks.Create(ctx, hook); err != nil {
+						return err
+					}
+
+					found, err := hooks.Find(ctx, hook.ID)
+					if err != nil {
+						return err
+					} else if found.Secret != hook.Secret || len(found.Events) != 1 || !found.Enabled {
+						return errors.New("webhook not saved")
+					}
+					return nil
+				},
+			},
+			{
+				Name: "CreateDelivery",
+				Func: func() error {
+					delivery, err = domain.NewWebhookDelivery(hook, eventID, "account.login", []byte(`{}`))
+					if err != nil {
+						return err
+					} else if err := deliveries.Create(ctx, delivery); err != nil {
+						return err
+					}
+
+					// 同一事件重复入队时忽略
+					again, err := domain.NewWebhookDelivery(hook, eventID, "account.login", []byte(`{}`))
+					if err != nil {
+						return err
+					} else if err := deliveries.Create(ctx, again); err != nil {
+						return err
+					} else if _, err := deliveries.Find(ctx, again.ID); !errors.Is(err, domain.ErrWebhookDeliveryNotFound) {
+						return fmt.Errorf("duplicate delivery, expected %v, got %v", domain.ErrWebhookDeliveryNotFound, err)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Claim",
+				Func: func() error {
+					claimed, err := deliveries.Claim(ctx, 10, time.Minute)
+					if err != nil {
+						return err
+					} else if len(claimed) != 1 || claimed[0].ID != delivery.ID {
+						return fmt.Errorf("expected 1 claimed delivery, got %d", len(claimed))
+					}
+
+					if again, err := deliveries.Claim(ctx, 10, time.Minute); err != nil {
+						return err
+					} else if len(again) != 0 {
+						return errors.New("claimed delivery should not be claimed again")
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Update",
+				Func: func() error {
+					delivery.Record(domain.WebhookAttempt{At: time.Now(), StatusCode: http.StatusBadGateway})
+					if err := deliveries.Update(ctx, delivery); err != nil {
+						return err
+					}
+
+					found, err := deliveries.Find(ctx, delivery.ID)
+					if err != nil {
+						return err
+					} else if found.Attempts != 1 || len(found.History) != 1 || found.History[0].StatusCode != http.StatusBadGateway {
+						return errors.New("attempt history not saved")
+					}
+					return nil
+				},
+			},
+			{
+				Name: "ListByWebhook",
+				Func: func() error {
+					if list, err := deliveries.ListByWebhook(ctx, hook.ID, domain.WebhookPending, 10); err != nil {
+						return err
+					} else if len(list) != 1 {
+						return fmt.Errorf("expected 1 pending delivery, got %d", len(list))
+					}
+
+					if list, err := deliveries.ListByWebhook(ctx, hook.ID, domain.WebhookDead, 10); err != nil {
+						return err
+					} else if len(list) != 0 {
+						return fmt.Errorf("expected 0 dead delivery, got %d", len(list))
+					}
+					return nil
+				},
+			},
+			{
+				Name: "Delete",
+				Func: func() error {
+					if err := hooks.Delete(ctx, hook.ID); err != nil {
+						return err
+					} else if _, err := hooks.Find(ctx, hook.ID); !errors.Is(err, domain.ErrWebhookNotFound) {
+						return fmt.Errorf("expected %v, got %v", domain.ErrWebhookNotFound, err)
+					} else if _, err := deliveries.Find(ctx, delivery.ID); !errors.Is(err, domain.ErrWebhookDeliveryNotFound) {
+						return fmt.Errorf("expected %v, got %v", domain.ErrWebhookDeliveryNotFound, err)
+					}
+					return nil
+				},
+			},
+		}.Execute()
+	}); !errors.Is(err, errRollbackTest) {
+		t.Fatalf("webhook repository, %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ddd-example/internal/app/adapter"
+	"ddd-example/internal/domain"
+
+	"github.com/samber/do/v2"
+)
+
+// webhookHTTPSender 使用http POST发送webhook
+type webhookHTTPSender struct {
+	client *http.Client
+}
+
+// WebhookSenderProvider webhook发送者提供者
+func WebhookSenderProvider(_ do.Injector) (adapter.WebhookSender, error) {
+	return NewWebhookSender(&http.Client{
+		Timeout: 10 * time.Second,
+		// 不跟随重定向，避免签名请求被转发到其它地址
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}), nil
+}
+
+// NewWebhookSender 构造webhook发送者
+func NewWebhookSender(client *http.Client) adapter.WebhookSender {
+	return &webhookHTTPSender{client: client}
+}
+
+// Send 发送请求
+//
+// 请求头包含事件ID、事件类型、时间戳和签名，接收方可以使用事件ID去重
+func (s *webhookHTTPSender) Send(ctx context.Context, hook *domain.Webhook, delivery *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("new request, %w", err)
+	}
+
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ddd-example-webhook/1")
+	req.Header.Set("X-Webhook-ID", delivery.EventID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Webhook-Signature", hook.Sign(now, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// 读完响应才能复用连接
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,36 @@
+-- postgresql从当前的表结构开始，之后的脚本与sqlite使用相同的版本号
+create table if not exists accounts (
+	id uuid primary key,
+	email varchar(255) not null,
+	email_verified boolean not null default false,
+	password varchar(255) not null,
+	setting jsonb,
+	create_at bigint not null,
+	update_at bigint not null
+);
+
+create unique index if not exists accounts_email_ukey on accounts (email);
+
+create table if not exists oauth_accounts (
+	account_id uuid not null,
+	vendor varchar(64) not null,
+	vendor_uid varchar(64) not null,
+	create_at bigint not null,
+	update_at bigint not null,
+	primary key (vendor, vendor_uid)
+);
+
+create index if not exists ix_oauth_account_id on oauth_accounts (account_id);
+
+create table if not exists sessions (
+	id uuid primary key,
+	account_id uuid not null,
+	device varchar(255),
+	user_agent varchar(1024),
+	ip varchar(64),
+	last_seen_at bigint not null,
+	create_at bigint not null,
+	update_at bigint not null
+);
+
+create index if not exists ix_sessions_account_id on sessions (account_id);
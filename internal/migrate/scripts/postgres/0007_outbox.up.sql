@@ -0,0 +1,13 @@
+create table if not exists outbox (
+	id uuid primary key,
+	type varchar(64) not null,
+	payload jsonb not null,
+	status varchar(16) not null,
+	attempts int not null default 0,
+	next_attempt_at bigint not null,
+	last_error varchar(1024),
+	create_at bigint not null,
+	update_at bigint not null
+);
+
+create index if not exists ix_outbox_status on outbox (status, next_attempt_at);
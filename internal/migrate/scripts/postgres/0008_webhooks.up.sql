@@ -0,0 +1,27 @@
+create table if not exists webhooks (
+	id uuid primary key,
+	url varchar(1024) not null,
+	secret varchar(255) not null,
+	events jsonb,
+	enabled boolean not null default true,
+	create_at bigint not null,
+	update_at bigint not null
+);
+
+create table if not exists webhook_deliveries (
+	id uuid primary key,
+	webhook_id uuid not null,
+	event_id uuid not null,
+	event_type varchar(64) not null,
+	payload jsonb not null,
+	status varchar(16) not null,
+	attempts int not null default 0,
+	history jsonb,
+	next_attempt_at bigint not null,
+	create_at bigint not null,
+	update_at bigint not null
+);
+
+-- 同一事件对同一webhook只投递一次
+create unique index if not exists ux_webhook_deliveries_event on webhook_deliveries (webhook_id, event_id);
+create index if not exists ix_webhook_deliveries_status on webhook_deliveries (status, next_attempt_at);
@@ -0,0 +1,4 @@
+alter table accounts add column if not exists status varchar(16) not null default 'active';
+
+create index if not exists ix_accounts_status on accounts (status, create_at);
+create index if not exists ix_accounts_create_at on accounts (create_at);
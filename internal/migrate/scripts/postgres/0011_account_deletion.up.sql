@@ -0,0 +1,3 @@
+alter table accounts add column if not exists status_update_at bigint not null default 0;
+
+create index if not exists ix_accounts_status_update_at on accounts (status, status_update_at);
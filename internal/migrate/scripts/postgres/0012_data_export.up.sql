@@ -0,0 +1,28 @@
+create table if not exists data_exports (
+	id uuid primary key,
+	account_id uuid not null,
+	status varchar(16) not null,
+	file varchar(255) not null,
+	size bigint not null default 0,
+	attempts int not null default 0,
+	last_error varchar(1024),
+	next_attempt_at bigint not null,
+	expire_at bigint not null default 0,
+	create_at bigint not null,
+	update_at bigint not null
+);
+
+create index if not exists ix_data_exports_account on data_exports (account_id, create_at);
+create index if not exists ix_data_exports_status on data_exports (status, next_attempt_at);
+create index if not exists ix_data_exports_expire_at on data_exports (expire_at);
+
+-- 账号相关的领域事件，用于登录历史和个人数据导出，不包含凭证等敏感字段
+create table if not exists account_events (
+	id uuid primary key,
+	account_id uuid not null,
+	type varchar(64) not null,
+	data jsonb not null,
+	occurred_at bigint not null
+);
+
+create index if not exists ix_account_events_account on account_events (account_id, occurred_at);
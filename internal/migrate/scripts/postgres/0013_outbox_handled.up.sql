@@ -0,0 +1 @@
+alter table outbox add column if not exists handled jsonb;
@@ -0,0 +1 @@
+alter table sessions add column if not exists auth_at bigint not null default 0;
@@ -0,0 +1,17 @@
+-- sqlite不支持修改字段类型，需要重建表
+create table accounts_new (
+	id character(36) primary key,
+	email varchar(255) not null,
+	password varchar(255) not null,
+	setting json,
+	create_at int not null,
+	update_at int not null
+);
+
+insert into accounts_new (id, email, password, setting, create_at, update_at)
+select id, email, password, setting, create_at, update_at from accounts;
+
+drop table accounts;
+alter table accounts_new rename to accounts;
+
+create unique index if not exists accounts_email_ukey on accounts(email);
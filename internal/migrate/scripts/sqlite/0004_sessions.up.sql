@@ -0,0 +1,12 @@
+create table if not exists sessions (
+	id character(36) primary key,
+	account_id character(36) not null,
+	device varchar(255),
+	user_agent varchar(1024),
+	ip varchar(64),
+	last_seen_at int not null,
+	create_at int not null,
+	update_at int not null
+);
+
+create index if not exists ix_sessions_account_id on sessions (account_id);
@@ -0,0 +1 @@
+alter table accounts add column email_verified boolean not null default false;
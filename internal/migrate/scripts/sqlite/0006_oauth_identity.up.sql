@@ -0,0 +1,18 @@
+-- 一个三方账号只能对应一个系统账号，一个系统账号可以绑定同一三方站点的多个账号
+create table oauth_accounts_new (
+	account_id character(36) not null,
+	vendor varchar(64) not null,
+	vendor_uid varchar(64) not null,
+	create_at int not null,
+	update_at int not null,
+	primary key (vendor, vendor_uid)
+);
+
+-- 旧数据中同一三方账号对应多个系统账号时，保留最后绑定的
+insert or ignore into oauth_accounts_new (account_id, vendor, vendor_uid, create_at, update_at)
+select account_id, vendor, vendor_uid, create_at, update_at from oauth_accounts order by update_at desc;
+
+drop table oauth_accounts;
+alter table oauth_accounts_new rename to oauth_accounts;
+
+create index if not exists ix_oauth_account_id on oauth_accounts (account_id);
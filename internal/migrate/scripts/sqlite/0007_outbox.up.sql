@@ -0,0 +1,13 @@
+create table if not exists outbox (
+	id character(36) primary key,
+	type varchar(64) not null,
+	payload json not null,
+	status varchar(16) not null,
+	attempts int not null default 0,
+	next_attempt_at int not null,
+	last_error varchar(1024),
+	create_at int not null,
+	update_at int not null
+);
+
+create index if not exists ix_outbox_status on outbox (status, next_attempt_at);
@@ -0,0 +1,27 @@
+create table if not exists webhooks (
+	id character(36) primary key,
+	url varchar(1024) not null,
+	secret varchar(255) not null,
+	events json,
+	enabled boolean not null default true,
+	create_at int not null,
+	update_at int not null
+);
+
+create table if not exists webhook_deliveries (
+	id character(36) primary key,
+	webhook_id character(36) not null,
+	event_id character(36) not null,
+	event_type varchar(64) not null,
+	payload json not null,
+	status varchar(16) not null,
+	attempts int not null default 0,
+	history json,
+	next_attempt_at int not null,
+	create_at int not null,
+	update_at int not null
+);
+
+-- 同一事件对同一webhook只投递一次
+create unique index if not exists ux_webhook_deliveries_event on webhook_deliveries (webhook_id, event_id);
+create index if not exists ix_webhook_deliveries_status on webhook_deliveries (status, next_attempt_at);
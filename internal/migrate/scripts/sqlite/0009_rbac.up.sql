@@ -0,0 +1,36 @@
+create table if not exists roles (
+	name varchar(64) primary key,
+	description varchar(255) not null default '',
+	create_at int not null,
+	update_at int not null
+);
+
+create table if not exists role_permissions (
+	role varchar(64) not null,
+	permission varchar(64) not null,
+	primary key (role, permission)
+);
+
+create table if not exists account_roles (
+	account_id character(36) not null,
+	role varchar(64) not null,
+	create_at int not null,
+	primary key (account_id, role)
+);
+
+create index if not exists ix_account_roles_role on account_roles (role);
+
+-- 内置角色，super_admin拥有所有权限
+insert into roles (name, description, create_at, update_at) values
+	('super_admin', '超级管理员', strftime('%s', 'now'), strftime('%s', 'now')),
+	('admin', '管理员', strftime('%s', 'now'), strftime('%s', 'now')),
+	('support', '客服', strftime('%s', 'now'), strftime('%s', 'now'))
+on conflict do nothing;
+
+insert into role_permissions (role, permission) values
+	('super_admin', '*'),
+	('admin', 'accounts:*'),
+	('admin', 'webhooks:*'),
+	('admin', 'roles:read'),
+	('support', 'accounts:read')
+on conflict do nothing;
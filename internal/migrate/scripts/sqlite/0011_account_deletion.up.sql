@@ -0,0 +1,3 @@
+alter table accounts add column status_update_at int not null default 0;
+
+create index if not exists ix_accounts_status_update_at on accounts (status, status_update_at);
@@ -0,0 +1,28 @@
+create table if not exists data_exports (
+	id character(36) primary key,
+	account_id character(36) not null,
+	status varchar(16) not null,
+	file varchar(255) not null,
+	size int not null default 0,
+	attempts int not null default 0,
+	last_error varchar(1024),
+	next_attempt_at int not null,
+	expire_at int not null default 0,
+	create_at int not null,
+	update_at int not null
+);
+
+create index if not exists ix_data_exports_account on data_exports (account_id, create_at);
+create index if not exists ix_data_exports_status on data_exports (status, next_attempt_at);
+create index if not exists ix_data_exports_expire_at on data_exports (expire_at);
+
+-- 账号相关的领域事件，用于登录历史和个人数据导出，不包含凭证等敏感字段
+create table if not exists account_events (
+	id character(36) primary key,
+	account_id character(36) not null,
+	type varchar(64) not null,
+	data json not null,
+	occurred_at int not null
+);
+
+create index if not exists ix_account_events_account on account_events (account_id, occurred_at);
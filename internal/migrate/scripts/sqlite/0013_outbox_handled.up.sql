@@ -0,0 +1 @@
+alter table outbox add column handled json;
@@ -0,0 +1 @@
+alter table sessions add column auth_at int not null default 0;
@@ -0,0 +1,6 @@
+alter table sessions add column expire_at int not null default 0;
+
+-- 已有会话按最近访问时间加上凭证有效期(30天)和访问记录间隔(1分钟)计算
+update sessions set expire_at = last_seen_at + 2592060 where expire_at = 0;
+
+create index if not exists ix_sessions_expire_at on sessions (expire_at);
@@ -0,0 +1,12 @@
+-- 旧版本三方账号注册时设置了没有人知道的随机密码(md5格式)，清除之后按没有密码的账号处理，
+-- 否则可以解绑最后一个三方账号导致无法登录。
+-- 旧版本注册时账号和三方账号在同一次请求里创建，以此和之后自己绑定三方账号的密码账号区分；
+-- 设置过新密码的账号已经不是md5格式，不受影响
+update accounts set password = ''
+where password <> ''
+	and password not like '$%'
+	and exists (
+		select 1 from oauth_accounts o
+		where o.account_id = accounts.id
+			and o.create_at between accounts.create_at and accounts.create_at + 60
+	);
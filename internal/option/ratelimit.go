@@ -0,0 +1,37 @@
+package option
+
+import (
+	"fmt"
+	"strings"
+
+	"ddd-example/pkg/ratelimit"
+)
+
+// 限流key的类型
+const (
+	RateLimitByIP      = "ip"
+	RateLimitByAccount = "account"
+	// 请求JSON里的email字段，用于免密码登录等按email发送邮件的接口
+	RateLimitByEmail = "email"
+	// header:<name> 使用请求头的值
+	RateLimitByHeader = "header:"
+)
+
+// RateLimitPolicy 接口限流策略
+type RateLimitPolicy struct {
+	// 按什么区分请求：ip(默认)，account(登录账号，匿名访问时按ip)，email(请求JSON里的email，没有时按ip)，
+	// header:<name>(请求头的值，没有时按ip)
+	Key string `toml:"key"`
+	ratelimit.Limit
+}
+
+// Validate 检查参数
+func (p RateLimitPolicy) Validate() error {
+	switch {
+	case p.Key == "", p.Key == RateLimitByIP, p.Key == RateLimitByAccount, p.Key == RateLimitByEmail:
+	case strings.HasPrefix(p.Key, RateLimitByHeader) && len(p.Key) > len(RateLimitByHeader):
+	default:
+		return fmt.Errorf("unsupported key %q", p.Key)
+	}
+	return p.Limit.Validate()
+}
@@ -0,0 +1,329 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"ddd-example/internal/app/handler"
+	"ddd-example/internal/domain"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// adminController 管理接口
+type adminController struct {
+	// revive:disable:struct-tag
+
+	assignRole            *handler.AssignRoleHandler             `do:""`
+	createWebhook         *handler.CreateWebhookHandler          `do:""`
+	deleteWebhook         *handler.DeleteWebhookHandler          `do:""`
+	disableAccount        *handler.DisableAccountHandler         `do:""`
+	enableAccount         *handler.EnableAccountHandler          `do:""`
+	getAccount            *handler.GetAccountHandler             `do:""`
+	listRoles             *handler.ListRolesHandler              `do:""`
+	listWebhookDeliveries *handler.ListWebhookDeliveriesHandler  `do:""`
+	listWebhooks          *handler.ListWebhooksHandler           `do:""`
+	lockAccount           *handler.LockAccountHandler            `do:""`
+	replayWebhookDelivery *handler.ReplayWebhookDeliveryHandler  `do:""`
+	revokeRole            *handler.RevokeRoleHandler             `do:""`
+	searchAccounts        *handler.SearchAccountsHandler         `do:""`
+	sendPasswordReset     *handler.SendPasswordResetHandler      `do:""`
+	suspendSessions       *handler.SuspendAccountSessionsHandler `do:""`
+	unlockAccount         *handler.UnlockAccountHandler          `do:""`
+
+	// revive:enable:struct-tag
+}
+
+// SearchAccounts 按email、状态、注册时间分页查询账号
+func (c *adminController) SearchAccounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.SearchAccounts{}
+		mustScanValues(&req, r.URL.Query())
+
+		result, err := c.searchAccounts.Handle(r.Context(), req)
+		if err != nil {
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(result))
+	}
+}
+
+// GetAccount 账号详情，包括绑定的三方账号
+func (c *adminController) GetAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		detail, err := c.getAccount.Handle(r.Context(), mustUUIDParam(r, "id"))
+		if err != nil {
+			if errors.Is(err, domain.ErrAccountNotFound) {
+				panic(errAccountNotFound)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(detail))
+	}
+}
+
+// DisableAccount 停用账号，同时强制退出登录
+func (c *adminController) DisableAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 原因是可选的，允许不提交请求内容
+		req := manageAccountArgs(r)
+		if r.ContentLength != 0 {
+			mustScanJSON(&req, r.Body)
+		}
+
+		account, err := c.disableAccount.Handle(r.Context(), req)
+		if err != nil {
+			panic(manageAccountError(err))
+		}
+
+		sendResponse(w, withData(account))
+	}
+}
+
+// EnableAccount 恢复停用的账号
+func (c *adminController) EnableAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := c.enableAccount.Handle(r.Context(), manageAccountArgs(r))
+		if err != nil {
+			panic(manageAccountError(err))
+		}
+
+		sendResponse(w, withData(account))
+	}
+}
+
+// LockAccount 因安全原因锁定账号，同时强制退出登录
+func (c *adminController) LockAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := manageAccountArgs(r)
+		if r.ContentLength != 0 {
+			mustScanJSON(&req, r.Body)
+		}
+
+		account, err := c.lockAccount.Handle(r.Context(), req)
+		if err != nil {
+			panic(manageAccountError(err))
+		}
+
+		sendResponse(w, withData(account))
+	}
+}
+
+// UnlockAccount 解锁账号
+func (c *adminController) UnlockAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := c.unlockAccount.Handle(r.Context(), manageAccountArgs(r))
+		if err != nil {
+			panic(manageAccountError(err))
+		}
+
+		sendResponse(w, withData(account))
+	}
+}
+
+// SuspendAccountSessions 强制账号退出所有会话
+func (c *adminController) SuspendAccountSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := c.suspendSessions.Handle(r.Context(), manageAccountArgs(r)); err != nil {
+			panic(manageAccountError(err))
+		}
+
+		sendResponse(w)
+	}
+}
+
+// SendPasswordReset 给账号发送重置密码邮件
+func (c *adminController) SendPasswordReset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := c.sendPasswordReset.Handle(r.Context(), manageAccountArgs(r)); err != nil {
+			panic(manageAccountError(err))
+		}
+
+		sendResponse(w, withStatusCode(http.StatusAccepted))
+	}
+}
+
+func manageAccountArgs(r *http.Request) handler.ManageAccount {
+	return handler.ManageAccount{
+		Operator:  mustVisitorFromCtx(r.Context()),
+		AccountID: mustUUIDParam(r, "id"),
+	}
+}
+
+func manageAccountError(err error) apiError {
+	switch {
+	case errors.Is(err, domain.ErrAccountNotFound):
+		return errAccountNotFound
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return errForbidden
+	case errors.Is(err, domain.ErrInvalidStatusTransition):
+		return errStatusConflict.WrapError(err)
+	default:
+		return errUnexpectedException.WrapError(err)
+	}
+}
+
+// ListRoles 角色列表
+func (c *adminController) ListRoles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roles, err := c.listRoles.Handle(r.Context())
+		if err != nil {
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"items": roles,
+		}))
+	}
+}
+
+// AssignRole 给账号分配角色
+func (c *adminController) AssignRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := c.assignRole.Handle(r.Context(), changeRoleArgs(r))
+		if err != nil {
+			panic(changeRoleError(err))
+		}
+
+		sendResponse(w, withData(account))
+	}
+}
+
+// RevokeRole 撤销账号的角色
+func (c *adminController) RevokeRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := c.revokeRole.Handle(r.Context(), changeRoleArgs(r))
+		if err != nil {
+			panic(changeRoleError(err))
+		}
+
+		sendResponse(w, withData(account))
+	}
+}
+
+func changeRoleArgs(r *http.Request) handler.ChangeRole {
+	return handler.ChangeRole{
+		Operator:  mustVisitorFromCtx(r.Context()),
+		AccountID: mustUUIDParam(r, "id"),
+		Role:      chi.URLParam(r, "role"),
+	}
+}
+
+func changeRoleError(err error) apiError {
+	switch {
+	case errors.Is(err, domain.ErrAccountNotFound):
+		return errAccountNotFound
+	case errors.Is(err, domain.ErrRoleNotFound):
+		return errRoleNotFound
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return errForbidden
+	case errors.Is(err, domain.ErrLastSuperAdmin):
+		return errLastSuperAdmin
+	default:
+		return errUnexpectedException.WrapError(err)
+	}
+}
+
+// ListWebhooks webhook列表
+func (c *adminController) ListWebhooks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hooks, err := c.listWebhooks.Handle(r.Context())
+		if err != nil {
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"items": hooks,
+		}))
+	}
+}
+
+// CreateWebhook 创建webhook，签名密钥只在这里返回一次
+func (c *adminController) CreateWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.CreateWebhook{}
+		mustScanJSON(&req, r.Body)
+
+		hook, err := c.createWebhook.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidWebhookURL) {
+				panic(errBadRequest.WrapError(err))
+			} else if errors.Is(err, domain.ErrUnknownEventType) {
+				panic(errUnknownEventType.WrapError(err))
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w,
+			withStatusCode(http.StatusCreated),
+			withData(mapAny{
+				"webhook": hook,
+				"secret":  hook.Secret,
+			}),
+		)
+	}
+}
+
+// DeleteWebhook 删除webhook
+func (c *adminController) DeleteWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := c.deleteWebhook.Handle(r.Context(), mustUUIDParam(r, "id")); err != nil {
+			if errors.Is(err, domain.ErrWebhookNotFound) {
+				panic(errWebhookNotFound)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w)
+	}
+}
+
+// ListWebhookDeliveries webhook投递记录，可以按状态过滤，例如只查看dead状态的记录
+func (c *adminController) ListWebhookDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.ListWebhookDeliveries{
+			WebhookID: mustUUIDParam(r, "id"),
+		}
+		mustScanValues(&req, r.URL.Query())
+
+		deliveries, err := c.listWebhookDeliveries.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, domain.ErrWebhookNotFound) {
+				panic(errWebhookNotFound)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"items": deliveries,
+		}))
+	}
+}
+
+// ReplayWebhookDelivery 重新投递
+func (c *adminController) ReplayWebhookDelivery() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		delivery, err := c.replayWebhookDelivery.Handle(r.Context(), mustUUIDParam(r, "id"))
+		if err != nil {
+			if errors.Is(err, domain.ErrWebhookDeliveryNotFound) {
+				panic(errDeliveryNotFound)
+			} else if errors.Is(err, domain.ErrWebhookDeliveryPending) {
+				panic(errDeliveryPending)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(delivery))
+	}
+}
+
+func mustUUIDParam(r *http.Request, name string) uuid.UUID {
+	id, err := uuid.Parse(chi.URLParam(r, name))
+	if err != nil {
+		panic(errBadRequest.WrapError(err))
+	}
+	return id
+}
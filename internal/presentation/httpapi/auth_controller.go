@@ -4,19 +4,28 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
 	"time"
 
 	"ddd-example/internal/app/handler"
 	"ddd-example/internal/domain"
 	"ddd-example/internal/option"
 	"ddd-example/pkg/logger"
+	"ddd-example/pkg/oauth"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
-var visitorKey contextKey = "__VISITOR__"
+var (
+	visitorKey     contextKey = "__VISITOR__"
+	sessionKey     contextKey = "__SESSION__"
+	permissionsKey contextKey = "__PERMISSIONS__"
+)
 
 type contextKey any
 
@@ -26,13 +35,36 @@ type authController struct {
 
 	opt *option.Options `do:""`
 
-	authorize         *handler.AuthorizeHandler         `do:""`
-	changePassword    *handler.ChangePasswordHandler    `do:""`
-	loginWithEmail    *handler.LoginWithEmailHandler    `do:""`
-	logout            *handler.LogoutHandler            `do:""`
-	register          *handler.RegisterHandler          `do:""`
-	registerWithOauth *handler.RegisterWithOauthHandler `do:""`
-	verifyOauth       *handler.VerifyOauthHandler       `do:""`
+	authorize           *handler.AuthorizeHandler            `do:""`
+	changePassword      *handler.ChangePasswordHandler       `do:""`
+	confirmTOTP         *handler.ConfirmTOTPHandler          `do:""`
+	changeEmail         *handler.ChangeEmailHandler          `do:""`
+	confirmEmailChange  *handler.ConfirmEmailChangeHandler   `do:""`
+	deleteAccount       *handler.DeleteAccountHandler        `do:""`
+	disableTOTP         *handler.DisableTOTPHandler          `do:""`
+	downloadDataExport  *handler.DownloadDataExportHandler   `do:""`
+	enrollTOTP          *handler.EnrollTOTPHandler           `do:""`
+	listOauth           *handler.ListOauthHandler            `do:""`
+	listSessions        *handler.ListSessionsHandler         `do:""`
+	loginWithEmail      *handler.LoginWithEmailHandler       `do:""`
+	loginWithOauth      *handler.LoginWithOauthHandler       `do:""`
+	logout              *handler.LogoutHandler               `do:""`
+	register            *handler.RegisterHandler             `do:""`
+	registerWithOauth   *handler.RegisterWithOauthHandler    `do:""`
+	requestDataExport   *handler.RequestDataExportHandler    `do:""`
+	requestMagicLink    *handler.RequestMagicLinkHandler     `do:""`
+	requestResetPwd     *handler.RequestPasswordResetHandler `do:""`
+	resendVerification  *handler.ResendVerificationHandler   `do:""`
+	resetPassword       *handler.ResetPasswordHandler        `do:""`
+	resolvePermissions  *handler.ResolvePermissionsHandler   `do:""`
+	revertEmailChange   *handler.RevertEmailChangeHandler    `do:""`
+	revokeOtherSessions *handler.RevokeOtherSessionsHandler  `do:""`
+	revokeSession       *handler.RevokeSessionHandler        `do:""`
+	unbindOauth         *handler.UnbindOauthHandler          `do:""`
+	verifyEmail         *handler.VerifyEmailHandler          `do:""`
+	verifyMagicLink     *handler.VerifyMagicLinkHandler      `do:""`
+	verifyMFA           *handler.VerifyMFAHandler            `do:""`
+	verifyOauth         *handler.VerifyOauthHandler          `do:""`
 
 	// revive:enable:struct-tag
 }
@@ -41,14 +73,19 @@ type authController struct {
 func (c *authController) Authorize(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if payload, ok := c.readSessionToken(r); ok {
-			account, newPayload, err := c.authorize.Handle(r.Context(), payload)
+			account, sessionID, newPayload, err := c.authorize.Handle(r.Context(), payload, clientInfo(r))
 			if err == nil {
-				r = r.WithContext(context.WithValue(r.Context(), visitorKey, account))
+				ctx := context.WithValue(r.Context(), visitorKey, account)
+				ctx = context.WithValue(ctx, sessionKey, sessionID)
+				ctx = context.WithValue(ctx, permissionsKey, &visitorPermissions{})
+				r = r.WithContext(ctx)
 
 				if newPayload != "" {
 					c.writeSessionToken(newPayload, w)
 				}
-			} else if !errors.Is(err, domain.ErrSessionTokenExpired) {
+			} else if _, inactive := inactiveAccount(err); !inactive &&
+				!errors.Is(err, domain.ErrSessionTokenExpired) &&
+				!errors.Is(err, domain.ErrSessionNotFound) {
 				// 只记录错误，不中断请求
 				logger.Error(r.Context(), "authorize visitor", "error", err)
 			}
@@ -66,6 +103,53 @@ func (c *authController) DenyAnonymous(next http.Handler) http.Handler {
 	})
 }
 
+// RequireVerifiedEmail 要求访问者email已经通过验证中间件，需要在DenyAnonymous之后使用
+func (c *authController) RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if visitor := mustVisitorFromCtx(r.Context()); !visitor.EmailVerified {
+			panic(errEmailNotVerified)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequirePermission 要求访问者拥有指定权限中间件，需要在DenyAnonymous之后使用
+func (c *authController) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			perms, err := c.permissionsFromCtx(r.Context())
+			if err != nil {
+				panic(errUnexpectedException.WrapError(err))
+			} else if !perms.Allow(permission) {
+				panic(errForbidden)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// visitorPermissions 访问者的权限，同一个请求内只查询一次
+type visitorPermissions struct {
+	once  sync.Once
+	perms domain.Permissions
+	err   error
+}
+
+// permissionsFromCtx 访问者拥有的权限，第一次使用时按访问者的角色查询
+func (c *authController) permissionsFromCtx(ctx context.Context) (domain.Permissions, error) {
+	visitor := mustVisitorFromCtx(ctx)
+
+	v, ok := ctx.Value(permissionsKey).(*visitorPermissions)
+	if !ok {
+		return c.resolvePermissions.Handle(ctx, visitor)
+	}
+
+	v.once.Do(func() {
+		v.perms, v.err = c.resolvePermissions.Handle(ctx, visitor)
+	})
+	return v.perms, v.err
+}
+
 func (c *authController) writeSessionToken(token string, w http.ResponseWriter) {
 	payload := base64.RawURLEncoding.EncodeToString([]byte(token))
 	http.SetCookie(w, &http.Cookie{
@@ -77,6 +161,34 @@ func (c *authController) writeSessionToken(token string, w http.ResponseWriter)
 	})
 }
 
+// writeOauthState 把三方登录的state绑定到访问者，验证回调时核对
+func (c *authController) writeOauthState(state string, w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "OAUTH_STATE",
+		Value:    state,
+		Path:     "/login/oauth",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+	})
+}
+
+func (c *authController) readOauthState(r *http.Request) string {
+	if cookie, err := r.Cookie("OAUTH_STATE"); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// clearOauthState state只能使用一次
+func (c *authController) clearOauthState(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "OAUTH_STATE",
+		Path:     "/login/oauth",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
 func (c *authController) readSessionToken(r *http.Request) (string, bool) {
 	if cookie, err := r.Cookie("VISITOR"); err == nil {
 		data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
@@ -94,27 +206,122 @@ func (c *authController) readSessionToken(r *http.Request) (string, bool) {
 // LoginWithEmail email登录
 func (c *authController) LoginWithEmail() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		req := handler.LoginWithEmail{}
+		req := handler.LoginWithEmail{
+			ClientInfo: clientInfo(r),
+		}
 		mustScanJSON(&req, r.Body)
 
-		_, token, err := c.loginWithEmail.Handle(r.Context(), req)
+		result, err := c.loginWithEmail.Handle(r.Context(), req)
 		if err != nil {
 			if errors.Is(err, domain.ErrAccountNotFound) || errors.Is(err, domain.ErrWrongPassword) {
 				panic(errUnauthorized)
+			} else if errors.Is(err, domain.ErrLoginLocked) {
+				panic(loginLocked(w, err))
+			} else if apiErr, ok := inactiveAccount(err); ok {
+				panic(apiErr)
 			}
 			panic(errUnexpectedException.WrapError(err))
+		} else if challenge := result.MFAChallenge; challenge != "" {
+			// 需要两步验证，不下发会话凭证
+			sendResponse(w,
+				withStatusCode(http.StatusAccepted),
+				withData(mapAny{
+					"mfa_challenge": challenge,
+				}),
+			)
+			return
 		}
 
-		c.writeSessionToken(token, w)
+		c.writeSessionToken(result.SessionToken, w)
 		sendResponse(w, withStatusCode(http.StatusCreated))
 	}
 }
 
+// RequestMagicLink 申请免密码登录，登录凭证通过邮件发送
+//
+// 无论email是否存在都返回同样的结果
+func (c *authController) RequestMagicLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.RequestMagicLink{}
+		mustScanJSON(&req, r.Body)
+
+		if err := c.requestMagicLink.Handle(r.Context(), req); err != nil {
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withStatusCode(http.StatusAccepted))
+	}
+}
+
+// VerifyMagicLink 使用邮件中的登录凭证登录
+func (c *authController) VerifyMagicLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.VerifyMagicLink{
+			ClientInfo: clientInfo(r),
+		}
+		mustScanJSON(&req, r.Body)
+
+		result, err := c.verifyMagicLink.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidToken) {
+				panic(errInvalidToken.WrapError(err))
+			} else if apiErr, ok := inactiveAccount(err); ok {
+				panic(apiErr)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		} else if challenge := result.MFAChallenge; challenge != "" {
+			// 需要两步验证，不下发会话凭证
+			sendResponse(w,
+				withStatusCode(http.StatusAccepted),
+				withData(mapAny{
+					"mfa_challenge": challenge,
+				}),
+			)
+			return
+		}
+
+		c.writeSessionToken(result.SessionToken, w)
+		sendResponse(w, withStatusCode(http.StatusCreated))
+	}
+}
+
+// VerifyMFA 使用挑战凭证和两步验证码完成登录
+func (c *authController) VerifyMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.VerifyMFA{
+			ClientInfo: clientInfo(r),
+		}
+		mustScanJSON(&req, r.Body)
+
+		account, token, err := c.verifyMFA.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidToken) {
+				panic(errInvalidToken.WrapError(err))
+			} else if errors.Is(err, domain.ErrWrongMFACode) {
+				panic(errWrongMFACode.WrapError(err))
+			} else if errors.Is(err, domain.ErrLoginLocked) {
+				panic(loginLocked(w, err))
+			} else if apiErr, ok := inactiveAccount(err); ok {
+				panic(apiErr)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		c.writeSessionToken(token, w)
+		sendResponse(w,
+			withStatusCode(http.StatusCreated),
+			withData(mapAny{
+				"account": account,
+			}),
+		)
+	}
+}
+
 // Logout 退出登录
 func (c *authController) Logout() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if account, ok := visitorFromCtx(r.Context()); ok {
-			if err := c.logout.Handle(r.Context(), account); err != nil {
+			if err := c.logout.Handle(r.Context(), account, sessionFromCtx(r.Context())); err != nil {
 				panic(errUnexpectedException.WrapError(err))
 			}
 		}
@@ -126,7 +333,9 @@ func (c *authController) Logout() http.HandlerFunc {
 // Register 账号注册
 func (c *authController) Register() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		req := handler.Register{}
+		req := handler.Register{
+			ClientInfo: clientInfo(r),
+		}
 		mustScanJSON(&req, r.Body)
 
 		_, token, err := c.register.Handle(r.Context(), req)
@@ -142,6 +351,42 @@ func (c *authController) Register() http.HandlerFunc {
 	}
 }
 
+// VerifyEmail 使用邮件内的凭证验证email
+func (c *authController) VerifyEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.VerifyEmail{}
+		mustScanJSON(&req, r.Body)
+
+		account, err := c.verifyEmail.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidToken) ||
+				errors.Is(err, domain.ErrTokenExpired) ||
+				errors.Is(err, domain.ErrAccountNotFound) {
+				panic(errInvalidToken.WrapError(err))
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"account": account,
+		}))
+	}
+}
+
+// ResendVerification 重新发送email验证邮件
+func (c *authController) ResendVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := c.resendVerification.Handle(r.Context(), mustVisitorFromCtx(r.Context())); err != nil {
+			if errors.Is(err, domain.ErrEmailAlreadyVerified) {
+				panic(errEmailVerified)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withStatusCode(http.StatusAccepted))
+	}
+}
+
 // ChangePassword 修改密码
 func (c *authController) ChangePassword() http.HandlerFunc {
 	return func(_ http.ResponseWriter, r *http.Request) {
@@ -159,6 +404,178 @@ func (c *authController) ChangePassword() http.HandlerFunc {
 	}
 }
 
+// ChangeEmail 申请修改email，确认凭证发送到新email
+func (c *authController) ChangeEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.ChangeEmail{
+			Account:    mustVisitorFromCtx(r.Context()),
+			ClientInfo: clientInfo(r),
+		}
+		mustScanJSON(&req, r.Body)
+
+		if err := c.changeEmail.Handle(r.Context(), req); err != nil {
+			if errors.Is(err, domain.ErrWrongPassword) {
+				panic(errWrongPassword)
+			} else if errors.Is(err, domain.ErrLoginLocked) {
+				panic(loginLocked(w, err))
+			} else if errors.Is(err, domain.ErrEmailRegistered) {
+				panic(errEmailRegistered)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withStatusCode(http.StatusAccepted))
+	}
+}
+
+// ConfirmEmailChange 使用新email收到的凭证确认修改，所有会话失效
+func (c *authController) ConfirmEmailChange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.ConfirmEmailChange{}
+		mustScanJSON(&req, r.Body)
+
+		account, err := c.confirmEmailChange.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidToken) || errors.Is(err, domain.ErrTokenExpired) {
+				panic(errInvalidToken.WrapError(err))
+			} else if errors.Is(err, domain.ErrEmailRegistered) {
+				panic(errEmailRegistered)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"account": account,
+		}))
+	}
+}
+
+// RevertEmailChange 使用旧email收到的凭证撤销修改，所有会话失效
+func (c *authController) RevertEmailChange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.RevertEmailChange{}
+		mustScanJSON(&req, r.Body)
+
+		account, err := c.revertEmailChange.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidToken) || errors.Is(err, domain.ErrTokenExpired) {
+				panic(errInvalidToken.WrapError(err))
+			} else if errors.Is(err, domain.ErrEmailRegistered) {
+				panic(errEmailRegistered)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"account": account,
+		}))
+	}
+}
+
+// RequestPasswordReset 忘记密码，申请通过邮件重置
+//
+// 无论email是否存在都返回同样的结果
+func (c *authController) RequestPasswordReset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.RequestPasswordReset{}
+		mustScanJSON(&req, r.Body)
+
+		if err := c.requestResetPwd.Handle(r.Context(), req); err != nil {
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withStatusCode(http.StatusAccepted))
+	}
+}
+
+// ResetPassword 使用邮件凭证重置密码
+func (c *authController) ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.ResetPassword{}
+		mustScanJSON(&req, r.Body)
+
+		if err := c.resetPassword.Handle(r.Context(), req); err != nil {
+			if errors.Is(err, domain.ErrInvalidToken) {
+				panic(errInvalidToken.WrapError(err))
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w)
+	}
+}
+
+// EnrollTOTP 生成两步验证密钥
+func (c *authController) EnrollTOTP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, uri, err := c.enrollTOTP.Handle(r.Context(), handler.EnrollTOTP{
+			Account: mustVisitorFromCtx(r.Context()),
+			Issuer:  c.opt.MFAIssuer(),
+		})
+		if err != nil {
+			if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+				panic(errMFAEnabled)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"secret": secret,
+			"uri":    uri,
+		}))
+	}
+}
+
+// ConfirmTOTP 使用第一个验证码确认开启两步验证，返回恢复码
+func (c *authController) ConfirmTOTP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.ConfirmTOTP{
+			Account: mustVisitorFromCtx(r.Context()),
+		}
+		mustScanJSON(&req, r.Body)
+
+		recoveryCodes, err := c.confirmTOTP.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, domain.ErrWrongMFACode) {
+				panic(errWrongMFACode)
+			} else if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+				panic(errMFAEnabled)
+			} else if errors.Is(err, domain.ErrMFANotEnrolled) {
+				panic(errMFANotEnabled)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"recovery_codes": recoveryCodes,
+		}))
+	}
+}
+
+// DisableTOTP 关闭两步验证
+func (c *authController) DisableTOTP() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.DisableTOTP{
+			Account:    mustVisitorFromCtx(r.Context()),
+			ClientInfo: clientInfo(r),
+		}
+		mustScanJSON(&req, r.Body)
+
+		if err := c.disableTOTP.Handle(r.Context(), req); err != nil {
+			if errors.Is(err, domain.ErrWrongMFACode) {
+				panic(errWrongMFACode)
+			} else if errors.Is(err, domain.ErrLoginLocked) {
+				panic(loginLocked(w, err))
+			} else if errors.Is(err, domain.ErrMFANotEnabled) {
+				panic(errMFANotEnabled)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w)
+	}
+}
+
 // MyIdentity 当前访问者信息
 func (c *authController) MyIdentity() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -168,6 +585,74 @@ func (c *authController) MyIdentity() http.HandlerFunc {
 	}
 }
 
+// MySessions 当前账号的登录会话列表
+func (c *authController) MySessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		visitor := mustVisitorFromCtx(r.Context())
+		current := sessionFromCtx(r.Context())
+
+		sessions, err := c.listSessions.Handle(r.Context(), visitor)
+		if err != nil {
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		type item struct {
+			*domain.Session
+			Current bool `json:"current"`
+		}
+		items := make([]item, 0, len(sessions))
+		for _, s := range sessions {
+			items = append(items, item{
+				Session: s,
+				Current: s.ID == current,
+			})
+		}
+
+		sendResponse(w, withData(mapAny{
+			"sessions": items,
+		}))
+	}
+}
+
+// RevokeSession 撤销指定会话
+func (c *authController) RevokeSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			panic(errBadRequest.WrapError(err))
+		}
+
+		if err := c.revokeSession.Handle(r.Context(), handler.RevokeSession{
+			Account:   mustVisitorFromCtx(r.Context()),
+			SessionID: sessionID,
+		}); err != nil {
+			if errors.Is(err, domain.ErrSessionNotFound) {
+				panic(errSessionNotFound)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w)
+	}
+}
+
+// RevokeOtherSessions 撤销除当前会话之外的所有会话
+func (c *authController) RevokeOtherSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := c.revokeOtherSessions.Handle(
+			r.Context(),
+			mustVisitorFromCtx(r.Context()),
+			sessionFromCtx(r.Context()),
+		)
+		if err != nil {
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		c.writeSessionToken(token, w)
+		sendResponse(w)
+	}
+}
+
 // LoginWithOauth oauth三方登录，下发重定向地址
 func (c *authController) LoginWithOauth() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -176,13 +661,22 @@ func (c *authController) LoginWithOauth() http.HandlerFunc {
 			panic(errOauthNotSupport)
 		}
 
-		req := struct {
-			RedirectURI string `json:"redirect_uri" validate:"http_url"` // FIXME: 检查重定向地址域名有效性，防止钓鱼劫持
-		}{}
+		req := handler.LoginWithOauth{
+			Client: client,
+		}
 		mustScanValues(&req, r.URL.Query())
 
+		nextURL, state, err := c.loginWithOauth.Handle(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, oauth.ErrRedirectURINotAllowed) {
+				panic(errRedirectNotAllow.WrapError(err))
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+		c.writeOauthState(state, w)
+
 		sendResponse(w, withData(mapAny{
-			"next_url": client.AuthorizeURL(req.RedirectURI).String(),
+			"next_url": nextURL.String(),
 		}))
 	}
 }
@@ -198,7 +692,9 @@ func (c *authController) VerifyOauth() http.HandlerFunc {
 		}
 
 		req := handler.VerifyOauth{
-			Client: client,
+			Client:     client,
+			State:      c.readOauthState(r),
+			ClientInfo: clientInfo(r),
 		}
 		mustScanJSON(&req, r.Body)
 
@@ -210,9 +706,23 @@ func (c *authController) VerifyOauth() http.HandlerFunc {
 		}
 		req.Query = query
 
+		c.clearOauthState(w)
 		result, err := c.verifyOauth.Handle(r.Context(), req)
 		if err != nil {
+			if errors.Is(err, domain.ErrInvalidOauthState) {
+				panic(errInvalidOauthState.WrapError(err))
+			} else if errors.Is(err, oauth.ErrRedirectURINotAllowed) {
+				panic(errRedirectNotAllow.WrapError(err))
+			} else if apiErr, ok := inactiveAccount(err); ok {
+				panic(apiErr)
+			}
 			panic(errUnexpectedException.WrapError(err))
+		} else if challenge := result.MFAChallenge; challenge != "" {
+			// 需要两步验证，不下发会话凭证
+			sendResponse(w, withData(mapAny{
+				"mfa_challenge": challenge,
+			}))
+			return
 		} else if account := result.Account; account != nil {
 			// 下发会话凭证及登录账号信息
 			c.writeSessionToken(result.SessionToken, w)
@@ -237,10 +747,12 @@ func (c *authController) VerifyOauth() http.HandlerFunc {
 // RegisterWithOauth 三方账号绑定或注册
 func (c *authController) RegisterWithOauth() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		req := handler.RegisterWithOauth{}
+		req := handler.RegisterWithOauth{
+			ClientInfo: clientInfo(r),
+		}
 		mustScanJSON(&req, r.Body)
 
-		account, token, err := c.registerWithOauth.Handle(r.Context(), req)
+		result, err := c.registerWithOauth.Handle(r.Context(), req)
 		if err != nil {
 			if errors.Is(err, domain.ErrInvalidOauthToken) {
 				panic(errInvalidOauthToken)
@@ -248,23 +760,70 @@ func (c *authController) RegisterWithOauth() http.HandlerFunc {
 				panic(errUnauthorized)
 			} else if errors.Is(err, domain.ErrEmailRegistered) {
 				panic(errEmailRegistered)
+			} else if errors.Is(err, domain.ErrOauthBoundToOther) {
+				panic(errOauthBound)
+			} else if errors.Is(err, domain.ErrLoginLocked) {
+				panic(loginLocked(w, err))
+			} else if apiErr, ok := inactiveAccount(err); ok {
+				panic(apiErr)
 			}
 
 			panic(errUnexpectedException.WrapError(err))
+		} else if challenge := result.MFAChallenge; challenge != "" {
+			// 绑定的账号需要两步验证，不下发会话凭证
+			sendResponse(w,
+				withStatusCode(http.StatusAccepted),
+				withData(mapAny{
+					"mfa_challenge": challenge,
+				}),
+			)
+			return
 		}
 
-		c.writeSessionToken(token, w)
+		c.writeSessionToken(result.SessionToken, w)
 		sendResponse(w, withData(mapAny{
-			"account": account,
+			"account": result.Account,
 		}))
 	}
 }
 
+// loginLocked 设置Retry-After响应头，返回对应的接口错误
+func loginLocked(w http.ResponseWriter, err error) apiError {
+	var locked *domain.LoginLockedError
+	if errors.As(err, &locked) {
+		seconds := int(math.Ceil(time.Until(locked.Until).Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	}
+	return errLoginLocked.WrapError(err)
+}
+
+// inactiveAccount 账号不是正常状态时对应的接口错误
+func inactiveAccount(err error) (apiError, bool) {
+	switch {
+	case errors.Is(err, domain.ErrAccountDisabled):
+		return errAccountDisabled, true
+	case errors.Is(err, domain.ErrAccountLocked):
+		return errAccountLocked, true
+	case errors.Is(err, domain.ErrAccountPendingDeletion):
+		return errAccountDeleting, true
+	case errors.Is(err, domain.ErrAccountDeleted):
+		return errAccountDeleted, true
+	default:
+		return apiError{}, false
+	}
+}
+
 func visitorFromCtx(ctx context.Context) (*domain.Account, bool) {
 	account, ok := ctx.Value(visitorKey).(*domain.Account)
 	return account, ok
 }
 
+// sessionFromCtx 当前会话ID，没有会话记录的旧凭证返回uuid.Nil
+func sessionFromCtx(ctx context.Context) uuid.UUID {
+	id, _ := ctx.Value(sessionKey).(uuid.UUID)
+	return id
+}
+
 func mustVisitorFromCtx(ctx context.Context) *domain.Account {
 	account, ok := visitorFromCtx(ctx)
 	if !ok {
@@ -272,3 +831,113 @@ func mustVisitorFromCtx(ctx context.Context) *domain.Account {
 	}
 	return account
 }
+
+// MyOauth 当前账号绑定的三方账号
+func (c *authController) MyOauth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identities, err := c.listOauth.Handle(r.Context(), mustVisitorFromCtx(r.Context()))
+		if err != nil {
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withData(mapAny{
+			"items": identities,
+		}))
+	}
+}
+
+// DeleteAccount 申请注销账号
+//
+// 需要提交密码，没有密码的账号需要重新登录之后再申请，申请之后所有会话立即失效
+func (c *authController) DeleteAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := handler.DeleteAccount{
+			Account:    mustVisitorFromCtx(r.Context()),
+			SessionID:  sessionFromCtx(r.Context()),
+			ClientInfo: clientInfo(r),
+		}
+		if r.ContentLength != 0 {
+			mustScanJSON(&req, r.Body)
+		}
+
+		purgeAt, err := c.deleteAccount.Handle(r.Context(), req)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrWrongPassword):
+				panic(errWrongPassword)
+			case errors.Is(err, domain.ErrLoginLocked):
+				panic(loginLocked(w, err))
+			case errors.Is(err, domain.ErrReauthRequired):
+				panic(errReauthRequired)
+			case errors.Is(err, domain.ErrLastSuperAdmin):
+				panic(errLastSuperAdmin)
+			case errors.Is(err, domain.ErrInvalidStatusTransition):
+				panic(errStatusConflict)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withStatusCode(http.StatusAccepted), withData(mapAny{
+			"purge_at": purgeAt,
+		}))
+	}
+}
+
+// RequestDataExport 申请导出个人数据
+//
+// 导出文件由后台任务生成，完成后通过邮件发送下载链接
+func (c *authController) RequestDataExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		export, err := c.requestDataExport.Handle(r.Context(), mustVisitorFromCtx(r.Context()))
+		if err != nil {
+			if errors.Is(err, domain.ErrDataExportInProgress) {
+				panic(errExportInProgress)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w, withStatusCode(http.StatusAccepted), withData(export))
+	}
+}
+
+// DownloadDataExport 下载导出文件，使用邮件中的下载凭证，不需要登录
+func (c *authController) DownloadDataExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		export, f, err := c.downloadDataExport.Handle(r.Context(), chi.URLParam(r, "token"))
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrTokenExpired):
+				panic(errInvalidToken.WrapError(err))
+			case errors.Is(err, domain.ErrDataExportNotFound):
+				panic(errExportNotFound)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="export.zip"`)
+		w.Header().Set("Cache-Control", "no-store")
+		http.ServeContent(w, r, "", export.CreateAt, f)
+	}
+}
+
+// UnbindOauth 解绑三方账号
+func (c *authController) UnbindOauth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := c.unbindOauth.Handle(r.Context(), handler.UnbindOauth{
+			Account: mustVisitorFromCtx(r.Context()),
+			Vendor:  chi.URLParam(r, "site"),
+		})
+		if err != nil {
+			if errors.Is(err, domain.ErrOauthNotBound) {
+				panic(errOauthNotBound)
+			} else if errors.Is(err, domain.ErrLastLoginMethod) {
+				panic(errLastLoginMethod)
+			}
+			panic(errUnexpectedException.WrapError(err))
+		}
+
+		sendResponse(w)
+	}
+}
@@ -11,6 +11,36 @@ var (
 	errWrongPassword     = newAPIError(40003, "密码验证错误", http.StatusNotAcceptable)
 	errOauthNotSupport   = newAPIError(40004, "不支持的oauth服务", http.StatusNotFound)
 	errInvalidOauthToken = newAPIError(40005, "oauth凭证无效", http.StatusNotAcceptable)
+	errSessionNotFound   = newAPIError(40006, "会话不存在", http.StatusNotFound)
+	errInvalidToken      = newAPIError(40007, "凭证无效或已过期", http.StatusNotAcceptable)
+	errEmailVerified     = newAPIError(40008, "Email已经通过验证", http.StatusConflict)
+	errEmailNotVerified  = newAPIError(40009, "Email未验证", http.StatusForbidden)
+	errWrongMFACode      = newAPIError(40010, "两步验证码错误", http.StatusNotAcceptable)
+	errMFAEnabled        = newAPIError(40011, "已经开启两步验证", http.StatusConflict)
+	errMFANotEnabled     = newAPIError(40012, "没有开启两步验证", http.StatusConflict)
+	errInvalidOauthState = newAPIError(40013, "oauth state无效", http.StatusNotAcceptable)
+	errRedirectNotAllow  = newAPIError(40014, "重定向地址不在白名单内", http.StatusBadRequest)
+	errOauthBound        = newAPIError(40015, "三方账号已绑定其它账号", http.StatusConflict)
+	errOauthNotBound     = newAPIError(40016, "没有绑定这个三方账号", http.StatusNotFound)
+	errLastLoginMethod   = newAPIError(40017, "不能解绑唯一的登录方式", http.StatusConflict)
+	errForbidden         = newAPIError(40018, "没有访问权限", http.StatusForbidden)
+	errWebhookNotFound   = newAPIError(40019, "webhook不存在", http.StatusNotFound)
+	errDeliveryNotFound  = newAPIError(40020, "webhook投递记录不存在", http.StatusNotFound)
+	errDeliveryPending   = newAPIError(40021, "webhook投递记录还在等待投递", http.StatusConflict)
+	errUnknownEventType  = newAPIError(40022, "未知或者不能订阅的事件类型", http.StatusBadRequest)
+	errLoginLocked       = newAPIError(40023, "登录失败次数过多，请稍后再试", http.StatusTooManyRequests)
+	errTooManyRequests   = newAPIError(40024, "请求过于频繁，请稍后再试", http.StatusTooManyRequests)
+	errAccountNotFound   = newAPIError(40025, "账号不存在", http.StatusNotFound)
+	errRoleNotFound      = newAPIError(40026, "角色不存在", http.StatusNotFound)
+	errLastSuperAdmin    = newAPIError(40027, "不能撤销最后一个超级管理员", http.StatusConflict)
+	errAccountDisabled   = newAPIError(40028, "账号已停用", http.StatusForbidden)
+	errAccountLocked     = newAPIError(40029, "账号已被锁定，请联系客服", http.StatusForbidden)
+	errAccountDeleting   = newAPIError(40030, "账号已申请注销", http.StatusForbidden)
+	errAccountDeleted    = newAPIError(40031, "账号已注销", http.StatusForbidden)
+	errStatusConflict    = newAPIError(40032, "账号当前状态不允许这个操作", http.StatusConflict)
+	errReauthRequired    = newAPIError(40033, "请输入密码或者重新登录后再操作", http.StatusForbidden)
+	errExportInProgress  = newAPIError(40034, "数据导出正在生成中", http.StatusConflict)
+	errExportNotFound    = newAPIError(40035, "数据导出不存在或已过期", http.StatusNotFound)
 
 	errUnexpectedException = newAPIError(50000, "服务器端未知错误", http.StatusInternalServerError)
 )
@@ -0,0 +1,117 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ddd-example/internal/domain"
+	"ddd-example/internal/option"
+	"ddd-example/pkg/logger"
+	"ddd-example/pkg/ratelimit"
+)
+
+// rateLimiter 接口限流中间件
+type rateLimiter struct {
+	// revive:disable:struct-tag
+
+	opt   *option.Options `do:""`
+	store ratelimit.Store `do:""`
+
+	// revive:enable:struct-tag
+}
+
+// Limit 按配置文件里分组的策略限流，没有配置策略时不限流
+//
+// 响应包含RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset头，超过限制时返回429和Retry-After头。
+// 需要在Authorize之后使用，按账号限流时才能拿到访问者
+func (l *rateLimiter) Limit(group string) func(http.Handler) http.Handler {
+	policy, ok := l.opt.RateLimitPolicy(group)
+	if !ok {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := fmt.Sprintf("%s:%s", group, rateLimitKey(r, policy.Key))
+			result, err := l.store.Allow(r.Context(), key, policy.Limit)
+			if err != nil {
+				// 限流存储不可用时放行，不影响正常请求
+				logger.Error(r.Context(), "ratelimit", "group", group, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, ceilSeconds(policy.Period)))
+
+			if !result.Allowed {
+				header.Set("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+				panic(errTooManyRequests)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey 区分请求的key
+func rateLimitKey(r *http.Request, kind string) string {
+	switch {
+	case kind == option.RateLimitByAccount:
+		if visitor, ok := visitorFromCtx(r.Context()); ok {
+			return "account:" + visitor.ID.String()
+		}
+	case kind == option.RateLimitByEmail:
+		if email := peekEmail(r); email != "" {
+			return "email:" + email
+		}
+	case strings.HasPrefix(kind, option.RateLimitByHeader):
+		name := strings.TrimPrefix(kind, option.RateLimitByHeader)
+		if v := r.Header.Get(name); v != "" {
+			return "header:" + v
+		}
+	}
+	return "ip:" + clientInfo(r).IP
+}
+
+// 按email限流时读取的请求体大小上限
+const peekEmailLimit = 4096
+
+// peekEmail 读取请求JSON里的email字段，读取之后还原请求体，不影响后续处理
+func peekEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, peekEmailLimit))
+	if err != nil {
+		return ""
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return domain.NormalizeEmail(req.Email)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
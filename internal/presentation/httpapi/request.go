@@ -4,7 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"net/url"
+	"strings"
+
+	"ddd-example/internal/domain"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/schema"
@@ -23,6 +28,33 @@ func init() {
 	requestDecoder.SetAliasTag("json")
 }
 
+// clientInfo 从请求中获取客户端信息
+//
+// 设备名称由客户端通过X-Device-Name请求头提供
+func clientInfo(r *http.Request) domain.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return domain.ClientInfo{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+		Device:    r.Header.Get("X-Device-Name"),
+		Language:  preferredLanguage(r.Header.Get("Accept-Language")),
+	}
+}
+
+// preferredLanguage Accept-Language中的第一个语言，客户端一般按优先级排列
+func preferredLanguage(header string) string {
+	language, _, _ := strings.Cut(header, ",")
+	language, _, _ = strings.Cut(language, ";")
+	if language = strings.TrimSpace(language); language == "*" {
+		return ""
+	}
+	return language
+}
+
 func mustScanJSON(dst any, input io.Reader) {
 	if err := scanJSON(dst, input); err != nil {
 		panic(errBadRequest.WrapError(err))
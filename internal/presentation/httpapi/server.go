@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"ddd-example/internal/domain"
 	"ddd-example/internal/option"
 	"ddd-example/pkg/logger"
 
@@ -17,13 +18,17 @@ import (
 type Server struct {
 	server *http.Server
 
-	auth *authController
+	admin   *adminController
+	auth    *authController
+	limiter *rateLimiter
 }
 
 // ServerProvider 提供Server实例
 func ServerProvider(injector do.Injector) (*Server, error) {
 	s := &Server{
-		auth: do.MustInvoke[*authController](injector),
+		admin:   do.MustInvoke[*adminController](injector),
+		auth:    do.MustInvoke[*authController](injector),
+		limiter: do.MustInvoke[*rateLimiter](injector),
 	}
 
 	opt := do.MustInvoke[*option.Options](injector)
@@ -62,18 +67,81 @@ func (s *Server) newRouter() chi.Router {
 	ac := s.auth
 	router.Use(ac.Authorize)
 
-	router.Post(`/session`, ac.LoginWithEmail())
-	router.Post(`/register`, ac.Register())
+	// 限流策略按分组名称在配置文件[ratelimit.policies]中配置
+	limit := s.limiter.Limit
+
 	router.Delete(`/session`, ac.Logout())
-	router.Get(`/login/oauth/{site}`, ac.LoginWithOauth())
-	router.Post(`/login/oauth/{site}`, ac.VerifyOauth())
-	router.Post(`/register/oauth`, ac.RegisterWithOauth())
+
+	router.Group(func(router chi.Router) {
+		router.Use(limit("auth"))
+
+		router.Post(`/session`, ac.LoginWithEmail())
+		router.Post(`/session/mfa`, ac.VerifyMFA())
+		router.With(limit("magic_link")).Post(`/session/magic-link`, ac.RequestMagicLink())
+		router.Post(`/session/magic-link/verify`, ac.VerifyMagicLink())
+		router.Post(`/register`, ac.Register())
+		router.Post(`/register/verify`, ac.VerifyEmail())
+		router.Post(`/password/reset-requests`, ac.RequestPasswordReset())
+		router.Post(`/password/reset`, ac.ResetPassword())
+		router.Post(`/email/confirm`, ac.ConfirmEmailChange())
+		router.Post(`/email/revert`, ac.RevertEmailChange())
+		router.Get(`/exports/{token}`, ac.DownloadDataExport())
+	})
+
+	router.Group(func(router chi.Router) {
+		router.Use(limit("oauth"))
+
+		router.Get(`/login/oauth/{site}`, ac.LoginWithOauth())
+		router.Post(`/login/oauth/{site}`, ac.VerifyOauth())
+		router.Post(`/register/oauth`, ac.RegisterWithOauth())
+	})
 
 	router.Group(func(router chi.Router) {
 		router.Use(ac.DenyAnonymous)
+		router.Use(limit("account"))
 
 		router.Get(`/session`, ac.MyIdentity())
+		router.Post(`/register/verify/resend`, ac.ResendVerification())
+		router.Delete(`/my/account`, ac.DeleteAccount())
+		router.Post(`/my/export`, ac.RequestDataExport())
 		router.Put(`/my/password`, ac.ChangePassword())
+		router.Put(`/my/email`, ac.ChangeEmail())
+		router.Get(`/my/sessions`, ac.MySessions())
+		router.Delete(`/my/sessions`, ac.RevokeOtherSessions())
+		router.Delete(`/my/sessions/{id}`, ac.RevokeSession())
+		router.Get(`/my/oauth`, ac.MyOauth())
+		router.Delete(`/my/oauth/{site}`, ac.UnbindOauth())
+
+		router.Group(func(router chi.Router) {
+			router.Use(ac.RequireVerifiedEmail)
+
+			router.Post(`/my/mfa/totp`, ac.EnrollTOTP())
+			router.Put(`/my/mfa/totp`, ac.ConfirmTOTP())
+			router.Delete(`/my/mfa/totp`, ac.DisableTOTP())
+		})
+
+		adm := s.admin
+		can := ac.RequirePermission
+		router.Route(`/admin`, func(router chi.Router) {
+			router.With(can(domain.PermissionAccountsRead)).Get(`/accounts`, adm.SearchAccounts())
+			router.With(can(domain.PermissionAccountsRead)).Get(`/accounts/{id}`, adm.GetAccount())
+			router.With(can(domain.PermissionAccountsWrite)).Post(`/accounts/{id}/disable`, adm.DisableAccount())
+			router.With(can(domain.PermissionAccountsWrite)).Post(`/accounts/{id}/enable`, adm.EnableAccount())
+			router.With(can(domain.PermissionAccountsWrite)).Post(`/accounts/{id}/lock`, adm.LockAccount())
+			router.With(can(domain.PermissionAccountsWrite)).Post(`/accounts/{id}/unlock`, adm.UnlockAccount())
+			router.With(can(domain.PermissionAccountsWrite)).Delete(`/accounts/{id}/sessions`, adm.SuspendAccountSessions())
+			router.With(can(domain.PermissionAccountsWrite)).Post(`/accounts/{id}/password-reset`, adm.SendPasswordReset())
+
+			router.With(can(domain.PermissionRolesRead)).Get(`/roles`, adm.ListRoles())
+			router.With(can(domain.PermissionRolesWrite)).Put(`/accounts/{id}/roles/{role}`, adm.AssignRole())
+			router.With(can(domain.PermissionRolesWrite)).Delete(`/accounts/{id}/roles/{role}`, adm.RevokeRole())
+
+			router.With(can(domain.PermissionWebhooksRead)).Get(`/webhooks`, adm.ListWebhooks())
+			router.With(can(domain.PermissionWebhooksWrite)).Post(`/webhooks`, adm.CreateWebhook())
+			router.With(can(domain.PermissionWebhooksWrite)).Delete(`/webhooks/{id}`, adm.DeleteWebhook())
+			router.With(can(domain.PermissionWebhooksRead)).Get(`/webhooks/{id}/deliveries`, adm.ListWebhookDeliveries())
+			router.With(can(domain.PermissionWebhooksWrite)).Post(`/webhooks/deliveries/{id}/replay`, adm.ReplayWebhookDelivery())
+		})
 	})
 
 	return router
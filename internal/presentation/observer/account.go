@@ -4,38 +4,46 @@ import (
 	"context"
 
 	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/handler"
 	"ddd-example/pkg/logger"
 
 	"github.com/reactivex/rxgo/v2"
 )
 
-// 在用户注册成功之后发送邮件
-type emailNotifier struct{}
+// 根据账号事件发送邮件
+//
+// 邮件在后台异步发送，这里只负责渲染模板并放入发送队列，不会被邮件服务器阻塞
+type emailNotifier struct {
+	send *handler.SendAccountEmailHandler
+}
 
 func (o *emailNotifier) Subscribe(ctx context.Context, events rxgo.Observable) rxgo.Disposed {
 	logger := logger.FromContext(ctx).With("scope", "observer.emailNotifier")
 	logger.Info("start")
 
-	return events.
-		Filter(func(item any) bool {
-			_, ok := item.(event.Register)
-			return ok
-		}).
-		ForEach(
-			func(item any) {
-				ev := item.(event.Register)
-
-				// 这里就不具体实现邮件发送，打条日志意思一下
-				logger.Info("send email to new user", "email", ev.Account.Email)
-			},
-			func(err error) {
-				logger.Error("handle event", "error", err)
-			},
-			func() {
-				logger.Warn("complete")
-			},
-
-			rxgo.WithContext(ctx),
-			rxgo.WithBufferedChannel(10),
-		)
+	return events.ForEach(
+		event.Observe(emailNotifierName, func(item any) error {
+			switch item.(type) {
+			case event.Register, event.VerificationRequested, event.EmailChangeRequested, event.EmailRevertRequested,
+				event.MagicLinkRequested, event.PasswordResetRequested, event.PasswordChanged, event.DataExportReady:
+			default:
+				return nil
+			}
+
+			if err := o.send.Handle(ctx, item); err != nil {
+				logger.Error("send email", "type", event.TypeOf(item), "error", err)
+				return err
+			}
+			return nil
+		}),
+		func(err error) {
+			logger.Error("handle event", "error", err)
+		},
+		func() {
+			logger.Warn("complete")
+		},
+
+		rxgo.WithContext(ctx),
+		rxgo.WithBufferedChannel(10),
+	)
 }
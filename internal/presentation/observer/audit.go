@@ -0,0 +1,40 @@
+package observer
+
+import (
+	"context"
+
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/handler"
+	"ddd-example/pkg/logger"
+
+	"github.com/reactivex/rxgo/v2"
+)
+
+// 保存账号相关的事件记录，用于登录历史和个人数据导出
+type auditRecorder struct {
+	record *handler.RecordAccountEventHandler
+}
+
+func (o *auditRecorder) Subscribe(ctx context.Context, events rxgo.Observable) rxgo.Disposed {
+	logger := logger.FromContext(ctx).With("scope", "observer.auditRecorder")
+	logger.Info("start")
+
+	return events.ForEach(
+		event.Observe(auditRecorderName, func(item any) error {
+			if err := o.record.Handle(ctx, item); err != nil {
+				logger.Error("record account event", "type", event.TypeOf(item), "error", err)
+				return err
+			}
+			return nil
+		}),
+		func(err error) {
+			logger.Error("handle event", "error", err)
+		},
+		func() {
+			logger.Warn("complete")
+		},
+
+		rxgo.WithContext(ctx),
+		rxgo.WithBufferedChannel(10),
+	)
+}
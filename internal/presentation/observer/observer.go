@@ -4,12 +4,29 @@ import (
 	"context"
 
 	"ddd-example/internal/app/event"
-	"ddd-example/internal/option"
+	"ddd-example/internal/app/handler"
+
+	"github.com/samber/do/v2"
+)
+
+// 观察者名称，会随outbox消息保存，不要修改
+const (
+	auditRecorderName   = "audit"
+	emailNotifierName   = "email"
+	webhookNotifierName = "webhook"
 )
 
 // Start 启动领域事件观察者
-func Start(ctx context.Context, opt *option.Options) {
-	(&emailNotifier{}).Subscribe(ctx, event.Stream)
+func Start(ctx context.Context, injector do.Injector) {
+	(&auditRecorder{
+		record: do.MustInvoke[*handler.RecordAccountEventHandler](injector),
+	}).Subscribe(ctx, event.Stream)
+	(&emailNotifier{
+		send: do.MustInvoke[*handler.SendAccountEmailHandler](injector),
+	}).Subscribe(ctx, event.Stream)
+	(&webhookNotifier{
+		enqueue: do.MustInvoke[*handler.EnqueueWebhooksHandler](injector),
+	}).Subscribe(ctx, event.Stream)
 }
 
 // Stop 关闭领域事件流
@@ -0,0 +1,43 @@
+package observer
+
+import (
+	"context"
+
+	"ddd-example/internal/app/event"
+	"ddd-example/internal/app/handler"
+	"ddd-example/pkg/logger"
+
+	"github.com/reactivex/rxgo/v2"
+)
+
+// 为订阅了事件的webhook生成投递记录，实际发送由后台任务完成
+type webhookNotifier struct {
+	enqueue *handler.EnqueueWebhooksHandler
+}
+
+func (o *webhookNotifier) Subscribe(ctx context.Context, events rxgo.Observable) rxgo.Disposed {
+	logger := logger.FromContext(ctx).With("scope", "observer.webhookNotifier")
+	logger.Info("start")
+
+	return events.ForEach(
+		event.Observe(webhookNotifierName, func(item any) error {
+			n, err := o.enqueue.Handle(ctx, item)
+			if err != nil {
+				logger.Error("enqueue webhooks", "error", err)
+				return err
+			} else if n > 0 {
+				logger.Debug("enqueue webhooks", "count", n)
+			}
+			return nil
+		}),
+		func(err error) {
+			logger.Error("handle event", "error", err)
+		},
+		func() {
+			logger.Warn("complete")
+		},
+
+		rxgo.WithContext(ctx),
+		rxgo.WithBufferedChannel(10),
+	)
+}
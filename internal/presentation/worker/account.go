@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"ddd-example/internal/app/handler"
+	"ddd-example/pkg/logger"
+
+	"github.com/samber/do/v2"
+)
+
+const (
+	// 注销账号清除间隔
+	accountPurgeInterval = time.Hour
+	// 过期会话清理间隔
+	sessionPurgeInterval = time.Hour
+)
+
+func startAccount(ctx context.Context, injector do.Injector) {
+	purge := do.MustInvoke[*handler.PurgeAccountsHandler](injector)
+	every(ctx, "account.purge", accountPurgeInterval, func(ctx context.Context) error {
+		for {
+			n, err := purge.Handle(ctx)
+			if err != nil {
+				return err
+			} else if n == 0 {
+				return nil
+			}
+
+			logger.Info(ctx, "purge deleted accounts", "count", n)
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	})
+
+	purgeSessions := do.MustInvoke[*handler.PurgeSessionsHandler](injector)
+	every(ctx, "session.purge", sessionPurgeInterval, func(ctx context.Context) error {
+		n, err := purgeSessions.Handle(ctx)
+		if err == nil && n > 0 {
+			logger.Info(ctx, "purge expired sessions", "count", n)
+		}
+		return err
+	})
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"ddd-example/internal/app/handler"
+	"ddd-example/pkg/logger"
+
+	"github.com/samber/do/v2"
+)
+
+// 数据导出任务间隔
+const (
+	exportBuildInterval = 5 * time.Second
+	exportPurgeInterval = time.Hour
+)
+
+func startExport(ctx context.Context, injector do.Injector) {
+	build := do.MustInvoke[*handler.BuildDataExportsHandler](injector)
+	every(ctx, "export.build", exportBuildInterval, func(ctx context.Context) error {
+		for {
+			n, err := build.Handle(ctx)
+			if err != nil {
+				return err
+			} else if n == 0 {
+				return nil
+			}
+
+			logger.Debug(ctx, "build data exports", "count", n)
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	})
+
+	purge := do.MustInvoke[*handler.PurgeDataExportsHandler](injector)
+	every(ctx, "export.purge", exportPurgeInterval, func(ctx context.Context) error {
+		for {
+			n, err := purge.Handle(ctx)
+			if err != nil {
+				return err
+			} else if n == 0 {
+				return nil
+			}
+
+			logger.Info(ctx, "purge expired data exports", "count", n)
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	})
+}
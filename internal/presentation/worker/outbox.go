@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"ddd-example/internal/app/handler"
+	"ddd-example/pkg/logger"
+
+	"github.com/samber/do/v2"
+)
+
+const (
+	// outbox轮询间隔
+	outboxInterval = time.Second
+	// outbox清理间隔
+	outboxPurgeInterval = time.Hour
+	// 已投递事件保留7天，投递失败的保留30天
+	outboxDispatchedRetention = 7 * 24 * time.Hour
+	outboxFailedRetention     = 30 * 24 * time.Hour
+)
+
+func startOutbox(ctx context.Context, injector do.Injector) {
+	dispatch := do.MustInvoke[*handler.DispatchOutboxHandler](injector)
+	every(ctx, "outbox.dispatch", outboxInterval, func(ctx context.Context) error {
+		// 有积压时连续投递，直到没有到期的事件
+		for {
+			n, err := dispatch.Handle(ctx)
+			if err != nil {
+				return err
+			} else if n == 0 {
+				return nil
+			}
+
+			logger.Debug(ctx, "dispatch outbox events", "count", n)
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	})
+
+	purge := do.MustInvoke[*handler.PurgeOutboxHandler](injector)
+	every(ctx, "outbox.purge", outboxPurgeInterval, func(ctx context.Context) error {
+		n, err := purge.Handle(ctx, handler.PurgeOutbox{
+			DispatchedRetention: outboxDispatchedRetention,
+			FailedRetention:     outboxFailedRetention,
+		})
+		if err == nil && n > 0 {
+			logger.Info(ctx, "purge outbox events", "count", n)
+		}
+		return err
+	})
+}
@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"ddd-example/internal/app/handler"
+	"ddd-example/pkg/logger"
+
+	"github.com/samber/do/v2"
+)
+
+// webhook投递轮询间隔
+const webhookInterval = 5 * time.Second
+
+func startWebhook(ctx context.Context, injector do.Injector) {
+	deliver := do.MustInvoke[*handler.DeliverWebhooksHandler](injector)
+	every(ctx, "webhook.deliver", webhookInterval, func(ctx context.Context) error {
+		for {
+			n, err := deliver.Handle(ctx)
+			if err != nil {
+				return err
+			} else if n == 0 {
+				return nil
+			}
+
+			logger.Debug(ctx, "deliver webhooks", "count", n)
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	})
+}
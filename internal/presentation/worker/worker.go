@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ddd-example/pkg/logger"
+
+	"github.com/samber/do/v2"
+)
+
+var wg sync.WaitGroup
+
+// Start 启动后台定时任务
+func Start(ctx context.Context, injector do.Injector) {
+	startAccount(ctx, injector)
+	startExport(ctx, injector)
+	startOutbox(ctx, injector)
+	startWebhook(ctx, injector)
+}
+
+// Stop 等待后台任务结束，调用前需要先取消Start使用的ctx
+func Stop() {
+	wg.Wait()
+}
+
+// every 按固定间隔执行任务，ctx取消后退出
+func every(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ctx := logger.NewContext(ctx, logger.FromContext(ctx).With("scope", "worker."+name))
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := fn(ctx); err != nil && ctx.Err() == nil {
+				logger.Error(ctx, "run task", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,246 @@
+package keyring
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoActiveKey 没有可用的签名密钥
+	ErrNoActiveKey = errors.New("no active key")
+	// ErrUnknownKey 密钥不存在或者已经过了宽限期
+	ErrUnknownKey = errors.New("unknown key")
+
+	// 检查密钥文件是否被其它进程更新的间隔
+	reloadInterval = time.Minute
+)
+
+// Key 签名密钥
+type Key struct {
+	ID       string    `json:"id"`
+	Secret   []byte    `json:"secret"`
+	CreateAt time.Time `json:"create_at"`
+	// 被新密钥替换的时间，替换之后只用于验证，不再用于签名
+	RetireAt time.Time `json:"retire_at,omitzero"`
+}
+
+// IsRetired 是否已经被替换
+func (k Key) IsRetired() bool {
+	return !k.RetireAt.IsZero()
+}
+
+// KeyRing 服务器端签名密钥环
+//
+// 最新的密钥用于签名，被替换的旧密钥在宽限期内仍然可以用于验证
+type KeyRing struct {
+	file  string
+	grace time.Duration
+
+	mu       sync.RWMutex
+	keys     []Key
+	modTime  time.Time
+	loadTime time.Time
+}
+
+// Load 从文件载入密钥环，文件不存在时会生成新的密钥并保存
+func Load(file string, grace time.Duration) (*KeyRing, error) {
+	kr := &KeyRing{file: file, grace: grace}
+
+	if err := kr.load(); errors.Is(err, os.ErrNotExist) {
+		if _, err := kr.Rotate(); err != nil {
+			return nil, fmt.Errorf("generate key, %w", err)
+		} else if err := kr.Save(); err != nil {
+			return nil, fmt.Errorf("save keyring, %w", err)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return kr, nil
+}
+
+// Active 当前用于签名的密钥
+func (kr *KeyRing) Active() (Key, error) {
+	kr.reload()
+
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	for i := len(kr.keys) - 1; i >= 0; i-- {
+		if k := kr.keys[i]; !k.IsRetired() {
+			return k, nil
+		}
+	}
+	return Key{}, ErrNoActiveKey
+}
+
+// Get 获取可用于验证的密钥，已经超过宽限期的密钥不会被返回
+func (kr *KeyRing) Get(id string) (Key, error) {
+	kr.reload()
+
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	now := time.Now()
+	for _, k := range kr.keys {
+		if k.ID != id {
+			continue
+		} else if k.IsRetired() && k.RetireAt.Add(kr.grace).Before(now) {
+			break
+		}
+		return k, nil
+	}
+	return Key{}, ErrUnknownKey
+}
+
+// Sign 使用当前密钥计算HMAC-SHA256签名
+func (kr *KeyRing) Sign(data []byte) (keyID string, signature []byte, err error) {
+	k, err := kr.Active()
+	if err != nil {
+		return "", nil, err
+	}
+	return k.ID, k.Sign(data), nil
+}
+
+// Verify 使用指定密钥验证签名
+func (kr *KeyRing) Verify(keyID string, data, signature []byte) error {
+	k, err := kr.Get(keyID)
+	if err != nil {
+		return err
+	} else if !hmac.Equal(k.Sign(data), signature) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// Rotate 生成新的签名密钥，当前密钥转为只用于验证，并清除已经超过宽限期的密钥
+func (kr *KeyRing) Rotate() (Key, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, fmt.Errorf("generate secret, %w", err)
+	}
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, fmt.Errorf("generate id, %w", err)
+	}
+
+	now := time.Now()
+	key := Key{
+		ID:       hex.EncodeToString(id),
+		Secret:   secret,
+		CreateAt: now,
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	keys := make([]Key, 0, len(kr.keys)+1)
+	for _, k := range kr.keys {
+		if !k.IsRetired() {
+			k.RetireAt = now
+		} else if k.RetireAt.Add(kr.grace).Before(now) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	kr.keys = append(keys, key)
+
+	return key, nil
+}
+
+// Save 保存到文件
+func (kr *KeyRing) Save() error {
+	kr.mu.RLock()
+	data, err := json.MarshalIndent(kr.keys, "", "\t")
+	kr.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encode keys, %w", err)
+	}
+
+	// 先写临时文件再改名，避免其它进程读到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(kr.file), filepath.Base(kr.file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	} else if err := tmp.Close(); err != nil {
+		return err
+	} else if err := os.Rename(tmp.Name(), kr.file); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(kr.file); err == nil {
+		kr.mu.Lock()
+		kr.modTime = info.ModTime()
+		kr.loadTime = time.Now()
+		kr.mu.Unlock()
+	}
+	return nil
+}
+
+func (kr *KeyRing) load() error {
+	info, err := os.Stat(kr.file)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(kr.file)
+	if err != nil {
+		return err
+	}
+
+	var keys []Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("decode keys, %w", err)
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	kr.keys = keys
+	kr.modTime = info.ModTime()
+	kr.loadTime = time.Now()
+	return nil
+}
+
+// reload 密钥文件可能被命令行工具轮换，定期检查并重新载入
+func (kr *KeyRing) reload() {
+	kr.mu.RLock()
+	skip := kr.file == "" || time.Since(kr.loadTime) < reloadInterval
+	modTime := kr.modTime
+	kr.mu.RUnlock()
+	if skip {
+		return
+	}
+
+	if info, err := os.Stat(kr.file); err == nil && info.ModTime().After(modTime) {
+		if err := kr.load(); err == nil {
+			return
+		}
+	}
+
+	kr.mu.Lock()
+	kr.loadTime = time.Now()
+	kr.mu.Unlock()
+}
+
+// Sign 计算HMAC-SHA256签名
+func (k Key) Sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, k.Secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,52 @@
+package keyring
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeyRing(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.keys")
+
+	kr, err := Load(file, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("hello world")
+	oldID, signature, err := kr.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := kr.Verify(oldID, data, signature); err != nil {
+		t.Fatalf("verify signature, %v", err)
+	} else if err := kr.Verify(oldID, []byte("hello"), signature); err == nil {
+		t.Fatal("verify should fail with different data")
+	}
+
+	// 轮换之后，旧密钥在宽限期内仍然可以验证
+	newKey, err := kr.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	} else if err := kr.Save(); err != nil {
+		t.Fatal(err)
+	} else if active, _ := kr.Active(); active.ID != newKey.ID {
+		t.Fatal("new key should be active")
+	} else if err := kr.Verify(oldID, data, signature); err != nil {
+		t.Fatalf("retired key should verify in grace window, %v", err)
+	}
+
+	reloaded, err := Load(file, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	} else if active, _ := reloaded.Active(); active.ID != newKey.ID {
+		t.Fatal("reloaded keyring should use the new key")
+	}
+
+	// 超过宽限期之后，旧密钥不再可用
+	reloaded.grace = 0
+	if err := reloaded.Verify(oldID, data, signature); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FileSender 把邮件保存为.eml文件，用于开发环境查看邮件内容
+type FileSender struct {
+	from string
+	dir  string
+}
+
+// NewFileSender 构造函数
+func NewFileSender(from, dir string) (*FileSender, error) {
+	if dir == "" {
+		return nil, errors.New("need mail dir")
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mail dir, %w", err)
+	}
+
+	return &FileSender{from: from, dir: dir}, nil
+}
+
+var unsafeFilename = regexp.MustCompile(`[^a-zA-Z0-9@._-]+`)
+
+// Send 保存邮件，文件名为时间和收件人
+func (s *FileSender) Send(_ context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = s.from
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		return fmt.Errorf("encode message, %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml",
+		time.Now().Format("20060102T150405.000000000"),
+		unsafeFilename.ReplaceAllString(msg.To[0], "_"),
+	)
+	return os.WriteFile(filepath.Join(s.dir, name), data, 0o644)
+}
+
+// MemorySender 把邮件保存在内存中，用于测试
+type MemorySender struct {
+	from string
+
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewMemorySender 构造函数
+func NewMemorySender(from string) *MemorySender {
+	return &MemorySender{from: from}
+}
+
+// Send 保存邮件
+func (s *MemorySender) Send(_ context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = s.from
+	}
+
+	if _, err := msg.Bytes(); err != nil {
+		return fmt.Errorf("encode message, %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages 已发送的邮件
+func (s *MemorySender) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
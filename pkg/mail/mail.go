@@ -0,0 +1,153 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message 邮件内容，Text和HTML至少需要一个
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender 邮件发送
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Option 邮件发送配置
+type Option struct {
+	// smtp、file或memory
+	Driver string `toml:"driver"`
+	// 发件人，例如 "Example <noreply@example.com>"
+	From string `toml:"from"`
+
+	// smtp服务器，465端口使用TLS连接，其它端口在服务器支持时使用STARTTLS
+	Host     string `toml:"host"`
+	Port     int    `toml:"port"`
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+
+	// file方式保存邮件的目录
+	Dir string `toml:"dir"`
+}
+
+// New 按配置构造Sender
+func New(opt Option) (Sender, error) {
+	if _, err := mail.ParseAddress(opt.From); err != nil {
+		return nil, fmt.Errorf("invalid from address, %w", err)
+	}
+
+	switch opt.Driver {
+	case "smtp":
+		return NewSMTPSender(opt)
+	case "file":
+		return NewFileSender(opt.From, opt.Dir)
+	case "memory":
+		return NewMemorySender(opt.From), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %q", opt.Driver)
+	}
+}
+
+// Bytes 编码为RFC 5322格式
+func (msg *Message) Bytes() ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, errors.New("no recipient")
+	} else if msg.Text == "" && msg.HTML == "" {
+		return nil, errors.New("empty body")
+	}
+
+	buf := &bytes.Buffer{}
+	header := func(key, value string) {
+		fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+	}
+
+	header("From", msg.From)
+	for _, to := range msg.To {
+		header("To", to)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(msg.From))
+	header("MIME-Version", "1.0")
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain; charset=utf-8", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html; charset=utf-8", msg.HTML
+		}
+
+		header("Content-Type", contentType)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(buf)
+	header("Content-Type", "multipart/alternative; boundary="+w.Boundary())
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		} else if err := writeQuotedPrintable(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func messageID(from string) string {
+	host := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndex(addr.Address, "@"); i >= 0 {
+			host = addr.Address[i+1:]
+		}
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), host)
+}
@@ -0,0 +1,196 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMessageBytes(t *testing.T) {
+	msg := &Message{
+		From:    "Example <noreply@example.com>",
+		To:      []string{"test@example.com"},
+		Subject: "验证邮箱",
+		Text:    "验证码 123456",
+		HTML:    "<p>验证码 <b>123456</b></p>",
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := &mime.WordDecoder{}
+	if subject, err := dec.DecodeHeader(m.Header.Get("Subject")); err != nil {
+		t.Fatal(err)
+	} else if subject != msg.Subject {
+		t.Fatalf("unexpected subject %q", subject)
+	} else if !strings.HasSuffix(m.Header.Get("Message-ID"), "@example.com>") {
+		t.Fatalf("unexpected message id %q", m.Header.Get("Message-ID"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	} else if mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type %q", mediaType)
+	}
+
+	var bodies []string
+	r := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		part, err := r.NextRawPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, string(body))
+	}
+
+	if len(bodies) != 2 || bodies[0] != msg.Text || bodies[1] != msg.HTML {
+		t.Fatalf("unexpected bodies %q", bodies)
+	}
+
+	if _, err := (&Message{To: msg.To}).Bytes(); err == nil {
+		t.Fatal("empty body should fail")
+	} else if _, err := (&Message{Text: "hello"}).Bytes(); err == nil {
+		t.Fatal("no recipient should fail")
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mails")
+	sender, err := New(Option{Driver: "file", From: "noreply@example.com", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sender.Send(context.Background(), &Message{
+		To:      []string{"test@example.com"},
+		Subject: "hello",
+		Text:    "hello world",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-test@example.com.eml"))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(data), "From: noreply@example.com") {
+		t.Fatal("default from address not used")
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go serveSMTP(t, ln, received)
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	sender, err := New(Option{
+		Driver: "smtp",
+		From:   "Example <noreply@example.com>",
+		Host:   host,
+		Port:   portNum,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sender.Send(context.Background(), &Message{
+		To:      []string{"Test <test@example.com>"},
+		Subject: "hello",
+		Text:    "hello world",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if data := <-received; !strings.Contains(data, "hello world") {
+		t.Fatalf("unexpected data %q", data)
+	}
+}
+
+// serveSMTP 只支持一次会话的smtp服务器
+func serveSMTP(t *testing.T, ln net.Listener, received chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) {
+		_, _ = io.WriteString(conn, s+"\r\n")
+	}
+
+	reply("220 localhost ESMTP")
+	var data strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:<NOREPLY@EXAMPLE.COM>"),
+			strings.HasPrefix(cmd, "RCPT TO:<TEST@EXAMPLE.COM>"):
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end with .")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				} else if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			received <- data.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			t.Errorf("unexpected command %q", line)
+			reply("500 unexpected")
+		}
+	}
+}
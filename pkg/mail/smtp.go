@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPSender 通过smtp服务器发送邮件
+type SMTPSender struct {
+	from     string
+	addr     string
+	host     string
+	username string
+	password string
+}
+
+// NewSMTPSender 构造函数
+func NewSMTPSender(opt Option) (*SMTPSender, error) {
+	if opt.Host == "" {
+		return nil, errors.New("need smtp host")
+	}
+
+	port := opt.Port
+	if port == 0 {
+		port = 587
+	}
+
+	return &SMTPSender{
+		from:     opt.From,
+		addr:     net.JoinHostPort(opt.Host, strconv.Itoa(port)),
+		host:     opt.Host,
+		username: opt.Username,
+		password: opt.Password,
+	}, nil
+}
+
+// Send 发送邮件，每封邮件使用单独的连接
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = s.from
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		return fmt.Errorf("encode message, %w", err)
+	}
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("parse from address, %w", err)
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("smtp auth, %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp mail, %w", err)
+	}
+	for _, to := range msg.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("parse recipient address, %w", err)
+		} else if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("smtp rcpt, %w", err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data, %w", err)
+	} else if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("write message, %w", err)
+	} else if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data, %w", err)
+	}
+	return client.Quit()
+}
+
+func (s *SMTPSender) dial(ctx context.Context) (*smtp.Client, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	tlsConfig := &tls.Config{ServerName: s.host}
+
+	var conn net.Conn
+	var err error
+	if _, port, _ := net.SplitHostPort(s.addr); port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", s.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect smtp server, %w", err)
+	}
+
+	// 整个会话的超时，防止缓慢的服务器一直占用连接
+	deadline := time.Now().Add(time.Minute)
+	if v, ok := ctx.Deadline(); ok && v.Before(deadline) {
+		deadline = v
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake, %w", err)
+	}
+
+	if _, ok := conn.(*tls.Conn); !ok {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("smtp starttls, %w", err)
+			}
+		}
+	}
+	return client, nil
+}
@@ -1,6 +1,9 @@
 package oauth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"net/http"
 	"net/url"
@@ -18,8 +21,9 @@ var (
 
 // Client 客户端
 type Client interface {
-	AuthorizeURL(redirectURI string) *url.URL
-	Authorize(code string, redirectURI string) (*User, error)
+	AuthorizeURL(redirectURI string, params AuthParams) (*url.URL, error)
+	Authorize(code string, redirectURI string, params AuthParams) (*User, error)
+	VerifyRedirectURI(redirectURI string) error
 	Vendor() string
 }
 
@@ -27,21 +31,90 @@ type Client interface {
 type Options struct {
 	ClientID     string `toml:"client_id"`
 	ClientSecret string `toml:"client_secret"`
+	// OpenID Connect服务地址，配置之后使用通用OIDC客户端
+	Issuer string `toml:"issuer"`
+	// OpenID Connect申请的scope，默认为openid email profile
+	Scopes []string `toml:"scopes"`
+	// 允许的重定向地址白名单
+	RedirectURIs []string `toml:"redirect_uris"`
+}
+
+// AuthParams 一次登录流程中，跳转到三方站点和验证回调时需要保持一致的参数
+type AuthParams struct {
+	// 回调时原样返回，防止login CSRF
+	State string `json:"state"`
+	// OpenID Connect nonce，防止ID token重放
+	Nonce string `json:"nonce"`
+	// PKCE验证码，RFC 7636，防止code被截获后注入
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// NewAuthParams 生成随机参数
+func NewAuthParams() (AuthParams, error) {
+	var params AuthParams
+	for _, v := range []*string{&params.State, &params.Nonce, &params.CodeVerifier} {
+		s, err := randomString(32)
+		if err != nil {
+			return AuthParams{}, err
+		}
+		*v = s
+	}
+	return params, nil
+}
+
+// CodeChallenge PKCE S256 code challenge
+func (p AuthParams) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(p.CodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// setAuthorizeQuery 跳转到三方站点时附带state及PKCE参数
+func (p AuthParams) setAuthorizeQuery(query url.Values) {
+	if p.State != "" {
+		query.Set("state", p.State)
+	}
+	if p.CodeVerifier != "" {
+		query.Set("code_challenge", p.CodeChallenge())
+		query.Set("code_challenge_method", "S256")
+	}
+}
+
+// setTokenQuery 使用code换取token时附带PKCE验证码
+func (p AuthParams) setTokenQuery(query url.Values) {
+	if p.CodeVerifier != "" {
+		query.Set("code_verifier", p.CodeVerifier)
+	}
 }
 
 // User 三方平台用户信息
 type User struct {
-	Vendor      string `json:"vendor"`
-	AccessToken string `json:"access_token"`
-	ID          string `json:"id"`
+	Vendor        string `json:"vendor"`
+	AccessToken   string `json:"access_token"`
+	ID            string `json:"id"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Picture       string `json:"picture,omitempty"`
 }
 
 // NewClient 构造函数
+//
+// 配置了issuer的站点使用通用OpenID Connect客户端
 func NewClient(site string, opt *Options) (Client, error) {
-	switch site {
-	case "facebook":
+	switch {
+	case site == "facebook":
 		return &facebook{opt: opt}, nil
+	case opt.Issuer != "":
+		return newOIDC(site, opt)
 	default:
 		return nil, ErrNotImplemented
 	}
 }
+
+func randomString(n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
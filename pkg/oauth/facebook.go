@@ -12,20 +12,21 @@ type facebook struct {
 	opt *Options
 }
 
-func (fb *facebook) AuthorizeURL(redirectURI string) *url.URL {
+func (fb *facebook) AuthorizeURL(redirectURI string, params AuthParams) (*url.URL, error) {
 	query := url.Values{}
 	query.Set("client_id", fb.opt.ClientID)
 	query.Set("response_type", "code")
 	query.Set("redirect_uri", redirectURI)
+	params.setAuthorizeQuery(query)
 
 	authURL, _ := url.Parse("https://www.facebook.com/v14.0/dialog/oauth")
 	authURL.RawQuery = query.Encode()
 
-	return authURL
+	return authURL, nil
 }
 
-func (fb *facebook) Authorize(code, redirectURI string) (*User, error) {
-	accessToken, err := fb.requestAccessToken(code, redirectURI)
+func (fb *facebook) Authorize(code, redirectURI string, params AuthParams) (*User, error) {
+	accessToken, err := fb.requestAccessToken(code, redirectURI, params)
 	if err != nil {
 		return nil, fmt.Errorf("get access token, %w", err)
 	}
@@ -41,16 +42,21 @@ func (fb *facebook) Authorize(code, redirectURI string) (*User, error) {
 	}, nil
 }
 
+func (fb *facebook) VerifyRedirectURI(redirectURI string) error {
+	return fb.opt.VerifyRedirectURI(redirectURI)
+}
+
 func (fb *facebook) Vendor() string {
 	return "facebook"
 }
 
-func (fb *facebook) requestAccessToken(code, redirectURI string) (string, error) {
+func (fb *facebook) requestAccessToken(code, redirectURI string, params AuthParams) (string, error) {
 	query := url.Values{}
 	query.Set("client_id", fb.opt.ClientID)
 	query.Set("client_secret", fb.opt.ClientSecret)
 	query.Set("redirect_uri", redirectURI)
 	query.Set("code", code)
+	params.setTokenQuery(query)
 
 	requestURL, _ := url.Parse("https://graph.facebook.com/v14.0/oauth/access_token")
 	requestURL.RawQuery = query.Encode()
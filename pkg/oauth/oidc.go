@@ -0,0 +1,464 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// 验证ID token时间时允许的时钟误差
+	oidcClockSkew = time.Minute
+	// 遇到未知的签名密钥时，重新获取JWKS的最小间隔
+	oidcKeysRefreshInterval = time.Minute
+)
+
+var defaultOIDCScopes = []string{"openid", "email", "profile"}
+
+// oidc 通用OpenID Connect客户端
+//
+// 通过issuer的/.well-known/openid-configuration获取服务端点及签名密钥，
+// 适用于Google、Microsoft、Keycloak等标准实现
+type oidc struct {
+	name string
+	opt  *Options
+
+	mu          sync.Mutex
+	provider    *oidcProvider
+	keys        map[string]crypto.PublicKey
+	keysFetchAt time.Time
+}
+
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func newOIDC(name string, opt *Options) (*oidc, error) {
+	if opt.Issuer == "" {
+		return nil, errors.New("empty issuer")
+	} else if opt.ClientID == "" {
+		return nil, errors.New("empty client id")
+	}
+
+	return &oidc{
+		name: name,
+		opt:  opt,
+	}, nil
+}
+
+func (c *oidc) AuthorizeURL(redirectURI string, params AuthParams) (*url.URL, error) {
+	provider, err := c.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := url.Parse(provider.AuthorizationEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse authorization endpoint, %w", err)
+	}
+
+	query := authURL.Query()
+	query.Set("client_id", c.opt.ClientID)
+	query.Set("response_type", "code")
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", strings.Join(c.scopes(), " "))
+	if params.Nonce != "" {
+		query.Set("nonce", params.Nonce)
+	}
+	params.setAuthorizeQuery(query)
+	authURL.RawQuery = query.Encode()
+
+	return authURL, nil
+}
+
+func (c *oidc) Authorize(code, redirectURI string, params AuthParams) (*User, error) {
+	provider, err := c.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, idToken, err := c.requestToken(provider, code, redirectURI, params)
+	if err != nil {
+		return nil, fmt.Errorf("get token, %w", err)
+	}
+
+	claims, err := c.verifyIDToken(provider, idToken, params.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("verify id token, %w", err)
+	}
+
+	return &User{
+		AccessToken:   accessToken,
+		ID:            claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+	}, nil
+}
+
+func (c *oidc) VerifyRedirectURI(redirectURI string) error {
+	return c.opt.VerifyRedirectURI(redirectURI)
+}
+
+func (c *oidc) Vendor() string {
+	return c.name
+}
+
+func (c *oidc) scopes() []string {
+	scopes := c.opt.Scopes
+	if len(scopes) == 0 {
+		return defaultOIDCScopes
+	} else if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	return scopes
+}
+
+// discover 获取服务端点，成功之后缓存结果
+func (c *oidc) discover() (*oidcProvider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider != nil {
+		return c.provider, nil
+	}
+
+	configURL := strings.TrimSuffix(c.opt.Issuer, "/") + "/.well-known/openid-configuration"
+	provider := &oidcProvider{}
+	if err := getJSON(configURL, provider); err != nil {
+		return nil, fmt.Errorf("discover provider, %w", err)
+	}
+
+	// OpenID Connect Discovery 1.0, section 4.3
+	if provider.Issuer != c.opt.Issuer {
+		return nil, fmt.Errorf("issuer mismatch, expected %q, got %q", c.opt.Issuer, provider.Issuer)
+	} else if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.New("incomplete provider metadata")
+	}
+
+	c.provider = provider
+	return provider, nil
+}
+
+func (c *oidc) requestToken(provider *oidcProvider, code, redirectURI string, params AuthParams) (accessToken, idToken string, err error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", c.opt.ClientID)
+	if v := c.opt.ClientSecret; v != "" {
+		form.Set("client_secret", v)
+	}
+	params.setTokenQuery(form)
+
+	response, err := httpClient.PostForm(provider.TokenEndpoint, form)
+	if err != nil {
+		return "", "", fmt.Errorf("send request, %w", err)
+	}
+	defer response.Body.Close()
+
+	body := struct {
+		AccessToken      string `json:"access_token"`
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return "", "", fmt.Errorf("decode response, status %d, %w", response.StatusCode, err)
+	} else if body.Error != "" {
+		return "", "", fmt.Errorf("%s: %s", body.Error, body.ErrorDescription)
+	} else if code := response.StatusCode; code > 299 {
+		return "", "", fmt.Errorf("response status %d", code)
+	} else if body.IDToken == "" {
+		return "", "", errors.New("response without id token")
+	}
+	return body.AccessToken, body.IDToken, nil
+}
+
+type idTokenClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	AuthorizedBy  string   `json:"azp"`
+	Expire        int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	Picture       string   `json:"picture"`
+}
+
+// verifyIDToken 验证ID token签名及声明，OpenID Connect Core 1.0, section 3.1.3.7
+func (c *oidc) verifyIDToken(provider *oidcProvider, token, nonce string) (*idTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("decode header, %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature, %w", err)
+	}
+
+	key, err := c.publicKey(provider, header.Kid)
+	if err != nil {
+		return nil, err
+	} else if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, fmt.Errorf("decode claims, %w", err)
+	}
+
+	now := time.Now()
+	if claims.Issuer != provider.Issuer {
+		return nil, fmt.Errorf("issuer mismatch, %q", claims.Issuer)
+	} else if !slices.Contains(claims.Audience, c.opt.ClientID) {
+		return nil, fmt.Errorf("audience mismatch, %v", claims.Audience)
+	} else if len(claims.Audience) > 1 && claims.AuthorizedBy != c.opt.ClientID {
+		return nil, fmt.Errorf("authorized party mismatch, %q", claims.AuthorizedBy)
+	} else if time.Unix(claims.Expire, 0).Add(oidcClockSkew).Before(now) {
+		return nil, errors.New("token expired")
+	} else if time.Unix(claims.IssuedAt, 0).Add(-oidcClockSkew).After(now) {
+		return nil, errors.New("token issued in the future")
+	} else if claims.Nonce != nonce {
+		return nil, errors.New("nonce mismatch")
+	} else if claims.Subject == "" {
+		return nil, errors.New("empty subject")
+	}
+	return claims, nil
+}
+
+// publicKey 查找签名密钥，找不到时重新获取JWKS以支持服务端密钥轮换
+func (c *oidc) publicKey(provider *oidcProvider, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.lookupKey(kid); ok {
+		return key, nil
+	} else if c.keys != nil && time.Since(c.keysFetchAt) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := fetchJWKS(provider.JWKSURI)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks, %w", err)
+	}
+	c.keys = keys
+	c.keysFetchAt = time.Now()
+
+	if key, ok := c.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (c *oidc) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+func fetchJWKS(jwksURI string) (map[string]crypto.PublicKey, error) {
+	body := struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}{}
+	if err := getJSON(jwksURI, &body); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(body.Keys))
+	for _, k := range body.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %q, decode n, %w", k.Kid, err)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("key %q, decode e, %w", k.Kid, err)
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("key %q, decode x, %w", k.Kid, err)
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("key %q, decode y, %w", k.Kid, err)
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+	return keys, nil
+}
+
+// verifySignature 只支持非对称签名算法，拒绝none及HS*
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %q mismatch rsa key", alg)
+		} else if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return fmt.Errorf("invalid signature, %w", err)
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("algorithm %q mismatch ecdsa key", alg)
+		} else if len(signature) != 2*size {
+			return errors.New("invalid signature length")
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
+
+func getJSON(u string, dst any) error {
+	response, err := httpClient.Get(u)
+	if err != nil {
+		return fmt.Errorf("send request, %w", err)
+	}
+	defer response.Body.Close()
+	if code := response.StatusCode; code > 299 {
+		return fmt.Errorf("response status %d", code)
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decode response, %w", err)
+	}
+	return nil
+}
+
+func decodeSegment(seg string, dst any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// audience aud声明可以是字符串或者字符串数组
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// flexBool 部分服务商的email_verified声明是"true"字符串
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		*b = flexBool(strings.EqualFold(v, "true"))
+	}
+	return nil
+}
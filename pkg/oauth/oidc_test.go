@@ -0,0 +1,261 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIdP 进程内的OpenID Connect服务端
+type fakeIdP struct {
+	server *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+
+	// 下一次token请求返回的ID token，由测试用例构造
+	claims map[string]any
+	alg    string
+	// 授权请求时提交的PKCE code challenge
+	challenge string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &fakeIdP{
+		rsaKey: rsaKey,
+		ecKey:  ecKey,
+		alg:    "RS256",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, _ *http.Request) {
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		writeJSON(w, map[string]any{
+			"keys": []map[string]any{
+				{
+					"kty": "RSA",
+					"kid": "rsa1",
+					"use": "sig",
+					"n":   b64(rsaKey.N.Bytes()),
+					"e":   b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+				},
+				{
+					"kty": "EC",
+					"kid": "ec1",
+					"use": "sig",
+					"crv": "P-256",
+					"x":   b64(ecKey.X.FillBytes(make([]byte, size))),
+					"y":   b64(ecKey.Y.FillBytes(make([]byte, size))),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]any{"error": "invalid_grant"})
+			return
+		}
+
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if idp.challenge != "" && b64(sum[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]any{"error": "invalid_grant", "error_description": "code verifier mismatch"})
+			return
+		}
+
+		writeJSON(w, map[string]any{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t),
+		})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T) string {
+	kid := "rsa1"
+	if strings.HasPrefix(idp.alg, "ES") {
+		kid = "ec1"
+	}
+
+	header, _ := json.Marshal(map[string]any{"alg": idp.alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(idp.claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch idp.alg {
+	case "RS256":
+		sig, err := rsa.SignPKCS1v15(rand.Reader, idp.rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = sig
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, idp.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	default:
+		// 伪造的签名
+		signature = []byte("signature")
+	}
+
+	return signed + "." + b64(signature)
+}
+
+func (idp *fakeIdP) validClaims(nonce string) map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":            idp.server.URL,
+		"sub":            "user-1",
+		"aud":            "client-1",
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          "alice@example.com",
+		"email_verified": "true",
+		"name":           "Alice",
+	}
+}
+
+func TestOIDC(t *testing.T) {
+	idp := newFakeIdP(t)
+
+	client, err := NewClient("keycloak", &Options{
+		ClientID:     "client-1",
+		ClientSecret: "secret",
+		Issuer:       idp.server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := NewAuthParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := client.AuthorizeURL("https://example.com/callback", params)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(authURL.String(), idp.server.URL+"/authorize?") {
+		t.Fatalf("unexpected authorize url %s", authURL)
+	} else if query := authURL.Query(); query.Get("nonce") != params.Nonce {
+		t.Fatal("authorize url without nonce")
+	} else if query.Get("scope") != "openid email profile" {
+		t.Fatalf("unexpected scope %q", query.Get("scope"))
+	} else if query.Get("state") != params.State {
+		t.Fatal("authorize url without state")
+	} else if query.Get("code_challenge_method") != "S256" {
+		t.Fatal("authorize url without pkce")
+	}
+	idp.challenge = authURL.Query().Get("code_challenge")
+
+	for _, alg := range []string{"RS256", "ES256"} {
+		idp.alg = alg
+		idp.claims = idp.validClaims(params.Nonce)
+
+		user, err := client.Authorize("good-code", "https://example.com/callback", params)
+		if err != nil {
+			t.Fatalf("%s, %v", alg, err)
+		} else if user.ID != "user-1" || user.Email != "alice@example.com" || !user.EmailVerified || user.Name != "Alice" {
+			t.Fatalf("%s, unexpected user %+v", alg, user)
+		}
+	}
+
+	cases := map[string]func(claims map[string]any){
+		"wrong issuer":   func(claims map[string]any) { claims["iss"] = "https://evil.example.com" },
+		"wrong audience": func(claims map[string]any) { claims["aud"] = []string{"client-2"} },
+		"wrong azp": func(claims map[string]any) {
+			claims["aud"] = []string{"client-1", "client-2"}
+			claims["azp"] = "client-2"
+		},
+		"expired":     func(claims map[string]any) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"wrong nonce": func(claims map[string]any) { claims["nonce"] = "other" },
+	}
+	idp.alg = "RS256"
+	for name, modify := range cases {
+		idp.claims = idp.validClaims(params.Nonce)
+		modify(idp.claims)
+
+		if _, err := client.Authorize("good-code", "https://example.com/callback", params); err == nil {
+			t.Fatalf("%s, should be rejected", name)
+		}
+	}
+
+	idp.alg = "HS256"
+	idp.claims = idp.validClaims(params.Nonce)
+	if _, err := client.Authorize("good-code", "https://example.com/callback", params); err == nil {
+		t.Fatal("symmetric algorithm should be rejected")
+	}
+
+	idp.alg = "RS256"
+	if _, err := client.Authorize("bad-code", "https://example.com/callback", params); err == nil {
+		t.Fatal("invalid code should be rejected")
+	}
+
+	other, err := NewAuthParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Nonce = params.Nonce
+	if _, err := client.Authorize("good-code", "https://example.com/callback", other); err == nil {
+		t.Fatal("wrong code verifier should be rejected")
+	}
+}
+
+func TestOIDCIssuerMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+
+	client, err := NewClient("keycloak", &Options{
+		ClientID: "client-1",
+		Issuer:   idp.server.URL + "/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := client.AuthorizeURL("https://example.com/callback", AuthParams{}); err == nil {
+		t.Fatal("discovery with mismatched issuer should fail")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
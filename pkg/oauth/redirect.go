@@ -0,0 +1,85 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrRedirectURINotAllowed 重定向地址不在白名单内
+var ErrRedirectURINotAllowed = errors.New("redirect uri not allowed")
+
+// VerifyRedirectURI 检查重定向地址是否在白名单内，防止code被重定向到钓鱼站点
+//
+// 白名单支持三种写法，可以组合使用：
+//
+//	https://app.example.com/callback  完整地址
+//	https://*.example.com/callback    任意子域名
+//	https://app.example.com/oauth/*   路径前缀
+//
+// 没有配置白名单时拒绝所有地址
+func (opt *Options) VerifyRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return fmt.Errorf("%w, %w", ErrRedirectURINotAllowed, err)
+	} else if !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("%w, not absolute url", ErrRedirectURINotAllowed)
+	} else if u.User != nil {
+		return fmt.Errorf("%w, url with userinfo", ErrRedirectURINotAllowed)
+	} else if u.Fragment != "" || strings.Contains(redirectURI, "#") {
+		return fmt.Errorf("%w, url with fragment", ErrRedirectURINotAllowed)
+	} else if hasDotSegment(u.Path) {
+		return fmt.Errorf("%w, path with dot segment", ErrRedirectURINotAllowed)
+	} else if len(opt.RedirectURIs) == 0 {
+		return fmt.Errorf("%w, empty allowlist", ErrRedirectURINotAllowed)
+	}
+
+	for _, pattern := range opt.RedirectURIs {
+		if matchRedirectURI(pattern, u) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w, no pattern matches %q", ErrRedirectURINotAllowed, redirectURI)
+}
+
+func matchRedirectURI(pattern string, u *url.URL) bool {
+	p, err := url.Parse(pattern)
+	if err != nil {
+		return false
+	}
+
+	if !strings.EqualFold(p.Scheme, u.Scheme) || p.Port() != u.Port() {
+		return false
+	}
+
+	host, patternHost := strings.ToLower(u.Hostname()), strings.ToLower(p.Hostname())
+	if suffix, ok := strings.CutPrefix(patternHost, "*."); ok {
+		if !strings.HasSuffix(host, "."+suffix) {
+			return false
+		}
+	} else if host != patternHost {
+		return false
+	}
+
+	if prefix, ok := strings.CutSuffix(p.Path, "*"); ok {
+		if !strings.HasPrefix(u.Path, prefix) {
+			return false
+		}
+	} else if u.Path != p.Path {
+		return false
+	}
+
+	// 白名单地址包含query时要求完全一致
+	return p.RawQuery == "" || p.RawQuery == u.RawQuery
+}
+
+// hasDotSegment 路径包含.或者..时，浏览器规范化之后可能跳出前缀范围
+func hasDotSegment(p string) bool {
+	for seg := range strings.SplitSeq(p, "/") {
+		if seg == "." || seg == ".." {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(p), "%2e")
+}
@@ -0,0 +1,55 @@
+package oauth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyRedirectURI(t *testing.T) {
+	opt := &Options{
+		RedirectURIs: []string{
+			"https://www.example.com/login/callback",
+			"https://*.example.net/login/callback",
+			"https://app.example.org/oauth/*",
+			"http://localhost:3000/callback",
+		},
+	}
+
+	cases := map[string]bool{
+		"https://www.example.com/login/callback":          true,
+		"https://WWW.example.com/login/callback":          true,
+		"https://www.example.com/login/callback?from=1":   true,
+		"http://www.example.com/login/callback":           false,
+		"https://www.example.com/login/callback/evil":     false,
+		"https://www.example.com:8443/login/callback":     false,
+		"https://example.com/login/callback":              false,
+		"https://a.example.net/login/callback":            true,
+		"https://a.b.example.net/login/callback":          true,
+		"https://example.net/login/callback":              false,
+		"https://evilexample.net/login/callback":          false,
+		"https://a.example.net.evil.com/login/callback":   false,
+		"https://app.example.org/oauth/":                  true,
+		"https://app.example.org/oauth/facebook":          true,
+		"https://app.example.org/oauthx":                  false,
+		"https://app.example.org/oauth/../admin":          false,
+		"https://app.example.org/oauth/%2e%2e/admin":      false,
+		"https://app.example.org/oauth/cb#fragment":       false,
+		"https://www.example.com@evil.com/login/callback": false,
+		"http://localhost:3000/callback":                  true,
+		"http://localhost/callback":                       false,
+		"/login/callback":                                 false,
+	}
+
+	for uri, allowed := range cases {
+		err := opt.VerifyRedirectURI(uri)
+		if allowed && err != nil {
+			t.Fatalf("%s should be allowed, %v", uri, err)
+		} else if !allowed && !errors.Is(err, ErrRedirectURINotAllowed) {
+			t.Fatalf("%s should be rejected", uri)
+		}
+	}
+
+	if err := (&Options{}).VerifyRedirectURI("https://www.example.com/login/callback"); err == nil {
+		t.Fatal("empty allowlist should reject all")
+	}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 清理已经补满的桶的间隔
+const memorySweepInterval = time.Minute
+
+// MemoryStore 本地内存存储，只适用于单个服务实例
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	// 补满的时间
+	fullAt time.Time
+}
+
+// NewMemoryStore 构造函数
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   map[string]*memoryBucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 从key对应的桶里取一个令牌
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{}
+		s.buckets[key] = b
+	}
+
+	result := b.take(now, limit)
+	b.fullAt = now.Add(b.fullAfter(limit))
+
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		s.sweep(now)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Limit 令牌桶参数，每Period补充Rate个令牌，桶内最多Burst个令牌
+type Limit struct {
+	Rate   int           `toml:"rate"`
+	Period time.Duration `toml:"period"`
+	// 默认等于Rate
+	Burst int `toml:"burst"`
+}
+
+// Validate 检查参数
+func (l Limit) Validate() error {
+	if l.Rate <= 0 {
+		return errors.New("rate must be positive")
+	} else if l.Period <= 0 {
+		return errors.New("period must be positive")
+	} else if l.Burst < 0 {
+		return errors.New("burst must not be negative")
+	}
+	return nil
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// interval 补充一个令牌的时长
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// Result 一次请求的限流结果
+type Result struct {
+	Allowed bool
+	// 桶的容量
+	Limit int
+	// 剩余的令牌数量
+	Remaining int
+	// 令牌补满需要的时长
+	ResetAfter time.Duration
+	// 不允许时，下一个令牌可用需要等待的时长
+	RetryAfter time.Duration
+}
+
+// Store 令牌桶存储
+type Store interface {
+	// Allow 从key对应的桶里取一个令牌
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket 令牌桶状态
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take 补充令牌之后取一个令牌
+func (b *bucket) take(now time.Time, limit Limit) Result {
+	burst := float64(limit.burst())
+	interval := limit.interval()
+
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+float64(elapsed)/float64(interval))
+	}
+	b.last = now
+
+	result := Result{Limit: limit.burst()}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = time.Duration((burst - b.tokens) * float64(interval))
+	return result
+}
+
+// fullAfter 桶补满的时长，之后桶的状态和新建的一样，可以丢弃
+func (b *bucket) fullAfter(limit Limit) time.Duration {
+	return time.Duration((float64(limit.burst()) - b.tokens) * float64(limit.interval()))
+}
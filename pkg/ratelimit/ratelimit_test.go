@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBucket(t *testing.T) {
+	limit := Limit{Rate: 2, Period: time.Second, Burst: 3}
+	now := time.Now()
+	b := &bucket{}
+
+	for i := range 3 {
+		if r := b.take(now, limit); !r.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		} else if r.Remaining != 2-i {
+			t.Fatalf("expected remaining %d, got %d", 2-i, r.Remaining)
+		}
+	}
+
+	r := b.take(now, limit)
+	if r.Allowed {
+		t.Fatal("should be limited after burst")
+	} else if r.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("expected retry after 500ms, got %v", r.RetryAfter)
+	} else if r.ResetAfter != 1500*time.Millisecond {
+		t.Fatalf("expected reset after 1.5s, got %v", r.ResetAfter)
+	}
+
+	// 每500ms补充一个令牌
+	if r := b.take(now.Add(500*time.Millisecond), limit); !r.Allowed {
+		t.Fatal("should be allowed after refill")
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	limit := Limit{Rate: 10, Period: time.Hour, Burst: 2}
+
+	for i := range 2 {
+		if r, err := store.Allow(ctx, "foo", limit); err != nil {
+			t.Fatal(err)
+		} else if !r.Allowed || r.Limit != 2 || r.Remaining != 1-i {
+			t.Fatalf("request %d, unexpected result %+v", i, r)
+		}
+	}
+
+	if r, err := store.Allow(ctx, "foo", limit); err != nil {
+		t.Fatal(err)
+	} else if r.Allowed {
+		t.Fatal("should be limited after burst")
+	} else if r.RetryAfter <= 0 || r.RetryAfter > 6*time.Minute {
+		t.Fatalf("unexpected retry after %v", r.RetryAfter)
+	}
+
+	// 不同的key使用不同的桶
+	if r, err := store.Allow(ctx, "bar", limit); err != nil {
+		t.Fatal(err)
+	} else if !r.Allowed {
+		t.Fatal("other key should be allowed")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	testStore(t, store)
+
+	store.sweep(time.Now().Add(time.Hour))
+	if n := len(store.buckets); n != 0 {
+		t.Fatalf("full buckets should be swept, got %d", n)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	testStore(t, NewRedisStore(client, "ratelimit:"))
+
+	if ttl := server.TTL("ratelimit:foo"); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("unexpected ttl %v", ttl)
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 令牌桶状态保存在hash里，补满之后过期删除
+//
+// KEYS[1] 桶
+// ARGV[1] 当前时间，毫秒
+// ARGV[2] 补充一个令牌的时长，毫秒
+// ARGV[3] 桶容量
+var takeScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+
+if tokens == nil then
+	tokens = burst
+elseif now > last then
+	tokens = math.min(burst, tokens + (now - last) / interval)
+else
+	now = last
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+local ttl = math.ceil((burst - tokens) * interval)
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", now)
+redis.call("PEXPIRE", KEYS[1], math.max(ttl, 1))
+
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore redis存储，多个服务实例之间共享
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore 构造函数，prefix为key的前缀
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Allow 从key对应的桶里取一个令牌
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	interval := float64(limit.interval()) / float64(time.Millisecond)
+	values, err := takeScript.Run(ctx, s.client,
+		[]string{s.prefix + key},
+		time.Now().UnixMilli(), interval, limit.burst(),
+	).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("run ratelimit script, %w", err)
+	} else if len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected script result %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	v, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("parse tokens, %w", err)
+	}
+
+	burst := float64(limit.burst())
+	result := Result{
+		Allowed:    allowed == 1,
+		Limit:      limit.burst(),
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((burst - tokens) * float64(limit.interval())),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(limit.interval()))
+	}
+	return result, nil
+}
@@ -0,0 +1,88 @@
+// Package storage 文件存储
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidName 文件名不能包含上级目录或者绝对路径
+var ErrInvalidName = errors.New("invalid file name")
+
+// Local 本地目录存储，文件名可以包含子目录，例如exports/xxx.zip
+type Local struct {
+	dir string
+}
+
+// NewLocal 构造函数
+func NewLocal(dir string) (*Local, error) {
+	if dir == "" {
+		return nil, errors.New("need storage dir")
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage dir, %w", err)
+	}
+
+	return &Local{dir: dir}, nil
+}
+
+// Put 保存文件，返回写入的字节数
+//
+// 先写入临时文件再重命名，读取方不会读到写了一半的文件
+func (s *Local) Put(_ context.Context, name string, r io.Reader) (int64, error) {
+	file, err := s.path(name)
+	if err != nil {
+		return 0, err
+	} else if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return 0, fmt.Errorf("create dir, %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".tmp-*")
+	if err != nil {
+		return 0, fmt.Errorf("create temp file, %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("write file, %w", err)
+	} else if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("close file, %w", err)
+	} else if err := os.Rename(tmp.Name(), file); err != nil {
+		return 0, fmt.Errorf("rename file, %w", err)
+	}
+	return n, nil
+}
+
+// Open 打开文件，文件不存在时返回fs.ErrNotExist
+func (s *Local) Open(_ context.Context, name string) (io.ReadSeekCloser, error) {
+	file, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(file)
+}
+
+// Delete 删除文件，文件不存在时不返回错误
+func (s *Local) Delete(_ context.Context, name string) error {
+	file, err := s.path(name)
+	if err != nil {
+		return err
+	} else if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *Local) path(name string) (string, error) {
+	if !filepath.IsLocal(name) || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("%w, %q", ErrInvalidName, name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := s.Put(ctx, "exports/a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	} else if n != 5 {
+		t.Fatalf("expected 5 bytes, got %d", n)
+	}
+
+	f, err := s.Open(ctx, "exports/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	} else if string(data) != "hello" {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	if err := s.Delete(ctx, "exports/a.txt"); err != nil {
+		t.Fatal(err)
+	} else if err := s.Delete(ctx, "exports/a.txt"); err != nil {
+		t.Fatalf("delete missing file, %v", err)
+	} else if _, err := s.Open(ctx, "exports/a.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected %v, got %v", fs.ErrNotExist, err)
+	}
+
+	for _, name := range []string{"../a.txt", "/etc/passwd", "exports/../../a.txt", ""} {
+		if _, err := s.Put(ctx, name, strings.NewReader("x")); !errors.Is(err, ErrInvalidName) {
+			t.Fatalf("put %q, expected %v, got %v", name, ErrInvalidName, err)
+		}
+	}
+}
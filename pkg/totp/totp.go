@@ -0,0 +1,128 @@
+// Package totp 基于时间的一次性密码，RFC 6238
+//
+// 使用与主流验证器应用兼容的参数：HMAC-SHA1，6位数字，30秒周期
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" // #nosec G505 -- RFC 6238默认算法，验证器应用普遍只支持SHA1
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits 验证码位数
+	Digits = 6
+	// Period 验证码周期
+	Period = 30 * time.Second
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret 生成base32编码的随机密钥
+func GenerateSecret() (string, error) {
+	data := make([]byte, 20)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(data), nil
+}
+
+// Step 时间所在的周期序号
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Generate 生成指定时间的验证码
+func Generate(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return code(key, uint64(Step(t)), Digits), nil
+}
+
+// Validate 验证验证码，允许前后skew个周期的时钟误差
+//
+// 验证通过时返回验证码所在的周期序号，调用方可以记录下来拒绝重复使用
+func Validate(secret, passcode string, t time.Time, skew int) (step int64, ok bool) {
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) != Digits {
+		return 0, false
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := Step(t)
+	for i := -skew; i <= skew; i++ {
+		s := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(code(key, uint64(s), Digits)), []byte(passcode)) == 1 {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// URI 验证器应用使用的provisioning uri，一般以二维码形式展示
+//
+//	otpauth://totp/<issuer>:<account>?secret=<secret>&issuer=<issuer>
+func URI(issuer, account, secret string) string {
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprintf("%d", Digits))
+	query.Set("period", fmt.Sprintf("%d", int(Period/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := encoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret, %w", err)
+	}
+	return key, nil
+}
+
+// code HOTP算法，RFC 4226
+func code(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
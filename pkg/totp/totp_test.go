@@ -0,0 +1,86 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 Appendix D
+	key := []byte("12345678901234567890")
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for i, want := range expected {
+		if got := code(key, uint64(i), 6); got != want {
+			t.Fatalf("counter %d, expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 Appendix B, SHA1
+	key := []byte("12345678901234567890")
+	cases := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+
+	for ts, want := range cases {
+		if got := code(key, uint64(Step(time.Unix(ts, 0))), 8); got != want {
+			t.Fatalf("time %d, expected %s, got %s", ts, want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	passcode, err := Generate(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if step, ok := Validate(secret, passcode, now, 1); !ok {
+		t.Fatal("validate current passcode")
+	} else if step != Step(now) {
+		t.Fatalf("expected step %d, got %d", Step(now), step)
+	}
+
+	if _, ok := Validate(secret, passcode, now.Add(Period), 1); !ok {
+		t.Fatal("validate passcode within skew")
+	} else if _, ok := Validate(secret, passcode, now.Add(3*Period), 1); ok {
+		t.Fatal("passcode out of skew should be rejected")
+	}
+
+	// 验证器应用显示的密钥可能是小写或者带空格的
+	loose := strings.ToLower(secret[:4] + " " + secret[4:])
+	if _, ok := Validate(loose, passcode, now, 0); !ok {
+		t.Fatal("validate with loose secret")
+	}
+
+	if _, ok := Validate(base32.StdEncoding.EncodeToString([]byte("other")), passcode, now, 1); ok {
+		t.Fatal("validate with other secret should fail")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Example", "alice@example.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/Example:alice@example.com?") {
+		t.Fatalf("unexpected uri %s", uri)
+	} else if !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(uri, "issuer=Example") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+}